@@ -0,0 +1,236 @@
+// Package auracassette records Aura API traffic to a cassette file and
+// replays it, so tests can run deterministically and offline against
+// responses captured from the real API.
+//
+// A Recorder is an http.RoundTripper that plugs into the client with
+// aura.WithTransport:
+//
+//	rec, err := auracassette.New("testdata/list_instances.yaml", auracassette.ModeReplayOrRecord)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	client, err := aura.NewClient(
+//		aura.WithCredentials(clientID, clientSecret),
+//		aura.WithTransport(rec),
+//	)
+//
+// In record mode every request is sent to the real API and the interaction is
+// written to the cassette, with the Authorization header, the client secret,
+// access tokens and instance passwords redacted. In replay mode requests are
+// answered from the cassette and never reach the network.
+package auracassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Redacted replaces every redacted value in a cassette.
+const Redacted = "[REDACTED]"
+
+// cassetteVersion is written to every cassette and checked on load.
+const cassetteVersion = 1
+
+// Cassette is the on-disk record of a sequence of interactions.
+type Cassette struct {
+	Version      int           `yaml:"version"`
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Request is a recorded request. Incoming requests are converted to the same
+// form before they are matched.
+type Request struct {
+	Method string      `yaml:"method"`
+	URL    string      `yaml:"url"`
+	Header http.Header `yaml:"header,omitempty"`
+	Body   string      `yaml:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `yaml:"status"`
+	Header http.Header `yaml:"header,omitempty"`
+	Body   string      `yaml:"body,omitempty"`
+}
+
+// defaultRedactedHeaders are always redacted from recorded requests.
+var defaultRedactedHeaders = []string{"Authorization"}
+
+// defaultRedactedFields are JSON object keys, and form fields, whose values
+// are always redacted. password covers CreateInstanceData.Password.
+var defaultRedactedFields = []string{"password", "client_secret", "access_token"}
+
+// ============================================================================
+// Loading and saving
+// ============================================================================
+
+// Load reads a cassette from path.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if c.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette %s has version %d, want %d", path, c.Version, cassetteVersion)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path atomically, creating parent directories.
+func (c *Cassette) Save(path string) error {
+	c.Version = cassetteVersion
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cassette-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ============================================================================
+// Redaction
+// ============================================================================
+
+// redactor removes secrets from interactions before they are recorded.
+type redactor struct {
+	headers []string
+	fields  map[string]bool
+	secrets []string
+}
+
+func newRedactor(headers, fields, secrets []string) *redactor {
+	r := &redactor{fields: make(map[string]bool)}
+	for _, h := range append(append([]string(nil), defaultRedactedHeaders...), headers...) {
+		r.headers = append(r.headers, http.CanonicalHeaderKey(h))
+	}
+	for _, f := range append(append([]string(nil), defaultRedactedFields...), fields...) {
+		r.fields[strings.ToLower(f)] = true
+	}
+	for _, s := range secrets {
+		if s != "" {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// Replace longer secrets first so one that contains another is removed
+	// whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// interaction returns a redacted copy of in.
+func (r *redactor) interaction(in Interaction) Interaction {
+	in.Request.Header = r.header(in.Request.Header)
+	in.Request.Body = r.body(in.Request.Header.Get("Content-Type"), in.Request.Body)
+	in.Request.URL = r.text(in.Request.URL)
+	in.Response.Header = r.header(in.Response.Header)
+	in.Response.Body = r.body(in.Response.Header.Get("Content-Type"), in.Response.Body)
+	return in
+}
+
+func (r *redactor) header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			out.Add(k, r.text(v))
+		}
+	}
+	for _, name := range r.headers {
+		if _, ok := out[name]; ok {
+			out[name] = []string{Redacted}
+		}
+	}
+	return out
+}
+
+// body redacts configured fields from JSON and form bodies, then any literal
+// secrets.
+func (r *redactor) body(contentType, body string) string {
+	if body == "" {
+		return body
+	}
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		var v any
+		if err := json.Unmarshal([]byte(body), &v); err == nil {
+			if out, err := json.Marshal(r.json(v)); err == nil {
+				body = string(out)
+			}
+		}
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		if form, err := url.ParseQuery(body); err == nil {
+			for k := range form {
+				if r.fields[strings.ToLower(k)] {
+					form.Set(k, Redacted)
+				}
+			}
+			body = form.Encode()
+		}
+	}
+	return r.text(body)
+}
+
+// json redacts configured keys at any depth of a decoded JSON value.
+func (r *redactor) json(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if r.fields[strings.ToLower(k)] {
+				if _, isString := child.(string); isString {
+					v[k] = Redacted
+					continue
+				}
+			}
+			v[k] = r.json(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = r.json(child)
+		}
+	}
+	return v
+}
+
+// text replaces literal secrets.
+func (r *redactor) text(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
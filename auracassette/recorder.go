@@ -0,0 +1,388 @@
+package auracassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay answers requests from an existing cassette.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real API and records them to a new
+	// cassette, replacing any existing file.
+	ModeRecord
+	// ModeReplayOrRecord replays if the cassette exists and records
+	// otherwise.
+	ModeReplayOrRecord
+)
+
+// ErrUnmatched is returned in strict mode for a request that matches no
+// recorded interaction.
+var ErrUnmatched = errors.New("auracassette: no recorded interaction matches request")
+
+// Matcher reports whether an incoming request matches a recorded one. Both
+// are in recorded form: the incoming request has not been redacted.
+type Matcher func(incoming, recorded *Request) bool
+
+// Option configures a Recorder.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	transport http.RoundTripper
+	matchers  []Matcher
+	strict    bool
+	headers   []string
+	fields    []string
+	secrets   []string
+}
+
+// Recorder records or replays HTTP interactions. It implements
+// http.RoundTripper and is safe for concurrent use.
+type Recorder struct {
+	path     string
+	mode     Mode
+	opts     options
+	redactor *redactor
+
+	mu        sync.Mutex
+	cassette  *Cassette
+	used      []bool
+	unmatched []Request
+}
+
+// ============================================================================
+// Matchers
+// ============================================================================
+
+// MatchMethod matches requests with the same HTTP method.
+func MatchMethod(incoming, recorded *Request) bool {
+	return incoming.Method == recorded.Method
+}
+
+// MatchPath matches requests with the same URL path, ignoring scheme and
+// host, so a cassette recorded against one base URL replays against another.
+func MatchPath(incoming, recorded *Request) bool {
+	return urlPart(incoming.URL, func(u *url.URL) string { return u.Path }) ==
+		urlPart(recorded.URL, func(u *url.URL) string { return u.Path })
+}
+
+// MatchQuery matches requests with the same query parameters, in any order.
+func MatchQuery(incoming, recorded *Request) bool {
+	parse := func(raw string) url.Values {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil
+		}
+		return u.Query()
+	}
+	return reflect.DeepEqual(parse(incoming.URL), parse(recorded.URL))
+}
+
+// MatchBody matches requests with the same body. JSON bodies are compared
+// semantically, so key order and whitespace do not matter, and a redacted
+// value in the recording matches any value.
+func MatchBody(incoming, recorded *Request) bool {
+	if incoming.Body == recorded.Body {
+		return true
+	}
+	var a, b any
+	if json.Unmarshal([]byte(incoming.Body), &a) != nil || json.Unmarshal([]byte(recorded.Body), &b) != nil {
+		return false
+	}
+	return jsonEqual(a, b)
+}
+
+// MatchHeader returns a matcher for requests with the same values of the
+// named header.
+func MatchHeader(name string) Matcher {
+	return func(incoming, recorded *Request) bool {
+		return reflect.DeepEqual(incoming.Header.Values(name), recorded.Header.Values(name))
+	}
+}
+
+// DefaultMatchers match on method, path and body.
+func DefaultMatchers() []Matcher {
+	return []Matcher{MatchMethod, MatchPath, MatchBody}
+}
+
+// urlPart returns part of a parsed URL, or the raw string if it cannot be
+// parsed.
+func urlPart(raw string, part func(*url.URL) string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return part(u)
+}
+
+// jsonEqual compares decoded JSON values, treating a recorded Redacted string
+// as equal to anything.
+func jsonEqual(incoming, recorded any) bool {
+	if s, ok := recorded.(string); ok && s == Redacted {
+		return true
+	}
+	switch r := recorded.(type) {
+	case map[string]any:
+		in, ok := incoming.(map[string]any)
+		if !ok || len(in) != len(r) {
+			return false
+		}
+		for k, v := range r {
+			if !jsonEqual(in[k], v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		in, ok := incoming.([]any)
+		if !ok || len(in) != len(r) {
+			return false
+		}
+		for i := range r {
+			if !jsonEqual(in[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(incoming, recorded)
+	}
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithTransport sets the transport used to reach the real API when recording,
+// and for unmatched requests when replaying without strict mode. Defaults to
+// http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithMatchers replaces DefaultMatchers. A request matches a recorded
+// interaction when every matcher agrees.
+func WithMatchers(matchers ...Matcher) Option {
+	return func(o *options) {
+		o.matchers = matchers
+	}
+}
+
+// WithStrict makes replay fail with ErrUnmatched for any request with no
+// matching interaction, instead of passing it to the real API. Unmatched
+// requests are also reported by Unmatched.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithRedactedHeaders redacts more request and response headers, in addition
+// to Authorization.
+func WithRedactedHeaders(names ...string) Option {
+	return func(o *options) {
+		o.headers = append(o.headers, names...)
+	}
+}
+
+// WithRedactedFields redacts more JSON keys and form fields, in addition to
+// password, client_secret and access_token. Names are case-insensitive.
+func WithRedactedFields(names ...string) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, names...)
+	}
+}
+
+// WithSecrets redacts literal values, such as the client ID and secret,
+// wherever they appear in a recorded URL, header or body.
+func WithSecrets(values ...string) Option {
+	return func(o *options) {
+		o.secrets = append(o.secrets, values...)
+	}
+}
+
+// ============================================================================
+// Recorder
+// ============================================================================
+
+// New returns a Recorder for the cassette at path. ModeReplay requires the
+// cassette to exist; ModeRecord starts an empty cassette, written on every
+// recorded interaction.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	o := options{transport: http.DefaultTransport, matchers: DefaultMatchers()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.transport == nil {
+		return nil, errors.New("transport cannot be nil")
+	}
+
+	if mode == ModeReplayOrRecord {
+		mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			mode = ModeReplay
+		}
+	}
+
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		opts:     o,
+		redactor: newRedactor(o.headers, o.fields, o.secrets),
+	}
+	switch mode {
+	case ModeReplay:
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	case ModeRecord:
+		r.cassette = &Cassette{Version: cassetteVersion}
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+	return r, nil
+}
+
+// Mode returns ModeReplay or ModeRecord, resolving ModeReplayOrRecord.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Unmatched returns the requests that matched no recorded interaction.
+func (r *Recorder) Unmatched() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.unmatched...)
+}
+
+// RoundTrip records or replays one request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	incoming, err := captureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, incoming)
+	}
+	return r.replay(req, incoming)
+}
+
+// record sends req to the real API and appends the redacted interaction.
+func (r *Recorder) record(req *http.Request, incoming Request) (*http.Response, error) {
+	resp, err := r.opts.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	in := r.redactor.interaction(Interaction{
+		Request:  incoming,
+		Response: Response{Status: resp.StatusCode, Header: resp.Header.Clone(), Body: string(body)},
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, fmt.Errorf("failed to save cassette: %w", err)
+	}
+	return resp, nil
+}
+
+// replay answers req from the first unused matching interaction. Once every
+// match has been used the last one is repeated, so polling loops replay
+// their final state.
+func (r *Recorder) replay(req *http.Request, incoming Request) (*http.Response, error) {
+	r.mu.Lock()
+	found, last := -1, -1
+	for i := range r.cassette.Interactions {
+		if !r.matches(&incoming, &r.cassette.Interactions[i].Request) {
+			continue
+		}
+		if !r.used[i] {
+			found = i
+			break
+		}
+		last = i
+	}
+	if found < 0 {
+		found = last
+	}
+	if found < 0 {
+		r.unmatched = append(r.unmatched, incoming)
+		r.mu.Unlock()
+		if r.opts.strict {
+			return nil, fmt.Errorf("%w: %s %s", ErrUnmatched, incoming.Method, incoming.URL)
+		}
+		return r.opts.transport.RoundTrip(req)
+	}
+	r.used[found] = true
+	recorded := r.cassette.Interactions[found].Response
+	r.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// matches reports whether every matcher accepts the pair. Callers hold r.mu.
+func (r *Recorder) matches(incoming, recorded *Request) bool {
+	for _, m := range r.opts.matchers {
+		if !m(incoming, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// captureRequest converts req to recorded form, restoring its body so it can
+// still be sent.
+func captureRequest(req *http.Request) (Request, error) {
+	out := Request{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+	if req.Body == nil || req.Body == http.NoBody {
+		return out, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return Request{}, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	out.Body = string(body)
+	return out, nil
+}
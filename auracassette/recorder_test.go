@@ -0,0 +1,157 @@
+package auracassette_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auracassette"
+	"github.com/LackOfMorals/aura-client/auratest"
+)
+
+func newRecorder(t *testing.T, path string, mode auracassette.Mode, opts ...auracassette.Option) *auracassette.Recorder {
+	t.Helper()
+	rec, err := auracassette.New(path, mode, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return rec
+}
+
+func newClient(t *testing.T, baseURL string, rec *auracassette.Recorder) *aura.AuraAPIClient {
+	t.Helper()
+	client, err := aura.NewClient(
+		aura.WithCredentials(auratest.ClientID, auratest.ClientSecret),
+		aura.WithInsecureBaseURL(baseURL),
+		aura.WithTransport(rec),
+		aura.WithMaxRetry(1),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+// session creates an instance and polls it until it is running.
+func session(t *testing.T, client *aura.AuraAPIClient) (*aura.CreateInstanceResponse, []aura.InstanceStatus) {
+	t.Helper()
+	ctx := context.Background()
+	created, err := client.Instances.Create(ctx, &aura.CreateInstanceConfigData{
+		Name: "cassette", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var statuses []aura.InstanceStatus
+	for i := 0; i < 3; i++ {
+		inst, err := client.Instances.Get(ctx, created.Data.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		statuses = append(statuses, inst.Data.Status)
+	}
+	return created, statuses
+}
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "create.yaml")
+
+	srv := auratest.NewServer()
+	rec := newRecorder(t, path, auracassette.ModeReplayOrRecord, auracassette.WithSecrets(auratest.ClientID))
+	if rec.Mode() != auracassette.ModeRecord {
+		t.Fatalf("Expected record mode for a missing cassette, got %v", rec.Mode())
+	}
+	recorded, recordedStatuses := session(t, newClient(t, srv.URL, rec))
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected cassette to be written, got %v", err)
+	}
+	for _, secret := range []string{auratest.ClientID, auratest.ClientSecret, recorded.Data.Password, "Bearer ", "Basic "} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be redacted from the cassette", secret)
+		}
+	}
+
+	// The server is gone, so everything must come from the cassette.
+	rec = newRecorder(t, path, auracassette.ModeReplayOrRecord, auracassette.WithStrict())
+	if rec.Mode() != auracassette.ModeReplay {
+		t.Fatalf("Expected replay mode for an existing cassette, got %v", rec.Mode())
+	}
+	replayed, replayedStatuses := session(t, newClient(t, "http://replay.invalid", rec))
+
+	if replayed.Data.ID != recorded.Data.ID || replayed.Data.Password != auracassette.Redacted {
+		t.Errorf("Unexpected replayed create response %+v", replayed.Data)
+	}
+	for i := range recordedStatuses {
+		if replayedStatuses[i] != recordedStatuses[i] {
+			t.Errorf("poll %d: expected %s, got %s", i, recordedStatuses[i], replayedStatuses[i])
+		}
+	}
+	if recordedStatuses[0] != aura.StatusCreating || recordedStatuses[2] != aura.StatusRunning {
+		t.Errorf("Expected the transition to be recorded, got %v", recordedStatuses)
+	}
+	if n := len(rec.Unmatched()); n != 0 {
+		t.Errorf("Expected every request to match, got %d unmatched", n)
+	}
+}
+
+func TestRecorder_StrictUnmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.yaml")
+	if err := (&auracassette.Cassette{}).Save(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rec := newRecorder(t, path, auracassette.ModeReplay, auracassette.WithStrict())
+
+	_, err := (&http.Client{Transport: rec}).Get("https://api.neo4j.io/v1/tenants")
+	if !errors.Is(err, auracassette.ErrUnmatched) {
+		t.Errorf("Expected ErrUnmatched, got %v", err)
+	}
+	if u := rec.Unmatched(); len(u) != 1 || u[0].Method != http.MethodGet {
+		t.Errorf("Expected the request to be reported, got %+v", u)
+	}
+}
+
+func TestRecorder_ReplayMissingCassette(t *testing.T) {
+	if _, err := auracassette.New(filepath.Join(t.TempDir(), "missing.yaml"), auracassette.ModeReplay); err == nil {
+		t.Error("Expected an error for a missing cassette")
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	recorded := &auracassette.Request{
+		Method: http.MethodPost,
+		URL:    "https://api.neo4j.io/v1/instances?b=2&a=1",
+		Header: http.Header{"X-Trace": {"1"}},
+		Body:   `{"name":"prod","password":"[REDACTED]","memory":"8GB"}`,
+	}
+	tests := []struct {
+		name    string
+		matcher auracassette.Matcher
+		in      auracassette.Request
+		want    bool
+	}{
+		{"path ignores host", auracassette.MatchPath, auracassette.Request{URL: "http://127.0.0.1:8080/v1/instances"}, true},
+		{"path differs", auracassette.MatchPath, auracassette.Request{URL: "http://127.0.0.1/v1/tenants"}, false},
+		{"query in any order", auracassette.MatchQuery, auracassette.Request{URL: "http://x/v1/instances?a=1&b=2"}, true},
+		{"query differs", auracassette.MatchQuery, auracassette.Request{URL: "http://x/v1/instances?a=1"}, false},
+		{"json body, reordered, redacted value", auracassette.MatchBody, auracassette.Request{Body: `{ "memory": "8GB", "name": "prod", "password": "hunter2" }`}, true},
+		{"json body differs", auracassette.MatchBody, auracassette.Request{Body: `{"name":"dev","password":"x","memory":"8GB"}`}, false},
+		{"header", auracassette.MatchHeader("X-Trace"), auracassette.Request{Header: http.Header{"X-Trace": {"1"}}}, true},
+		{"header missing", auracassette.MatchHeader("X-Trace"), auracassette.Request{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matcher(&tt.in, recorded); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
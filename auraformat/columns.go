@@ -0,0 +1,347 @@
+package auraformat
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+)
+
+// Column is one selectable column of a table or CSV.
+type Column struct {
+	// Name selects the column in Options.Columns and heads it in CSV output;
+	// tables print it upper-cased.
+	Name string
+	// Secret columns print Redacted unless Options.ShowSecrets is set.
+	Secret bool
+	// Value returns the cell text for one element.
+	Value func(v any) string
+}
+
+// columnSet is the columns of one type and the names shown by default.
+type columnSet struct {
+	columns  []Column
+	defaults []string
+}
+
+// registry holds the curated columns, keyed by element type.
+var registry = map[reflect.Type]columnSet{}
+
+func register[T any](defaults []string, columns ...Column) {
+	registry[reflect.TypeFor[T]()] = columnSet{columns: columns, defaults: defaults}
+}
+
+// col returns a column reading a T.
+func col[T any](name string, value func(T) string) Column {
+	return Column{Name: name, Value: func(v any) string { return value(v.(T)) }}
+}
+
+func init() {
+	register[aura.ListInstanceData](
+		[]string{"id", "name", "tenant_id", "cloud_provider", "created_at"},
+		col("id", func(d aura.ListInstanceData) string { return d.ID }),
+		col("name", func(d aura.ListInstanceData) string { return d.Name }),
+		col("tenant_id", func(d aura.ListInstanceData) string { return d.TenantID }),
+		col("cloud_provider", func(d aura.ListInstanceData) string { return d.CloudProvider }),
+		col("created_at", func(d aura.ListInstanceData) string { return d.Created }),
+	)
+
+	register[aura.InstanceData](
+		[]string{"id", "name", "status", "cloud_provider", "region", "type", "memory"},
+		col("id", func(d aura.InstanceData) string { return d.ID }),
+		col("name", func(d aura.InstanceData) string { return d.Name }),
+		col("status", func(d aura.InstanceData) string { return string(d.Status) }),
+		col("tenant_id", func(d aura.InstanceData) string { return d.TenantID }),
+		col("cloud_provider", func(d aura.InstanceData) string { return d.CloudProvider }),
+		col("region", func(d aura.InstanceData) string { return d.Region }),
+		col("type", func(d aura.InstanceData) string { return d.Type }),
+		col("memory", func(d aura.InstanceData) string { return d.Memory }),
+		col("storage", func(d aura.InstanceData) string {
+			if d.Storage == nil {
+				return ""
+			}
+			return *d.Storage
+		}),
+		col("connection_url", func(d aura.InstanceData) string { return d.ConnectionURL }),
+		col("metrics_integration_url", func(d aura.InstanceData) string { return d.MetricsURL }),
+		col("secondaries_count", func(d aura.InstanceData) string { return strconv.Itoa(d.Secondaries) }),
+		col("cdc_enrichment_mode", func(d aura.InstanceData) string { return d.CDCEnrichment }),
+		col("graph_analytics_plugin", func(d aura.InstanceData) string { return strconv.FormatBool(d.GDSPlugin) }),
+		col("vector_optimized", func(d aura.InstanceData) string { return strconv.FormatBool(d.VectorOptimized) }),
+	)
+
+	password := col("password", func(d aura.CreateInstanceData) string { return d.Password })
+	password.Secret = true
+	register[aura.CreateInstanceData](
+		[]string{"id", "name", "connection_url", "username", "password"},
+		col("id", func(d aura.CreateInstanceData) string { return d.ID }),
+		col("name", func(d aura.CreateInstanceData) string { return d.Name }),
+		col("tenant_id", func(d aura.CreateInstanceData) string { return d.TenantID }),
+		col("cloud_provider", func(d aura.CreateInstanceData) string { return d.CloudProvider }),
+		col("region", func(d aura.CreateInstanceData) string { return d.Region }),
+		col("type", func(d aura.CreateInstanceData) string { return d.Type }),
+		col("connection_url", func(d aura.CreateInstanceData) string { return d.ConnectionURL }),
+		col("username", func(d aura.CreateInstanceData) string { return d.Username }),
+		password,
+	)
+
+	register[aura.GetSnapshotData](
+		[]string{"snapshot_id", "status", "profile", "timestamp", "exportable"},
+		col("snapshot_id", func(d aura.GetSnapshotData) string { return d.SnapshotID }),
+		col("instance_id", func(d aura.GetSnapshotData) string { return d.InstanceID }),
+		col("status", func(d aura.GetSnapshotData) string { return d.Status }),
+		col("profile", func(d aura.GetSnapshotData) string { return d.Profile }),
+		col("timestamp", func(d aura.GetSnapshotData) string { return formatTime(d.Timestamp) }),
+		col("exportable", func(d aura.GetSnapshotData) string { return strconv.FormatBool(d.Exportable) }),
+	)
+
+	register[aura.GetGDSSessionData](
+		[]string{"id", "name", "status", "memory", "instance_id", "expiry_date"},
+		col("id", func(d aura.GetGDSSessionData) string { return d.ID }),
+		col("name", func(d aura.GetGDSSessionData) string { return d.Name }),
+		col("status", func(d aura.GetGDSSessionData) string { return d.Status }),
+		col("memory", func(d aura.GetGDSSessionData) string { return d.Memory }),
+		col("instance_id", func(d aura.GetGDSSessionData) string { return d.InstanceID }),
+		col("database_uuid", func(d aura.GetGDSSessionData) string { return d.DatabaseID }),
+		col("tenant_id", func(d aura.GetGDSSessionData) string { return d.TenantID }),
+		col("cloud_provider", func(d aura.GetGDSSessionData) string { return d.CloudProvider }),
+		col("region", func(d aura.GetGDSSessionData) string { return d.Region }),
+		col("host", func(d aura.GetGDSSessionData) string { return d.Host }),
+		col("ttl", func(d aura.GetGDSSessionData) string { return d.TTL }),
+		col("user_id", func(d aura.GetGDSSessionData) string { return d.UserID }),
+		col("created_at", func(d aura.GetGDSSessionData) string { return formatTime(d.CreatedAt) }),
+		col("expiry_date", func(d aura.GetGDSSessionData) string { return formatTime(d.ExpiresAt) }),
+	)
+
+	register[aura.PrometheusHealthMetrics](
+		[]string{"instance_id", "overall_status", "cpu_usage_percent", "memory_usage_percent",
+			"queries_per_second", "avg_latency_ms", "connection_usage_percent", "issues"},
+		col("instance_id", func(d aura.PrometheusHealthMetrics) string { return d.InstanceID }),
+		col("timestamp", func(d aura.PrometheusHealthMetrics) string { return formatTime(d.Timestamp) }),
+		col("overall_status", func(d aura.PrometheusHealthMetrics) string { return d.OverallStatus }),
+		col("cpu_usage_percent", func(d aura.PrometheusHealthMetrics) string { return formatFloat(d.Resources.CPUUsagePercent) }),
+		col("memory_usage_percent", func(d aura.PrometheusHealthMetrics) string { return formatFloat(d.Resources.MemoryUsagePercent) }),
+		col("queries_per_second", func(d aura.PrometheusHealthMetrics) string { return formatFloat(d.Query.QueriesPerSecond) }),
+		col("avg_latency_ms", func(d aura.PrometheusHealthMetrics) string { return formatFloat(d.Query.AvgLatencyMS) }),
+		col("active_connections", func(d aura.PrometheusHealthMetrics) string { return strconv.Itoa(d.Connections.ActiveConnections) }),
+		col("max_connections", func(d aura.PrometheusHealthMetrics) string { return strconv.Itoa(d.Connections.MaxConnections) }),
+		col("connection_usage_percent", func(d aura.PrometheusHealthMetrics) string { return formatFloat(d.Connections.UsagePercent) }),
+		col("page_cache_hit_rate", func(d aura.PrometheusHealthMetrics) string { return formatFloat(d.Storage.PageCacheHitRate) }),
+		col("issues", func(d aura.PrometheusHealthMetrics) string { return strings.Join(d.Issues, "; ") }),
+		col("recommendations", func(d aura.PrometheusHealthMetrics) string { return strings.Join(d.Recommendations, "; ") }),
+		col("anomalies", func(d aura.PrometheusHealthMetrics) string { return strconv.Itoa(len(d.Anomalies)) }),
+	)
+
+	register[aura.InstanceEvent](
+		[]string{"time", "instance_id", "type", "before", "after"},
+		col("time", func(e aura.InstanceEvent) string { return formatTime(e.Time) }),
+		col("instance_id", func(e aura.InstanceEvent) string { return e.InstanceID }),
+		col("type", func(e aura.InstanceEvent) string { return string(e.Type) }),
+		col("before", func(e aura.InstanceEvent) string { return e.Before }),
+		col("after", func(e aura.InstanceEvent) string { return e.After }),
+		col("name", func(e aura.InstanceEvent) string {
+			if e.Instance == nil {
+				return ""
+			}
+			return e.Instance.Name
+		}),
+		col("error", func(e aura.InstanceEvent) string { return errorText(e.Err) }),
+	)
+
+	register[aura.GDSSessionEvent](
+		[]string{"time", "session_id", "type", "before", "after"},
+		col("time", func(e aura.GDSSessionEvent) string { return formatTime(e.Time) }),
+		col("session_id", func(e aura.GDSSessionEvent) string { return e.SessionID }),
+		col("type", func(e aura.GDSSessionEvent) string { return string(e.Type) }),
+		col("before", func(e aura.GDSSessionEvent) string { return e.Before }),
+		col("after", func(e aura.GDSSessionEvent) string { return e.After }),
+		col("name", func(e aura.GDSSessionEvent) string {
+			if e.Session == nil {
+				return ""
+			}
+			return e.Session.Name
+		}),
+		col("error", func(e aura.GDSSessionEvent) string { return errorText(e.Err) }),
+	)
+
+	register[aura.PlanStep](
+		[]string{"action", "name", "tenant_id", "instance_id", "changes"},
+		col("action", func(s aura.PlanStep) string { return string(s.Action) }),
+		col("name", func(s aura.PlanStep) string { return s.Name }),
+		col("tenant_id", func(s aura.PlanStep) string { return s.TenantID }),
+		col("instance_id", func(s aura.PlanStep) string { return s.InstanceID }),
+		col("changes", func(s aura.PlanStep) string { return formatChanges(s.Changes) }),
+	)
+
+	register[aura.ApplyResult](
+		[]string{"action", "name", "instance_id", "applied", "error"},
+		col("action", func(r aura.ApplyResult) string { return string(r.Step.Action) }),
+		col("name", func(r aura.ApplyResult) string { return r.Step.Name }),
+		col("tenant_id", func(r aura.ApplyResult) string { return r.Step.TenantID }),
+		col("instance_id", func(r aura.ApplyResult) string { return r.InstanceID }),
+		col("changes", func(r aura.ApplyResult) string { return formatChanges(r.Step.Changes) }),
+		col("applied", func(r aura.ApplyResult) string { return strconv.FormatBool(r.Applied) }),
+		col("error", func(r aura.ApplyResult) string { return r.Error }),
+	)
+
+	register[aura.Drift](
+		[]string{"instance_id", "name", "kind", "field", "expected", "actual"},
+		col("instance_id", func(d aura.Drift) string { return d.InstanceID }),
+		col("name", func(d aura.Drift) string { return d.Name }),
+		col("tenant_id", func(d aura.Drift) string { return d.TenantID }),
+		col("kind", func(d aura.Drift) string { return string(d.Kind) }),
+		col("field", func(d aura.Drift) string { return d.Field }),
+		col("expected", func(d aura.Drift) string { return d.Expected }),
+		col("actual", func(d aura.Drift) string { return d.Actual }),
+	)
+}
+
+// Columns returns the names of every column available for v, which may be a
+// value, a pointer or a slice. The default columns come first.
+func Columns(v any) []string {
+	typ, _ := elements(v)
+	set := columnsOf(typ)
+	names := append([]string(nil), set.defaults...)
+	for _, c := range set.columns {
+		if !contains(names, c.Name) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// columnsOf returns the columns of an element type: the curated ones if
+// registered, otherwise one per exported struct field, named as in JSON, or a
+// single "value" column for anything that is not a struct.
+func columnsOf(typ reflect.Type) columnSet {
+	if typ == nil {
+		return columnSet{}
+	}
+	if set, ok := registry[typ]; ok {
+		return set
+	}
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		return columnSet{
+			columns:  []Column{{Name: "value", Value: func(v any) string { return render(reflect.ValueOf(v)) }}},
+			defaults: []string{"value"},
+		}
+	}
+	var set columnSet
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		index := i
+		set.columns = append(set.columns, Column{Name: name, Value: func(v any) string {
+			return render(reflect.ValueOf(v).Field(index))
+		}})
+		set.defaults = append(set.defaults, name)
+	}
+	return set
+}
+
+// jsonName returns the name encoding/json gives an exported field, and false
+// for fields it skips.
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+// table returns the selected columns and the rendered rows of v.
+func table(v any, selected []string, showSecrets bool) ([]Column, [][]string, error) {
+	typ, elems := elements(v)
+	set := columnsOf(typ)
+	if len(selected) == 0 {
+		selected = set.defaults
+	}
+	cols := make([]Column, 0, len(selected))
+	for _, name := range selected {
+		c, ok := find(set.columns, name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown column %q: available columns are %s", name, strings.Join(Columns(v), ", "))
+		}
+		cols = append(cols, c)
+	}
+
+	rows := make([][]string, len(elems))
+	for i, e := range elems {
+		row := make([]string, len(cols))
+		for j, c := range cols {
+			row[j] = c.Value(e.Interface())
+			if c.Secret && !showSecrets && row[j] != "" {
+				row[j] = Redacted
+			}
+		}
+		rows[i] = row
+	}
+	return cols, rows, nil
+}
+
+func find(columns []Column, name string) (Column, bool) {
+	for _, c := range columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// formatTime renders a time in RFC 3339, UTC, and the zero time as empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// formatFloat renders a float with at most two decimals.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// formatChanges renders plan changes as "field: before -> after", omitting
+// the before value when there is none.
+func formatChanges(changes []aura.PlanChange) string {
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		if c.Before == "" {
+			parts[i] = fmt.Sprintf("%s: %s", c.Field, c.After)
+		} else {
+			parts[i] = fmt.Sprintf("%s: %s -> %s", c.Field, c.Before, c.After)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
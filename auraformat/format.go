@@ -0,0 +1,437 @@
+// Package auraformat renders Aura API responses for people and scripts: as an
+// aligned table, JSON, YAML, CSV or through a Go template.
+//
+//	resp, err := client.Instances.List(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = auraformat.Write(os.Stdout, resp.Data, auraformat.Options{
+//		Format:  auraformat.FormatTable,
+//		Columns: []string{"id", "name", "cloud_provider"},
+//	})
+//
+// Write accepts a single value, a pointer or a slice. Tables and CSV have
+// curated columns for the common resource types and derive columns from the
+// JSON field names of any other struct; see Columns. Secrets such as the
+// password returned when an instance is created are replaced by Redacted in
+// every format unless Options.ShowSecrets is set, however deeply they are
+// nested; string fields of other types tagged `auraformat:"secret"` are
+// redacted too.
+package auraformat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Format selects how Write renders a value.
+type Format string
+
+// Supported formats.
+const (
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatCSV      Format = "csv"
+	FormatTemplate Format = "template"
+)
+
+// Formats lists every supported format, in the order they are documented.
+var Formats = []Format{FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatTemplate}
+
+// Redacted replaces secret values in the output.
+const Redacted = "[REDACTED]"
+
+// Options controls how Write renders a value.
+type Options struct {
+	// Format defaults to FormatTable.
+	Format Format
+	// Columns selects and orders the columns of a table or CSV. Empty means
+	// the default columns of the value's type.
+	Columns []string
+	// Template is the text/template executed for FormatTemplate. It runs
+	// once, with the value itself (after redaction) as dot, so a list is
+	// ranged over with {{range .}}. The json function renders its argument
+	// as compact JSON.
+	Template string
+	// NoHeaders omits the header row of a table or CSV.
+	NoHeaders bool
+	// ShowSecrets disables redaction.
+	ShowSecrets bool
+}
+
+// ============================================================================
+// Writing
+// ============================================================================
+
+// ParseFormat returns the Format named by s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown output format %q: must be one of %s", s, strings.Join(names, ", "))
+}
+
+// Write renders v to w.
+func Write(w io.Writer, v any, opts Options) error {
+	format := opts.Format
+	if format == "" {
+		format = FormatTable
+	}
+	if len(opts.Columns) > 0 && format != FormatTable && format != FormatCSV {
+		return fmt.Errorf("columns can only be selected for table and csv output, not %s", format)
+	}
+	if format == FormatTemplate && opts.Template == "" {
+		return fmt.Errorf("template output requires a template")
+	}
+	if !opts.ShowSecrets {
+		v = redact(v)
+	}
+
+	switch format {
+	case FormatTable, FormatCSV:
+		cols, rows, err := table(v, opts.Columns, opts.ShowSecrets)
+		if err != nil {
+			return err
+		}
+		if format == FormatCSV {
+			return writeCSV(w, cols, rows, opts.NoHeaders)
+		}
+		return writeTable(w, cols, rows, opts.NoHeaders)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatYAML:
+		return writeYAML(w, v)
+	case FormatTemplate:
+		tmpl, err := template.New("output").Funcs(template.FuncMap{"json": toJSON}).Parse(opts.Template)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		return tmpl.Execute(w, v)
+	default:
+		_, err := ParseFormat(string(format))
+		return err
+	}
+}
+
+func writeTable(w io.Writer, cols []Column, rows [][]string, noHeaders bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if !noHeaders {
+		headers := make([]string, len(cols))
+		for i, c := range cols {
+			headers[i] = strings.ToUpper(c.Name)
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+	cleaner := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for _, row := range rows {
+		for i := range row {
+			row[i] = cleaner.Replace(row[i])
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, cols []Column, rows [][]string, noHeaders bool) error {
+	cw := csv.NewWriter(w)
+	if !noHeaders {
+		headers := make([]string, len(cols))
+		for i, c := range cols {
+			headers[i] = c.Name
+		}
+		if err := cw.Write(headers); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeYAML renders v with the same field names and order as its JSON form.
+// JSON is valid YAML, so decoding it into a node keeps the order, and
+// clearing the node styles turns the flow syntax into block YAML.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	var clear func(n *yaml.Node)
+	clear = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			clear(child)
+		}
+	}
+	clear(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// ============================================================================
+// Redaction
+// ============================================================================
+
+// secretFields names the fields of the aura types that hold secrets. Fields
+// of other structs are secret when tagged `auraformat:"secret"`.
+var secretFields = map[reflect.Type]map[string]bool{
+	reflect.TypeFor[aura.CreateInstanceData](): {"Password": true},
+}
+
+// redact returns v with its secrets replaced by Redacted, wherever they are
+// nested: behind pointers and interfaces, in slices, arrays and maps, and in
+// the fields of wrapping structs. Only the parts that hold a secret are
+// copied; v itself is never modified.
+func redact(v any) any {
+	out, changed := redactValue(reflect.ValueOf(v), map[visit]reflect.Value{})
+	if !changed {
+		return v
+	}
+	return out.Interface()
+}
+
+// visit identifies a pointer already walked by redactValue.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// redactValue returns a copy of rv with its secrets redacted and true, or rv
+// and false when it holds none. seen maps each pointer walked to its result,
+// so a pointer shared by several elements is copied once and cycles end.
+func redactValue(rv reflect.Value, seen map[visit]reflect.Value) (reflect.Value, bool) {
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return rv, false
+		}
+		key := visit{rv.Pointer(), rv.Type()}
+		if out, ok := seen[key]; ok {
+			return out, out.Pointer() != rv.Pointer()
+		}
+		seen[key] = rv
+		elem, changed := redactValue(rv.Elem(), seen)
+		if !changed {
+			return rv, false
+		}
+		out := reflect.New(elem.Type())
+		out.Elem().Set(elem)
+		seen[key] = out
+		return out, true
+
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv, false
+		}
+		elem, changed := redactValue(rv.Elem(), seen)
+		if !changed {
+			return rv, false
+		}
+		out := reflect.New(rv.Type()).Elem()
+		out.Set(elem)
+		return out, true
+
+	case reflect.Struct:
+		var out reflect.Value
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			value, changed := rv.Field(i), false
+			if isSecret(rv.Type(), field) {
+				if value.Kind() == reflect.String && value.String() != "" {
+					value, changed = reflect.ValueOf(Redacted).Convert(field.Type), true
+				}
+			} else {
+				value, changed = redactValue(value, seen)
+			}
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.New(rv.Type()).Elem()
+				out.Set(rv)
+			}
+			out.Field(i).Set(value)
+		}
+		if !out.IsValid() {
+			return rv, false
+		}
+		return out, true
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return rv, false
+		}
+		var out reflect.Value
+		for i := 0; i < rv.Len(); i++ {
+			elem, changed := redactValue(rv.Index(i), seen)
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				if rv.Kind() == reflect.Slice {
+					out = reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+					reflect.Copy(out, rv)
+				} else {
+					out = reflect.New(rv.Type()).Elem()
+					out.Set(rv)
+				}
+			}
+			out.Index(i).Set(elem)
+		}
+		if !out.IsValid() {
+			return rv, false
+		}
+		return out, true
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv, false
+		}
+		var out reflect.Value
+		iter := rv.MapRange()
+		for iter.Next() {
+			elem, changed := redactValue(iter.Value(), seen)
+			if !changed {
+				continue
+			}
+			if !out.IsValid() {
+				out = reflect.MakeMapWithSize(rv.Type(), rv.Len())
+				for all := rv.MapRange(); all.Next(); {
+					out.SetMapIndex(all.Key(), all.Value())
+				}
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		if !out.IsValid() {
+			return rv, false
+		}
+		return out, true
+	}
+	return rv, false
+}
+
+// isSecret reports whether field of the struct type t holds a secret.
+func isSecret(t reflect.Type, field reflect.StructField) bool {
+	return secretFields[t][field.Name] || field.Tag.Get("auraformat") == "secret"
+}
+
+// ============================================================================
+// Cells
+// ============================================================================
+
+// elements returns the values rendered as table rows: the elements of a
+// slice or array, or v itself. Pointers are followed and nil ones skipped.
+func elements(v any) (reflect.Type, []reflect.Value) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return rv.Type(), []reflect.Value{rv}
+	}
+	typ := rv.Type().Elem()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	var out []reflect.Value
+	for i := 0; i < rv.Len(); i++ {
+		if e := indirect(rv.Index(i)); e.IsValid() {
+			out = append(out, e)
+		}
+	}
+	return typ, out
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// render returns the table cell text of a value: scalars as text, times in
+// RFC 3339, lists of scalars comma-separated and anything else as JSON.
+func render(rv reflect.Value) string {
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return ""
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		return formatTime(t)
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(rv.Interface())
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(rv.Float())
+	case reflect.Slice, reflect.Array:
+		if isScalar(rv.Type().Elem()) {
+			items := make([]string, rv.Len())
+			for i := range items {
+				items[i] = render(rv.Index(i))
+			}
+			return strings.Join(items, ",")
+		}
+	}
+	data, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return fmt.Sprint(rv.Interface())
+	}
+	return string(data)
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package auraformat_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auraformat"
+	"gopkg.in/yaml.v3"
+)
+
+var instances = []aura.InstanceData{
+	{ID: "a1b2c3d4", Name: "prod", Status: aura.StatusRunning, CloudProvider: "gcp", Region: "europe-west1", Type: "enterprise-db", Memory: "8GB"},
+	{ID: "e5f6a7b8", Name: "dev\tbox", Status: aura.StatusPaused, CloudProvider: "aws", Region: "us-east-1", Type: "professional-db", Memory: "2GB"},
+}
+
+var created = &aura.CreateInstanceData{
+	ID: "a1b2c3d4", Name: "prod", ConnectionURL: "neo4j+s://a1b2c3d4.databases.neo4j.io",
+	Username: "neo4j", Password: "hunter2",
+}
+
+func write(t *testing.T, v any, opts auraformat.Options) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := auraformat.Write(&buf, v, opts); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return buf.String()
+}
+
+func TestWrite_Table(t *testing.T) {
+	got := write(t, instances, auraformat.Options{Columns: []string{"id", "name", "status"}})
+	want := "ID         NAME      STATUS\n" +
+		"a1b2c3d4   prod      running\n" +
+		"e5f6a7b8   dev box   paused\n"
+	if got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	got = write(t, &instances[0], auraformat.Options{Columns: []string{"name"}, NoHeaders: true})
+	if got != "prod\n" {
+		t.Errorf("Expected a single row without headers, got %q", got)
+	}
+}
+
+func TestWrite_CSV(t *testing.T) {
+	snapshots := []aura.GetSnapshotData{{
+		SnapshotID: "s1", Status: "Completed", Profile: "AdHoc", Exportable: true,
+		Timestamp: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}}
+	got := write(t, snapshots, auraformat.Options{Format: auraformat.FormatCSV})
+	want := "snapshot_id,status,profile,timestamp,exportable\n" +
+		"s1,Completed,AdHoc,2026-10-01T12:00:00Z,true\n"
+	if got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestWrite_Redaction(t *testing.T) {
+	formats := []auraformat.Options{
+		{Format: auraformat.FormatTable},
+		{Format: auraformat.FormatCSV},
+		{Format: auraformat.FormatJSON},
+		{Format: auraformat.FormatYAML},
+		{Format: auraformat.FormatTemplate, Template: "{{.Password}}"},
+	}
+	for _, opts := range formats {
+		t.Run(string(opts.Format), func(t *testing.T) {
+			got := write(t, created, opts)
+			if strings.Contains(got, "hunter2") || !strings.Contains(got, auraformat.Redacted) {
+				t.Errorf("Expected the password to be redacted, got %q", got)
+			}
+			opts.ShowSecrets = true
+			if got := write(t, created, opts); !strings.Contains(got, "hunter2") {
+				t.Errorf("Expected the password with ShowSecrets, got %q", got)
+			}
+		})
+	}
+	if created.Password != "hunter2" {
+		t.Error("Expected the caller's value to be left unchanged")
+	}
+}
+
+func TestWrite_RedactionNested(t *testing.T) {
+	type apiKey struct {
+		Name   string `json:"name"`
+		Secret string `json:"secret" auraformat:"secret"`
+	}
+	type wrapper struct {
+		Instances []*aura.CreateInstanceData `json:"instances"`
+		ByName    map[string]any             `json:"by_name"`
+		Key       *apiKey                    `json:"key"`
+	}
+	other := &aura.CreateInstanceData{ID: "e5f6a7b8", Name: "dev", Password: "swordfish"}
+	values := map[string]any{
+		"pointer slice": []*aura.CreateInstanceData{created, nil, other},
+		"map":           map[string]aura.CreateInstanceData{"prod": *created, "dev": *other},
+		"wrapper": wrapper{
+			Instances: []*aura.CreateInstanceData{created, created},
+			ByName:    map[string]any{"dev": other, "count": 2},
+			Key:       &apiKey{Name: "ci", Secret: "letmein"},
+		},
+	}
+	for name, v := range values {
+		t.Run(name, func(t *testing.T) {
+			got := write(t, v, auraformat.Options{Format: auraformat.FormatJSON})
+			for _, secret := range []string{"hunter2", "swordfish", "letmein"} {
+				if strings.Contains(got, secret) {
+					t.Errorf("Expected %s to be redacted, got %s", secret, got)
+				}
+			}
+			if !strings.Contains(got, auraformat.Redacted) || !strings.Contains(got, "a1b2c3d4") {
+				t.Errorf("Expected the redacted value with its other fields, got %s", got)
+			}
+		})
+	}
+	if created.Password != "hunter2" || other.Password != "swordfish" {
+		t.Error("Expected the caller's values to be left unchanged")
+	}
+}
+
+func TestWrite_JSONAndYAML(t *testing.T) {
+	var fromJSON []aura.InstanceData
+	if err := json.Unmarshal([]byte(write(t, instances, auraformat.Options{Format: auraformat.FormatJSON})), &fromJSON); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	got := write(t, instances[0], auraformat.Options{Format: auraformat.FormatYAML})
+	if !strings.HasPrefix(got, "id: a1b2c3d4\nname: prod\nstatus: running\n") {
+		t.Errorf("Expected block YAML with JSON field names in order, got\n%s", got)
+	}
+	var fromYAML map[string]any
+	if err := yaml.Unmarshal([]byte(got), &fromYAML); err != nil || fromYAML["memory"] != "8GB" {
+		t.Errorf("Expected valid YAML, got %v, %v", fromYAML, err)
+	}
+}
+
+func TestWrite_Template(t *testing.T) {
+	got := write(t, instances, auraformat.Options{
+		Format:   auraformat.FormatTemplate,
+		Template: `{{range .}}{{.Name}}={{.Status}} {{end}}{{json (index . 0).Memory}}`,
+	})
+	if got != `prod=running dev	box=paused "8GB"` {
+		t.Errorf("Unexpected template output %q", got)
+	}
+}
+
+func TestWrite_DerivedColumns(t *testing.T) {
+	tenants := []aura.TenantsResponseData{{ID: "t1", Name: "Production"}}
+	got := write(t, tenants, auraformat.Options{Format: auraformat.FormatCSV})
+	if got != "id,name\nt1,Production\n" {
+		t.Errorf("Expected columns from the JSON field names, got %q", got)
+	}
+}
+
+func TestWrite_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts auraformat.Options
+	}{
+		{"unknown column", auraformat.Options{Columns: []string{"colour"}}},
+		{"columns with json", auraformat.Options{Format: auraformat.FormatJSON, Columns: []string{"id"}}},
+		{"missing template", auraformat.Options{Format: auraformat.FormatTemplate}},
+		{"bad template", auraformat.Options{Format: auraformat.FormatTemplate, Template: "{{.Name"}},
+		{"unknown format", auraformat.Options{Format: "xml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := auraformat.Write(&bytes.Buffer{}, instances, tt.opts); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	cols := auraformat.Columns([]*aura.PrometheusHealthMetrics{})
+	if cols[0] != "instance_id" || cols[1] != "overall_status" {
+		t.Errorf("Expected the default columns first, got %v", cols)
+	}
+	for _, want := range []string{"timestamp", "anomalies", "page_cache_hit_rate"} {
+		found := false
+		for _, c := range cols {
+			found = found || c == want
+		}
+		if !found {
+			t.Errorf("Expected column %q in %v", want, cols)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := auraformat.ParseFormat("yaml"); err != nil || f != auraformat.FormatYAML {
+		t.Errorf("Expected yaml, got %q, %v", f, err)
+	}
+	if _, err := auraformat.ParseFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
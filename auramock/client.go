@@ -0,0 +1,50 @@
+package auramock
+
+import (
+	aura "github.com/LackOfMorals/aura-client"
+)
+
+// placeholder credentials for clients built by NewClient. They are never sent
+// anywhere, since every service is a mock.
+const (
+	mockClientID     = "auramock-client-id"
+	mockClientSecret = "auramock-client-secret"
+)
+
+// Services holds one mock per service of a client built by NewClient.
+type Services struct {
+	Tenants        *TenantService
+	Instances      *InstanceService
+	Snapshots      *SnapshotService
+	Cmek           *CmekService
+	GraphAnalytics *GDSSessionService
+	Prometheus     *PrometheusService
+}
+
+// NewClient returns a client whose services are all mocks, together with the
+// mocks. Unlike a struct literal, the client is fully initialised, so client
+// methods such as FleetHealth and ReapGDSSessions can be exercised against
+// the mocks. opts are applied as for aura.NewClient; credentials are filled
+// in when not given.
+func NewClient(opts ...aura.Option) (*aura.AuraAPIClient, *Services, error) {
+	client, err := aura.NewClient(append([]aura.Option{aura.WithCredentials(mockClientID, mockClientSecret)}, opts...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mocks := &Services{
+		Tenants:        &TenantService{},
+		Instances:      &InstanceService{},
+		Snapshots:      &SnapshotService{},
+		Cmek:           &CmekService{},
+		GraphAnalytics: &GDSSessionService{},
+		Prometheus:     &PrometheusService{},
+	}
+	client.Tenants = mocks.Tenants
+	client.Instances = mocks.Instances
+	client.Snapshots = mocks.Snapshots
+	client.Cmek = mocks.Cmek
+	client.GraphAnalytics = mocks.GraphAnalytics
+	client.Prometheus = mocks.Prometheus
+	return client, mocks, nil
+}
@@ -0,0 +1,157 @@
+// Package auramock provides call-recording mocks of the aura service
+// interfaces for unit-testing code that depends on them.
+//
+// Each mock has one stub field per method, named after the method with a Func
+// suffix. A call runs the stub if it is set and returns ErrNotStubbed
+// otherwise. Every call is recorded, without its context, so tests can
+// inspect or assert on it afterwards:
+//
+//	instances := &auramock.InstanceService{
+//		GetFunc: func(ctx context.Context, id string) (*aura.GetInstanceResponse, error) {
+//			return &aura.GetInstanceResponse{Data: aura.InstanceData{ID: id, Status: aura.StatusRunning}}, nil
+//		},
+//	}
+//	client := &aura.AuraAPIClient{Instances: instances}
+//
+//	runCodeUnderTest(client)
+//
+//	instances.AssertCalled(t, "Get", "c9f0d13a")
+//	instances.AssertNotCalled(t, "Delete")
+//
+// NewClient returns a fully configured client whose services are all mocks,
+// for exercising client methods such as FleetHealth.
+package auramock
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrNotStubbed is returned by a mock method whose stub function is nil.
+var ErrNotStubbed = errors.New("auramock: method not stubbed")
+
+// Call is one recorded call to a mock method.
+type Call struct {
+	// Method is the name of the method called, such as "Get".
+	Method string
+	// Args holds the arguments after the context, in order. Variadic
+	// arguments are recorded as a single slice.
+	Args []any
+}
+
+// TestingT is the subset of testing.TB used by the assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Recorder records calls to a mock. It is embedded in every mock, and its
+// methods are safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// record appends a call.
+func (r *Recorder) record(method string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns every recorded call, oldest first.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls to method, oldest first.
+func (r *Recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// CallCount returns the number of recorded calls to method.
+func (r *Recorder) CallCount(method string) int {
+	return len(r.CallsTo(method))
+}
+
+// Reset forgets every recorded call. Stub functions are unchanged.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// AssertCalled reports a test error unless method was called at least once.
+// If args are given, at least one call must have had exactly those
+// arguments, compared with reflect.DeepEqual.
+func (r *Recorder) AssertCalled(t TestingT, method string, args ...any) bool {
+	t.Helper()
+	calls := r.CallsTo(method)
+	if len(calls) == 0 {
+		t.Errorf("auramock: expected a call to %s, got none", method)
+		return false
+	}
+	if len(args) == 0 {
+		return true
+	}
+	for _, c := range calls {
+		if reflect.DeepEqual(c.Args, args) {
+			return true
+		}
+	}
+	t.Errorf("auramock: expected a call to %s with %s, got %s", method, formatArgs(args), formatCalls(calls))
+	return false
+}
+
+// AssertNotCalled reports a test error if method was called.
+func (r *Recorder) AssertNotCalled(t TestingT, method string) bool {
+	t.Helper()
+	if calls := r.CallsTo(method); len(calls) > 0 {
+		t.Errorf("auramock: expected no call to %s, got %s", method, formatCalls(calls))
+		return false
+	}
+	return true
+}
+
+// AssertCallCount reports a test error unless method was called exactly n
+// times.
+func (r *Recorder) AssertCallCount(t TestingT, method string, n int) bool {
+	t.Helper()
+	if got := r.CallCount(method); got != n {
+		t.Errorf("auramock: expected %d calls to %s, got %d", n, method, got)
+		return false
+	}
+	return true
+}
+
+// notStubbed returns the error for a call to an unset stub.
+func notStubbed(service, method string) error {
+	return fmt.Errorf("%w: %s.%s", ErrNotStubbed, service, method)
+}
+
+func formatArgs(args []any) string {
+	return fmt.Sprintf("%#v", args)
+}
+
+func formatCalls(calls []Call) string {
+	s := ""
+	for i, c := range calls {
+		if i > 0 {
+			s += ", "
+		}
+		s += formatArgs(c.Args)
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package auramock
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeT captures assertion failures.
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder_Assertions(t *testing.T) {
+	var r Recorder
+	r.record("Get", "aaaaaaaa")
+	r.record("Get", "bbbbbbbb")
+	r.record("Pause", "aaaaaaaa")
+
+	ft := &fakeT{}
+	passes := []bool{
+		r.AssertCalled(ft, "Get"),
+		r.AssertCalled(ft, "Get", "bbbbbbbb"),
+		r.AssertNotCalled(ft, "Delete"),
+		r.AssertCallCount(ft, "Get", 2),
+	}
+	for i, ok := range passes {
+		if !ok {
+			t.Errorf("assertion %d: expected to pass, got %v", i, ft.errors)
+		}
+	}
+
+	if r.AssertCalled(ft, "Get", "cccccccc") || r.AssertNotCalled(ft, "Pause") || r.AssertCallCount(ft, "Pause", 2) || r.AssertCalled(ft, "Resume") {
+		t.Error("Expected failing assertions to return false")
+	}
+	if len(ft.errors) != 4 || !strings.Contains(ft.errors[0], `"cccccccc"`) {
+		t.Errorf("Expected 4 descriptive failures, got %q", ft.errors)
+	}
+
+	if calls := r.CallsTo("Get"); len(calls) != 2 || calls[1].Args[0] != "bbbbbbbb" {
+		t.Errorf("Unexpected calls %+v", calls)
+	}
+	r.Reset()
+	if len(r.Calls()) != 0 {
+		t.Error("Expected Reset to forget calls")
+	}
+}
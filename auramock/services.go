@@ -0,0 +1,378 @@
+package auramock
+
+import (
+	"context"
+
+	aura "github.com/LackOfMorals/aura-client"
+)
+
+// Compile-time checks that every mock implements its interface, so the mocks
+// fail to build as soon as interfaces.go changes.
+var (
+	_ aura.TenantService     = (*TenantService)(nil)
+	_ aura.InstanceService   = (*InstanceService)(nil)
+	_ aura.SnapshotService   = (*SnapshotService)(nil)
+	_ aura.CmekService       = (*CmekService)(nil)
+	_ aura.GDSSessionService = (*GDSSessionService)(nil)
+	_ aura.PrometheusService = (*PrometheusService)(nil)
+)
+
+// ============================================================================
+// TenantService
+// ============================================================================
+
+// TenantService is a mock aura.TenantService.
+type TenantService struct {
+	Recorder
+
+	ListFunc       func(ctx context.Context) (*aura.ListTenantsResponse, error)
+	GetFunc        func(ctx context.Context, tenantID string) (*aura.GetTenantResponse, error)
+	GetMetricsFunc func(ctx context.Context, tenantID string) (*aura.GetTenantMetricsURLResponse, error)
+}
+
+// List records the call and runs ListFunc.
+func (m *TenantService) List(ctx context.Context) (*aura.ListTenantsResponse, error) {
+	m.record("List")
+	if m.ListFunc == nil {
+		return nil, notStubbed("TenantService", "List")
+	}
+	return m.ListFunc(ctx)
+}
+
+// Get records the call and runs GetFunc.
+func (m *TenantService) Get(ctx context.Context, tenantID string) (*aura.GetTenantResponse, error) {
+	m.record("Get", tenantID)
+	if m.GetFunc == nil {
+		return nil, notStubbed("TenantService", "Get")
+	}
+	return m.GetFunc(ctx, tenantID)
+}
+
+// GetMetrics records the call and runs GetMetricsFunc.
+func (m *TenantService) GetMetrics(ctx context.Context, tenantID string) (*aura.GetTenantMetricsURLResponse, error) {
+	m.record("GetMetrics", tenantID)
+	if m.GetMetricsFunc == nil {
+		return nil, notStubbed("TenantService", "GetMetrics")
+	}
+	return m.GetMetricsFunc(ctx, tenantID)
+}
+
+// ============================================================================
+// InstanceService
+// ============================================================================
+
+// InstanceService is a mock aura.InstanceService.
+type InstanceService struct {
+	Recorder
+
+	ListFunc                  func(ctx context.Context) (*aura.ListInstancesResponse, error)
+	GetFunc                   func(ctx context.Context, instanceID string) (*aura.GetInstanceResponse, error)
+	CreateFunc                func(ctx context.Context, instanceRequest *aura.CreateInstanceConfigData) (*aura.CreateInstanceResponse, error)
+	DeleteFunc                func(ctx context.Context, instanceID string) (*aura.DeleteInstanceResponse, error)
+	PauseFunc                 func(ctx context.Context, instanceID string) (*aura.GetInstanceResponse, error)
+	ResumeFunc                func(ctx context.Context, instanceID string) (*aura.GetInstanceResponse, error)
+	UpdateFunc                func(ctx context.Context, instanceID string, instanceRequest *aura.UpdateInstanceData) (*aura.GetInstanceResponse, error)
+	OverwriteFromInstanceFunc func(ctx context.Context, instanceID string, sourceInstanceID string) (*aura.OverwriteInstanceResponse, error)
+	OverwriteFromSnapshotFunc func(ctx context.Context, instanceID string, sourceSnapshotID string) (*aura.OverwriteInstanceResponse, error)
+	WatchFunc                 func(ctx context.Context, instanceIDs ...string) (<-chan aura.InstanceEvent, error)
+}
+
+// List records the call and runs ListFunc.
+func (m *InstanceService) List(ctx context.Context) (*aura.ListInstancesResponse, error) {
+	m.record("List")
+	if m.ListFunc == nil {
+		return nil, notStubbed("InstanceService", "List")
+	}
+	return m.ListFunc(ctx)
+}
+
+// Get records the call and runs GetFunc.
+func (m *InstanceService) Get(ctx context.Context, instanceID string) (*aura.GetInstanceResponse, error) {
+	m.record("Get", instanceID)
+	if m.GetFunc == nil {
+		return nil, notStubbed("InstanceService", "Get")
+	}
+	return m.GetFunc(ctx, instanceID)
+}
+
+// Create records the call and runs CreateFunc.
+func (m *InstanceService) Create(ctx context.Context, instanceRequest *aura.CreateInstanceConfigData) (*aura.CreateInstanceResponse, error) {
+	m.record("Create", instanceRequest)
+	if m.CreateFunc == nil {
+		return nil, notStubbed("InstanceService", "Create")
+	}
+	return m.CreateFunc(ctx, instanceRequest)
+}
+
+// Delete records the call and runs DeleteFunc.
+func (m *InstanceService) Delete(ctx context.Context, instanceID string) (*aura.DeleteInstanceResponse, error) {
+	m.record("Delete", instanceID)
+	if m.DeleteFunc == nil {
+		return nil, notStubbed("InstanceService", "Delete")
+	}
+	return m.DeleteFunc(ctx, instanceID)
+}
+
+// Pause records the call and runs PauseFunc.
+func (m *InstanceService) Pause(ctx context.Context, instanceID string) (*aura.GetInstanceResponse, error) {
+	m.record("Pause", instanceID)
+	if m.PauseFunc == nil {
+		return nil, notStubbed("InstanceService", "Pause")
+	}
+	return m.PauseFunc(ctx, instanceID)
+}
+
+// Resume records the call and runs ResumeFunc.
+func (m *InstanceService) Resume(ctx context.Context, instanceID string) (*aura.GetInstanceResponse, error) {
+	m.record("Resume", instanceID)
+	if m.ResumeFunc == nil {
+		return nil, notStubbed("InstanceService", "Resume")
+	}
+	return m.ResumeFunc(ctx, instanceID)
+}
+
+// Update records the call and runs UpdateFunc.
+func (m *InstanceService) Update(ctx context.Context, instanceID string, instanceRequest *aura.UpdateInstanceData) (*aura.GetInstanceResponse, error) {
+	m.record("Update", instanceID, instanceRequest)
+	if m.UpdateFunc == nil {
+		return nil, notStubbed("InstanceService", "Update")
+	}
+	return m.UpdateFunc(ctx, instanceID, instanceRequest)
+}
+
+// OverwriteFromInstance records the call and runs OverwriteFromInstanceFunc.
+func (m *InstanceService) OverwriteFromInstance(ctx context.Context, instanceID string, sourceInstanceID string) (*aura.OverwriteInstanceResponse, error) {
+	m.record("OverwriteFromInstance", instanceID, sourceInstanceID)
+	if m.OverwriteFromInstanceFunc == nil {
+		return nil, notStubbed("InstanceService", "OverwriteFromInstance")
+	}
+	return m.OverwriteFromInstanceFunc(ctx, instanceID, sourceInstanceID)
+}
+
+// OverwriteFromSnapshot records the call and runs OverwriteFromSnapshotFunc.
+func (m *InstanceService) OverwriteFromSnapshot(ctx context.Context, instanceID string, sourceSnapshotID string) (*aura.OverwriteInstanceResponse, error) {
+	m.record("OverwriteFromSnapshot", instanceID, sourceSnapshotID)
+	if m.OverwriteFromSnapshotFunc == nil {
+		return nil, notStubbed("InstanceService", "OverwriteFromSnapshot")
+	}
+	return m.OverwriteFromSnapshotFunc(ctx, instanceID, sourceSnapshotID)
+}
+
+// Watch records the call and runs WatchFunc.
+func (m *InstanceService) Watch(ctx context.Context, instanceIDs ...string) (<-chan aura.InstanceEvent, error) {
+	m.record("Watch", instanceIDs)
+	if m.WatchFunc == nil {
+		return nil, notStubbed("InstanceService", "Watch")
+	}
+	return m.WatchFunc(ctx, instanceIDs...)
+}
+
+// ============================================================================
+// SnapshotService
+// ============================================================================
+
+// SnapshotService is a mock aura.SnapshotService.
+type SnapshotService struct {
+	Recorder
+
+	ListFunc    func(ctx context.Context, instanceID string, snapshotDate *aura.SnapshotDate) (*aura.GetSnapshotsResponse, error)
+	CreateFunc  func(ctx context.Context, instanceID string) (*aura.CreateSnapshotResponse, error)
+	GetFunc     func(ctx context.Context, instanceID string, snapshotID string) (*aura.GetSnapshotDataResponse, error)
+	RestoreFunc func(ctx context.Context, instanceID string, snapshotID string) (*aura.RestoreSnapshotResponse, error)
+}
+
+// List records the call and runs ListFunc.
+func (m *SnapshotService) List(ctx context.Context, instanceID string, snapshotDate *aura.SnapshotDate) (*aura.GetSnapshotsResponse, error) {
+	m.record("List", instanceID, snapshotDate)
+	if m.ListFunc == nil {
+		return nil, notStubbed("SnapshotService", "List")
+	}
+	return m.ListFunc(ctx, instanceID, snapshotDate)
+}
+
+// Create records the call and runs CreateFunc.
+func (m *SnapshotService) Create(ctx context.Context, instanceID string) (*aura.CreateSnapshotResponse, error) {
+	m.record("Create", instanceID)
+	if m.CreateFunc == nil {
+		return nil, notStubbed("SnapshotService", "Create")
+	}
+	return m.CreateFunc(ctx, instanceID)
+}
+
+// Get records the call and runs GetFunc.
+func (m *SnapshotService) Get(ctx context.Context, instanceID string, snapshotID string) (*aura.GetSnapshotDataResponse, error) {
+	m.record("Get", instanceID, snapshotID)
+	if m.GetFunc == nil {
+		return nil, notStubbed("SnapshotService", "Get")
+	}
+	return m.GetFunc(ctx, instanceID, snapshotID)
+}
+
+// Restore records the call and runs RestoreFunc.
+func (m *SnapshotService) Restore(ctx context.Context, instanceID string, snapshotID string) (*aura.RestoreSnapshotResponse, error) {
+	m.record("Restore", instanceID, snapshotID)
+	if m.RestoreFunc == nil {
+		return nil, notStubbed("SnapshotService", "Restore")
+	}
+	return m.RestoreFunc(ctx, instanceID, snapshotID)
+}
+
+// ============================================================================
+// CmekService
+// ============================================================================
+
+// CmekService is a mock aura.CmekService.
+type CmekService struct {
+	Recorder
+
+	ListFunc func(ctx context.Context, tenantID string) (*aura.GetCmeksResponse, error)
+}
+
+// List records the call and runs ListFunc.
+func (m *CmekService) List(ctx context.Context, tenantID string) (*aura.GetCmeksResponse, error) {
+	m.record("List", tenantID)
+	if m.ListFunc == nil {
+		return nil, notStubbed("CmekService", "List")
+	}
+	return m.ListFunc(ctx, tenantID)
+}
+
+// ============================================================================
+// GDSSessionService
+// ============================================================================
+
+// GDSSessionService is a mock aura.GDSSessionService.
+type GDSSessionService struct {
+	Recorder
+
+	ListFunc        func(ctx context.Context) (*aura.GetGDSSessionListResponse, error)
+	EstimateFunc    func(ctx context.Context, estimateRequest *aura.GetGDSSessionSizeEstimation) (*aura.GDSSessionSizeEstimationResponse, error)
+	CreateFunc      func(ctx context.Context, createRequest *aura.CreateGDSSessionConfigData) (*aura.GetGDSSessionResponse, error)
+	GetFunc         func(ctx context.Context, sessionID string) (*aura.GetGDSSessionResponse, error)
+	DeleteFunc      func(ctx context.Context, sessionID string) (*aura.DeleteGDSSessionResponse, error)
+	CreateSizedFunc func(ctx context.Context, estimateRequest *aura.GetGDSSessionSizeEstimation, createRequest *aura.CreateGDSSessionConfigData, opts ...aura.GDSSessionSizingOption) (*aura.CreateSizedGDSSessionResponse, error)
+	WatchFunc       func(ctx context.Context, sessionIDs ...string) (<-chan aura.GDSSessionEvent, error)
+}
+
+// List records the call and runs ListFunc.
+func (m *GDSSessionService) List(ctx context.Context) (*aura.GetGDSSessionListResponse, error) {
+	m.record("List")
+	if m.ListFunc == nil {
+		return nil, notStubbed("GDSSessionService", "List")
+	}
+	return m.ListFunc(ctx)
+}
+
+// Estimate records the call and runs EstimateFunc.
+func (m *GDSSessionService) Estimate(ctx context.Context, estimateRequest *aura.GetGDSSessionSizeEstimation) (*aura.GDSSessionSizeEstimationResponse, error) {
+	m.record("Estimate", estimateRequest)
+	if m.EstimateFunc == nil {
+		return nil, notStubbed("GDSSessionService", "Estimate")
+	}
+	return m.EstimateFunc(ctx, estimateRequest)
+}
+
+// Create records the call and runs CreateFunc.
+func (m *GDSSessionService) Create(ctx context.Context, createRequest *aura.CreateGDSSessionConfigData) (*aura.GetGDSSessionResponse, error) {
+	m.record("Create", createRequest)
+	if m.CreateFunc == nil {
+		return nil, notStubbed("GDSSessionService", "Create")
+	}
+	return m.CreateFunc(ctx, createRequest)
+}
+
+// Get records the call and runs GetFunc.
+func (m *GDSSessionService) Get(ctx context.Context, sessionID string) (*aura.GetGDSSessionResponse, error) {
+	m.record("Get", sessionID)
+	if m.GetFunc == nil {
+		return nil, notStubbed("GDSSessionService", "Get")
+	}
+	return m.GetFunc(ctx, sessionID)
+}
+
+// Delete records the call and runs DeleteFunc.
+func (m *GDSSessionService) Delete(ctx context.Context, sessionID string) (*aura.DeleteGDSSessionResponse, error) {
+	m.record("Delete", sessionID)
+	if m.DeleteFunc == nil {
+		return nil, notStubbed("GDSSessionService", "Delete")
+	}
+	return m.DeleteFunc(ctx, sessionID)
+}
+
+// CreateSized records the call and runs CreateSizedFunc.
+func (m *GDSSessionService) CreateSized(ctx context.Context, estimateRequest *aura.GetGDSSessionSizeEstimation, createRequest *aura.CreateGDSSessionConfigData, opts ...aura.GDSSessionSizingOption) (*aura.CreateSizedGDSSessionResponse, error) {
+	m.record("CreateSized", estimateRequest, createRequest, opts)
+	if m.CreateSizedFunc == nil {
+		return nil, notStubbed("GDSSessionService", "CreateSized")
+	}
+	return m.CreateSizedFunc(ctx, estimateRequest, createRequest, opts...)
+}
+
+// Watch records the call and runs WatchFunc.
+func (m *GDSSessionService) Watch(ctx context.Context, sessionIDs ...string) (<-chan aura.GDSSessionEvent, error) {
+	m.record("Watch", sessionIDs)
+	if m.WatchFunc == nil {
+		return nil, notStubbed("GDSSessionService", "Watch")
+	}
+	return m.WatchFunc(ctx, sessionIDs...)
+}
+
+// ============================================================================
+// PrometheusService
+// ============================================================================
+
+// PrometheusService is a mock aura.PrometheusService.
+type PrometheusService struct {
+	Recorder
+
+	FetchRawMetricsFunc           func(ctx context.Context, prometheusURL string, opts ...aura.FetchOption) (*aura.PrometheusMetricsResponse, error)
+	GetMetricValueFunc            func(ctx context.Context, metrics *aura.PrometheusMetricsResponse, name string, labelFilters map[string]string) (float64, error)
+	QueryFunc                     func(ctx context.Context, metrics *aura.PrometheusMetricsResponse, query string) ([]aura.MetricSample, error)
+	GetInstanceHealthFunc         func(ctx context.Context, instanceID string, prometheusURL string, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error)
+	InstanceHealthFromMetricsFunc func(ctx context.Context, instanceID string, metrics *aura.PrometheusMetricsResponse, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error)
+}
+
+// FetchRawMetrics records the call and runs FetchRawMetricsFunc.
+func (m *PrometheusService) FetchRawMetrics(ctx context.Context, prometheusURL string, opts ...aura.FetchOption) (*aura.PrometheusMetricsResponse, error) {
+	m.record("FetchRawMetrics", prometheusURL, opts)
+	if m.FetchRawMetricsFunc == nil {
+		return nil, notStubbed("PrometheusService", "FetchRawMetrics")
+	}
+	return m.FetchRawMetricsFunc(ctx, prometheusURL, opts...)
+}
+
+// GetMetricValue records the call and runs GetMetricValueFunc.
+func (m *PrometheusService) GetMetricValue(ctx context.Context, metrics *aura.PrometheusMetricsResponse, name string, labelFilters map[string]string) (float64, error) {
+	m.record("GetMetricValue", metrics, name, labelFilters)
+	if m.GetMetricValueFunc == nil {
+		return 0, notStubbed("PrometheusService", "GetMetricValue")
+	}
+	return m.GetMetricValueFunc(ctx, metrics, name, labelFilters)
+}
+
+// Query records the call and runs QueryFunc.
+func (m *PrometheusService) Query(ctx context.Context, metrics *aura.PrometheusMetricsResponse, query string) ([]aura.MetricSample, error) {
+	m.record("Query", metrics, query)
+	if m.QueryFunc == nil {
+		return nil, notStubbed("PrometheusService", "Query")
+	}
+	return m.QueryFunc(ctx, metrics, query)
+}
+
+// GetInstanceHealth records the call and runs GetInstanceHealthFunc.
+func (m *PrometheusService) GetInstanceHealth(ctx context.Context, instanceID string, prometheusURL string, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+	m.record("GetInstanceHealth", instanceID, prometheusURL, opts)
+	if m.GetInstanceHealthFunc == nil {
+		return nil, notStubbed("PrometheusService", "GetInstanceHealth")
+	}
+	return m.GetInstanceHealthFunc(ctx, instanceID, prometheusURL, opts...)
+}
+
+// InstanceHealthFromMetrics records the call and runs InstanceHealthFromMetricsFunc.
+func (m *PrometheusService) InstanceHealthFromMetrics(ctx context.Context, instanceID string, metrics *aura.PrometheusMetricsResponse, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+	m.record("InstanceHealthFromMetrics", instanceID, metrics, opts)
+	if m.InstanceHealthFromMetricsFunc == nil {
+		return nil, notStubbed("PrometheusService", "InstanceHealthFromMetrics")
+	}
+	return m.InstanceHealthFromMetricsFunc(ctx, instanceID, metrics, opts...)
+}
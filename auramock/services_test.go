@@ -0,0 +1,71 @@
+package auramock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auramock"
+)
+
+func TestInstanceService_StubAndRecord(t *testing.T) {
+	ctx := context.Background()
+	m := &auramock.InstanceService{
+		GetFunc: func(ctx context.Context, id string) (*aura.GetInstanceResponse, error) {
+			return &aura.GetInstanceResponse{Data: aura.InstanceData{ID: id, Status: aura.StatusRunning}}, nil
+		},
+	}
+	var svc aura.InstanceService = m
+
+	resp, err := svc.Get(ctx, "c9f0d13a")
+	if err != nil || resp.Data.Status != aura.StatusRunning {
+		t.Fatalf("Expected stubbed response, got %+v %v", resp, err)
+	}
+	update := &aura.UpdateInstanceData{Memory: "16GB"}
+	if _, err := svc.Update(ctx, "c9f0d13a", update); !errors.Is(err, auramock.ErrNotStubbed) {
+		t.Errorf("Expected ErrNotStubbed, got %v", err)
+	}
+
+	m.AssertCalled(t, "Get", "c9f0d13a")
+	m.AssertCalled(t, "Update", "c9f0d13a", &aura.UpdateInstanceData{Memory: "16GB"})
+	m.AssertNotCalled(t, "Delete")
+	if calls := m.Calls(); len(calls) != 2 || calls[1].Args[1] != update {
+		t.Errorf("Unexpected calls %+v", calls)
+	}
+}
+
+func TestPrometheusService_VariadicArgs(t *testing.T) {
+	m := &auramock.PrometheusService{
+		GetInstanceHealthFunc: func(ctx context.Context, id, url string, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+			return &aura.PrometheusHealthMetrics{InstanceID: id, OverallStatus: aura.HealthStatusHealthy}, nil
+		},
+	}
+	if _, err := m.GetInstanceHealth(context.Background(), "c9f0d13a", "https://metrics"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	m.AssertCalled(t, "GetInstanceHealth", "c9f0d13a", "https://metrics", []aura.HealthOption(nil))
+}
+
+func TestNewClient_FleetHealth(t *testing.T) {
+	client, mocks, err := auramock.NewClient()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mocks.Instances.ListFunc = func(ctx context.Context) (*aura.ListInstancesResponse, error) {
+		return &aura.ListInstancesResponse{Data: []aura.ListInstanceData{{ID: "c9f0d13a", Name: "prod"}}}, nil
+	}
+	mocks.Instances.GetFunc = func(ctx context.Context, id string) (*aura.GetInstanceResponse, error) {
+		return &aura.GetInstanceResponse{Data: aura.InstanceData{ID: id, Status: aura.StatusRunning, MetricsURL: "https://metrics"}}, nil
+	}
+	mocks.Prometheus.GetInstanceHealthFunc = func(ctx context.Context, id, url string, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+		return &aura.PrometheusHealthMetrics{InstanceID: id, OverallStatus: aura.HealthStatusWarning}, nil
+	}
+
+	report, err := client.FleetHealth(context.Background(), aura.FleetHealthOptions{})
+	if err != nil || report.Warning != 1 {
+		t.Fatalf("Expected one warning instance, got %+v %v", report, err)
+	}
+	mocks.Prometheus.AssertCallCount(t, "GetInstanceHealth", 1)
+	mocks.Tenants.AssertNotCalled(t, "List")
+}
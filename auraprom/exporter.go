@@ -0,0 +1,377 @@
+// Package auraprom re-exports Neo4j Aura instance metrics through a
+// Prometheus client_golang registry, so they can be served from an
+// application's own /metrics endpoint instead of configuring Prometheus to
+// scrape the Aura metrics API with OAuth.
+//
+//	exporter, err := auraprom.New(client.Prometheus,
+//		[]auraprom.Target{{InstanceID: "a1b2c3d4"}},
+//		auraprom.WithInstances(client.Instances))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	prometheus.MustRegister(exporter)
+//	http.Handle("/metrics", promhttp.Handler())
+package auraprom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Labels added to every re-exported series.
+const (
+	InstanceIDLabel   = "aura_instance_id"
+	InstanceNameLabel = "aura_instance_name"
+)
+
+// Metrics describing the exporter itself.
+const (
+	HealthStatusMetric  = "aura_instance_health_status"
+	ScrapeSuccessMetric = "aura_exporter_scrape_success"
+)
+
+// Exporter defaults, used when the corresponding option is not given.
+const (
+	defaultCacheTTL      = 30 * time.Second
+	defaultScrapeTimeout = 10 * time.Second
+)
+
+// Health status values reported by the aura_instance_health_status gauge.
+var healthStatusValues = map[string]float64{
+	aura.HealthStatusHealthy:  0,
+	aura.HealthStatusWarning:  1,
+	aura.HealthStatusCritical: 2,
+}
+
+// Target is one Aura instance to re-export. MetricsURL may be left empty when
+// WithInstances is used; it is then looked up from the instance.
+type Target struct {
+	InstanceID string
+	MetricsURL string
+}
+
+// RelabelFunc rewrites a series before it is exported. It may modify labels in
+// place and returns the metric name to export, or keep=false to drop the series.
+type RelabelFunc func(name string, labels map[string]string) (newName string, keep bool)
+
+// Option configures an Exporter.
+type Option func(*options) error
+
+// options holds the settings applied by Option values.
+type options struct {
+	instances     aura.InstanceService
+	cacheTTL      time.Duration
+	scrapeTimeout time.Duration
+	relabel       RelabelFunc
+	healthRules   aura.HealthOption
+	logger        *slog.Logger
+}
+
+// Exporter is a prometheus.Collector that fetches Aura metrics when it is
+// scraped. Results are cached for the cache TTL, so frequent scrapes of the
+// application do not multiply requests to Aura. It is an unchecked collector:
+// the set of metric families depends on what Aura returns.
+type Exporter struct {
+	prometheus aura.PrometheusService
+	opts       options
+	now        func() time.Time
+	targets    []*target
+}
+
+// target is the cached state of one Target.
+type target struct {
+	Target
+
+	mu        sync.Mutex // held while refreshing, so one scrape fetches at a time
+	name      string
+	fetchedAt time.Time
+	metrics   *aura.PrometheusMetricsResponse
+	health    *aura.PrometheusHealthMetrics
+	err       error
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithInstances enables the aura_instance_name label and lookup of missing
+// metrics URLs, using Instances.Get.
+func WithInstances(instances aura.InstanceService) Option {
+	return func(o *options) error {
+		if instances == nil {
+			return errors.New("instance service must not be nil")
+		}
+		o.instances = instances
+		return nil
+	}
+}
+
+// WithCacheTTL sets how long fetched metrics are reused before the next
+// scrape fetches them again. Defaults to 30 seconds.
+func WithCacheTTL(d time.Duration) Option {
+	return func(o *options) error {
+		if d < 0 {
+			return fmt.Errorf("cache TTL must not be negative")
+		}
+		o.cacheTTL = d
+		return nil
+	}
+}
+
+// WithScrapeTimeout limits how long fetching one instance may take during a
+// scrape. Defaults to 10 seconds.
+func WithScrapeTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		if d <= 0 {
+			return fmt.Errorf("scrape timeout must be greater than zero")
+		}
+		o.scrapeTimeout = d
+		return nil
+	}
+}
+
+// WithRelabel applies fn to every re-exported series, after the instance
+// labels have been added. The exporter's own metrics are not relabelled.
+func WithRelabel(fn RelabelFunc) Option {
+	return func(o *options) error {
+		if fn == nil {
+			return errors.New("relabel function must not be nil")
+		}
+		o.relabel = fn
+		return nil
+	}
+}
+
+// WithHealthRules sets the rules used to derive the health status gauge.
+// Defaults to aura.DefaultHealthRules.
+func WithHealthRules(rules *aura.HealthRuleSet) Option {
+	return func(o *options) error {
+		if rules == nil {
+			return errors.New("health rules must not be nil")
+		}
+		if err := rules.Validate(); err != nil {
+			return err
+		}
+		// Built once, so the rules are parsed once rather than on every refresh.
+		o.healthRules = aura.WithHealthRules(rules)
+		return nil
+	}
+}
+
+// WithLogger sets the logger for fetch failures. Defaults to warn-level
+// logging to stderr.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) error {
+		if logger == nil {
+			return errors.New("logger cannot be nil")
+		}
+		o.logger = logger
+		return nil
+	}
+}
+
+// ============================================================================
+// Exporter
+// ============================================================================
+
+// New returns an Exporter for targets, fetching with prom.
+func New(prom aura.PrometheusService, targets []Target, opts ...Option) (*Exporter, error) {
+	if prom == nil {
+		return nil, errors.New("prometheus service must not be nil")
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("at least one target is required")
+	}
+
+	o := options{
+		cacheTTL:      defaultCacheTTL,
+		scrapeTimeout: defaultScrapeTimeout,
+		logger:        slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &Exporter{prometheus: prom, opts: o, now: time.Now}
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if t.InstanceID == "" {
+			return nil, errors.New("target instance ID must not be empty")
+		}
+		if t.MetricsURL == "" && o.instances == nil {
+			return nil, fmt.Errorf("target %s has no metrics URL; set one or use WithInstances", t.InstanceID)
+		}
+		if seen[t.InstanceID] {
+			return nil, fmt.Errorf("duplicate target %s", t.InstanceID)
+		}
+		seen[t.InstanceID] = true
+		e.targets = append(e.targets, &target{Target: t})
+	}
+	return e, nil
+}
+
+// Describe implements prometheus.Collector. It sends no descriptors, which
+// registers the Exporter as an unchecked collector.
+func (e *Exporter) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector. Targets are refreshed concurrently
+// when their cache has expired; a target that cannot be fetched only reports
+// aura_exporter_scrape_success 0.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	for _, t := range e.targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			e.collectTarget(t, ch)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// collectTarget refreshes t if needed and sends its metrics.
+func (e *Exporter) collectTarget(t *target, ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.fetchedAt.IsZero() || e.now().Sub(t.fetchedAt) >= e.opts.cacheTTL {
+		e.refresh(t)
+	}
+
+	instanceLabels := map[string]string{InstanceIDLabel: t.InstanceID}
+	if e.opts.instances != nil {
+		instanceLabels[InstanceNameLabel] = t.name
+	}
+
+	success := 0.0
+	if t.err == nil {
+		success = 1
+	}
+	ch <- constMetric(ScrapeSuccessMetric, "Whether the last fetch of Aura metrics for the instance succeeded.", prometheus.GaugeValue, success, instanceLabels)
+	if t.err != nil {
+		return
+	}
+
+	if t.health != nil {
+		if value, ok := healthStatusValues[t.health.OverallStatus]; ok {
+			ch <- constMetric(HealthStatusMetric, "Derived health status of the Aura instance: 0 healthy, 1 warning, 2 critical.", prometheus.GaugeValue, value, instanceLabels)
+		}
+	}
+
+	for name, series := range t.metrics.Metrics {
+		for _, m := range series {
+			labels := make(map[string]string, len(m.Labels)+len(instanceLabels))
+			for k, v := range m.Labels {
+				labels[k] = v
+			}
+			for k, v := range instanceLabels {
+				labels[k] = v
+			}
+			exportName := name
+			if e.opts.relabel != nil {
+				var keep bool
+				if exportName, keep = e.opts.relabel(name, labels); !keep {
+					continue
+				}
+			}
+			metric, err := reexport(exportName, m, labels)
+			if err != nil {
+				e.opts.logger.Warn("failed to re-export metric", slog.String("metric", exportName), slog.String("error", err.Error()))
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+// refresh fetches the metrics, health and, on first use, the instance details of t.
+func (e *Exporter) refresh(t *target) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.scrapeTimeout)
+	defer cancel()
+
+	t.fetchedAt = e.now()
+	if e.opts.instances != nil && (t.name == "" || t.MetricsURL == "") {
+		instance, err := e.opts.instances.Get(ctx, t.InstanceID)
+		if err != nil {
+			e.opts.logger.Warn("failed to look up Aura instance", slog.String("instanceID", t.InstanceID), slog.String("error", err.Error()))
+		} else {
+			t.name = instance.Data.Name
+			if t.MetricsURL == "" {
+				t.MetricsURL = instance.Data.MetricsURL
+			}
+		}
+	}
+	if t.MetricsURL == "" {
+		t.err = fmt.Errorf("no metrics URL for instance %s", t.InstanceID)
+		t.metrics, t.health = nil, nil
+		return
+	}
+
+	t.metrics, t.err = e.prometheus.FetchRawMetrics(ctx, t.MetricsURL)
+	if t.err != nil {
+		e.opts.logger.Warn("failed to fetch Aura metrics", slog.String("instanceID", t.InstanceID), slog.String("error", t.err.Error()))
+		t.health = nil
+		return
+	}
+
+	// Health is derived from the scrape just fetched rather than with
+	// GetInstanceHealth, which would scrape the endpoint a second time.
+	var healthOpts []aura.HealthOption
+	if e.opts.healthRules != nil {
+		healthOpts = append(healthOpts, e.opts.healthRules)
+	}
+	health, err := e.prometheus.InstanceHealthFromMetrics(ctx, t.InstanceID, t.metrics, healthOpts...)
+	if err != nil {
+		e.opts.logger.Warn("failed to derive Aura instance health", slog.String("instanceID", t.InstanceID), slog.String("error", err.Error()))
+	}
+	t.health = health
+}
+
+// constMetric builds a metric with the given labels.
+func constMetric(name, help string, valueType prometheus.ValueType, value float64, labels map[string]string) prometheus.Metric {
+	return prometheus.MustNewConstMetric(prometheus.NewDesc(name, help, nil, labels), valueType, value)
+}
+
+// reexport converts one parsed Aura series back into a Prometheus metric of
+// the same type.
+func reexport(name string, m aura.PrometheusMetric, labels map[string]string) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(name, "Neo4j Aura metric re-exported from the Aura metrics endpoint.", nil, labels)
+
+	switch m.Type {
+	case aura.MetricTypeCounter:
+		return prometheus.NewConstMetric(desc, prometheus.CounterValue, m.Value)
+	case aura.MetricTypeGauge:
+		return prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.Value)
+	case aura.MetricTypeHistogram:
+		buckets := make(map[float64]uint64, len(m.Buckets))
+		for _, b := range m.Buckets {
+			if !math.IsInf(b.UpperBound, 1) {
+				buckets[b.UpperBound] = uint64(b.CumulativeCount)
+			}
+		}
+		return prometheus.NewConstHistogram(desc, uint64(m.Count), m.Sum, buckets)
+	case aura.MetricTypeSummary:
+		quantiles := make(map[float64]float64, len(m.Quantiles))
+		for _, q := range m.Quantiles {
+			quantiles[q.Quantile] = q.Value
+		}
+		return prometheus.NewConstSummary(desc, uint64(m.Count), m.Sum, quantiles)
+	default:
+		return prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.Value)
+	}
+}
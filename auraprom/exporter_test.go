@@ -0,0 +1,302 @@
+package auraprom
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakePrometheus serves canned metrics and health per URL and counts fetches,
+// including the scrape GetInstanceHealth would make.
+type fakePrometheus struct {
+	mu      sync.Mutex
+	metrics map[string]*aura.PrometheusMetricsResponse
+	health  map[string]*aura.PrometheusHealthMetrics
+	fetches map[string]int
+	rules   int // health calls that carried options
+}
+
+func (f *fakePrometheus) FetchRawMetrics(_ context.Context, url string, _ ...aura.FetchOption) (*aura.PrometheusMetricsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches[url]++
+	m, ok := f.metrics[url]
+	if !ok {
+		return nil, errors.New("unavailable")
+	}
+	return m, nil
+}
+
+func (f *fakePrometheus) GetMetricValue(context.Context, *aura.PrometheusMetricsResponse, string, map[string]string) (float64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (f *fakePrometheus) Query(context.Context, *aura.PrometheusMetricsResponse, string) ([]aura.MetricSample, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakePrometheus) InstanceHealthFromMetrics(_ context.Context, _ string, metrics *aura.PrometheusMetricsResponse, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(opts) > 0 {
+		f.rules++
+	}
+	for url, m := range f.metrics {
+		if h, ok := f.health[url]; ok && m == metrics {
+			return h, nil
+		}
+	}
+	return nil, errors.New("unavailable")
+}
+
+func (f *fakePrometheus) GetInstanceHealth(_ context.Context, _ string, url string, opts ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches[url]++
+	if len(opts) > 0 {
+		f.rules++
+	}
+	h, ok := f.health[url]
+	if !ok {
+		return nil, errors.New("unavailable")
+	}
+	return h, nil
+}
+
+// fakeInstances implements only Get; other methods panic via the nil embedded interface.
+type fakeInstances struct {
+	aura.InstanceService
+	mu        sync.Mutex
+	instances map[string]aura.InstanceData
+	gets      int
+}
+
+func (f *fakeInstances) Get(_ context.Context, id string) (*aura.GetInstanceResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+	inst, ok := f.instances[id]
+	if !ok {
+		return nil, &aura.Error{StatusCode: 404, Message: "not found"}
+	}
+	return &aura.GetInstanceResponse{Data: inst}, nil
+}
+
+const (
+	urlA = "https://a1b2c3d4.metrics.neo4j.io/prometheus"
+	urlB = "https://e5f6a7b8.metrics.neo4j.io/prometheus"
+)
+
+func newFakePrometheus() *fakePrometheus {
+	return &fakePrometheus{
+		fetches: map[string]int{},
+		metrics: map[string]*aura.PrometheusMetricsResponse{
+			urlA: {Metrics: map[string][]aura.PrometheusMetric{
+				"neo4j_aura_cpu_usage":                   {{Name: "neo4j_aura_cpu_usage", Type: aura.MetricTypeGauge, Labels: map[string]string{"availability_zone": "a"}, Value: 0.5}},
+				"neo4j_db_query_execution_success_total": {{Name: "neo4j_db_query_execution_success_total", Type: aura.MetricTypeCounter, Labels: map[string]string{"database": "neo4j"}, Value: 42}},
+				"neo4j_db_query_execution_latency_millis": {{
+					Name: "neo4j_db_query_execution_latency_millis", Type: aura.MetricTypeHistogram, Labels: map[string]string{},
+					Count: 10, Sum: 55, Value: 55,
+					Buckets: []aura.HistogramBucket{{UpperBound: 5, CumulativeCount: 6}, {UpperBound: math.Inf(1), CumulativeCount: 10}},
+				}},
+				"neo4j_gc_pause_seconds": {{
+					Name: "neo4j_gc_pause_seconds", Type: aura.MetricTypeSummary, Labels: map[string]string{},
+					Count: 4, Sum: 1, Quantiles: []aura.SummaryQuantile{{Quantile: 0.5, Value: 0.2}},
+				}},
+			}},
+		},
+		health: map[string]*aura.PrometheusHealthMetrics{
+			urlA: {OverallStatus: aura.HealthStatusWarning},
+		},
+	}
+}
+
+// gather registers e with a fresh registry and returns the gathered families by name.
+func gather(t *testing.T, e *Exporter) map[string]*dto.MetricFamily {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	out := make(map[string]*dto.MetricFamily, len(families))
+	for _, f := range families {
+		out[f.GetName()] = f
+	}
+	return out
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestExporter_Collect(t *testing.T) {
+	prom := newFakePrometheus()
+	instances := &fakeInstances{instances: map[string]aura.InstanceData{
+		"a1b2c3d4": {ID: "a1b2c3d4", Name: "orders", MetricsURL: urlA},
+		"e5f6a7b8": {ID: "e5f6a7b8", Name: "broken", MetricsURL: urlB},
+	}}
+	e, err := New(prom, []Target{{InstanceID: "a1b2c3d4"}, {InstanceID: "e5f6a7b8"}}, WithInstances(instances))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	families := gather(t, e)
+
+	cpu := families["neo4j_aura_cpu_usage"]
+	if cpu.GetType() != dto.MetricType_GAUGE || len(cpu.GetMetric()) != 1 {
+		t.Fatalf("unexpected cpu family: %v", cpu)
+	}
+	m := cpu.GetMetric()[0]
+	if labelValue(m, InstanceIDLabel) != "a1b2c3d4" || labelValue(m, InstanceNameLabel) != "orders" || labelValue(m, "availability_zone") != "a" {
+		t.Errorf("unexpected labels: %v", m.GetLabel())
+	}
+
+	if f := families["neo4j_db_query_execution_success_total"]; f.GetType() != dto.MetricType_COUNTER || f.GetMetric()[0].GetCounter().GetValue() != 42 {
+		t.Errorf("unexpected counter: %v", f)
+	}
+	hist := families["neo4j_db_query_execution_latency_millis"].GetMetric()[0].GetHistogram()
+	if hist.GetSampleCount() != 10 || hist.GetSampleSum() != 55 || len(hist.GetBucket()) != 1 || hist.GetBucket()[0].GetCumulativeCount() != 6 {
+		t.Errorf("unexpected histogram: %v", hist)
+	}
+	summary := families["neo4j_gc_pause_seconds"].GetMetric()[0].GetSummary()
+	if summary.GetSampleCount() != 4 || len(summary.GetQuantile()) != 1 {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+
+	if health := families[HealthStatusMetric].GetMetric(); len(health) != 1 || health[0].GetGauge().GetValue() != 1 {
+		t.Errorf("expected one warning health gauge, got %v", health)
+	}
+
+	success := map[string]float64{}
+	for _, m := range families[ScrapeSuccessMetric].GetMetric() {
+		success[labelValue(m, InstanceNameLabel)] = m.GetGauge().GetValue()
+	}
+	if success["orders"] != 1 || success["broken"] != 0 {
+		t.Errorf("unexpected scrape success: %v", success)
+	}
+}
+
+func TestExporter_Caching(t *testing.T) {
+	prom := newFakePrometheus()
+	e, err := New(prom, []Target{{InstanceID: "a1b2c3d4", MetricsURL: urlA}}, WithCacheTTL(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	e.now = func() time.Time { return now }
+
+	gather(t, e)
+	gather(t, e)
+	if prom.fetches[urlA] != 1 {
+		t.Errorf("expected cached result to be reused, got %d fetches", prom.fetches[urlA])
+	}
+
+	now = now.Add(time.Minute)
+	gather(t, e)
+	if prom.fetches[urlA] != 2 {
+		t.Errorf("expected refetch after TTL, got %d fetches", prom.fetches[urlA])
+	}
+}
+
+func TestExporter_InstanceLookedUpOnce(t *testing.T) {
+	prom := newFakePrometheus()
+	instances := &fakeInstances{instances: map[string]aura.InstanceData{
+		"a1b2c3d4": {ID: "a1b2c3d4", Name: "orders", MetricsURL: urlA},
+	}}
+	e, err := New(prom, []Target{{InstanceID: "a1b2c3d4"}}, WithInstances(instances), WithCacheTTL(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gather(t, e)
+	gather(t, e)
+	if instances.gets != 1 {
+		t.Errorf("expected one instance lookup, got %d", instances.gets)
+	}
+	if prom.fetches[urlA] != 2 {
+		t.Errorf("expected a fetch per scrape with no cache, got %d", prom.fetches[urlA])
+	}
+}
+
+func TestExporter_Relabel(t *testing.T) {
+	prom := newFakePrometheus()
+	e, err := New(prom, []Target{{InstanceID: "a1b2c3d4", MetricsURL: urlA}}, WithRelabel(func(name string, labels map[string]string) (string, bool) {
+		if name != "neo4j_aura_cpu_usage" {
+			return "", false
+		}
+		labels["zone"] = labels["availability_zone"]
+		delete(labels, "availability_zone")
+		return "aura_cpu_usage", true
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families := gather(t, e)
+	if _, ok := families["neo4j_db_query_execution_success_total"]; ok {
+		t.Error("expected dropped series to be absent")
+	}
+	cpu, ok := families["aura_cpu_usage"]
+	if !ok {
+		t.Fatalf("expected renamed family, got %v", families)
+	}
+	if labelValue(cpu.GetMetric()[0], "zone") != "a" {
+		t.Errorf("expected relabelled zone, got %v", cpu.GetMetric()[0].GetLabel())
+	}
+	if _, ok := families[ScrapeSuccessMetric]; !ok {
+		t.Error("exporter metrics must not be relabelled away")
+	}
+}
+
+func TestExporter_HealthRules(t *testing.T) {
+	prom := newFakePrometheus()
+	e, err := New(prom, []Target{{InstanceID: "a1b2c3d4", MetricsURL: urlA}}, WithHealthRules(aura.DefaultHealthRules()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gather(t, e)
+	if prom.rules != 1 {
+		t.Errorf("expected health rules to be passed to InstanceHealthFromMetrics")
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	prom := newFakePrometheus()
+	tests := []struct {
+		name    string
+		prom    aura.PrometheusService
+		targets []Target
+		opts    []Option
+	}{
+		{"nil service", nil, []Target{{InstanceID: "a", MetricsURL: urlA}}, nil},
+		{"no targets", prom, nil, nil},
+		{"no instance ID", prom, []Target{{MetricsURL: urlA}}, nil},
+		{"no URL without instances", prom, []Target{{InstanceID: "a"}}, nil},
+		{"duplicate", prom, []Target{{InstanceID: "a", MetricsURL: urlA}, {InstanceID: "a", MetricsURL: urlB}}, nil},
+		{"negative TTL", prom, []Target{{InstanceID: "a", MetricsURL: urlA}}, []Option{WithCacheTTL(-time.Second)}},
+		{"invalid rules", prom, []Target{{InstanceID: "a", MetricsURL: urlA}}, []Option{WithHealthRules(&aura.HealthRuleSet{})}},
+		{"nil relabel", prom, []Target{{InstanceID: "a", MetricsURL: urlA}}, []Option{WithRelabel(nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.prom, tt.targets, tt.opts...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package auratest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// FaultKind identifies how a faulty response misbehaves.
+type FaultKind string
+
+const (
+	// FaultStatus replies with Fault.Status (for example 429, 500 or 503)
+	// and an Aura-style error body, without touching server state.
+	FaultStatus FaultKind = "status"
+	// FaultLatency serves the request normally after Fault.Delay.
+	FaultLatency FaultKind = "latency"
+	// FaultMalformedJSON replies 200 with a body that is not valid JSON,
+	// without touching server state.
+	FaultMalformedJSON FaultKind = "malformed-json"
+	// FaultTruncate serves the request normally, so any state change takes
+	// effect, then drops the connection half way through the body.
+	FaultTruncate FaultKind = "truncate"
+	// FaultReset resets the TCP connection before anything is written.
+	FaultReset FaultKind = "reset"
+	// FaultTokenExpiry revokes every token issued so far and then handles the
+	// request, so an API call fails with 401 until a new token is fetched.
+	FaultTokenExpiry FaultKind = "token-expiry"
+)
+
+// Fault describes one faulty response. Build one with the helper functions
+// such as Status and Latency, or fill in the fields directly.
+type Fault struct {
+	Kind FaultKind
+	// Status is the status code written by FaultStatus.
+	Status int
+	// RetryAfter, when positive, sets a Retry-After header on FaultStatus
+	// responses.
+	RetryAfter time.Duration
+	// Delay is how long to wait before applying the fault. It is the whole
+	// effect of FaultLatency and can be combined with any other kind.
+	Delay time.Duration
+}
+
+// FaultRule injects a fault into matching requests. Matching requests are
+// counted per rule, starting at 1. A rule fires on the calls listed in Calls
+// if set, otherwise with Probability if set, otherwise on every call. When
+// several rules fire for one request the first one added wins.
+type FaultRule struct {
+	// Method restricts the rule to one HTTP method. Empty matches any method.
+	Method string
+	// Path is a path.Match pattern such as "/v1/instances/*". Empty matches
+	// every path, including /oauth/token.
+	Path string
+	// Fault is the fault to inject.
+	Fault Fault
+	// Calls lists the matching calls, counted from 1, on which to fire.
+	Calls []int
+	// Probability fires the rule on each matching call with this
+	// probability, between 0 and 1. It is ignored when Calls is set.
+	Probability float64
+}
+
+// activeFault is a FaultRule installed on a Server.
+type activeFault struct {
+	id    int
+	rule  FaultRule
+	calls int
+}
+
+// ============================================================================
+// Fault helpers
+// ============================================================================
+
+// Status returns a fault that replies with the given status code.
+func Status(code int) Fault {
+	return Fault{Kind: FaultStatus, Status: code}
+}
+
+// RateLimited returns a 429 fault with a Retry-After header.
+func RateLimited(retryAfter time.Duration) Fault {
+	return Fault{Kind: FaultStatus, Status: http.StatusTooManyRequests, RetryAfter: retryAfter}
+}
+
+// Latency returns a fault that delays the response by d.
+func Latency(d time.Duration) Fault {
+	return Fault{Kind: FaultLatency, Delay: d}
+}
+
+// MalformedJSON returns a fault that replies with invalid JSON.
+func MalformedJSON() Fault {
+	return Fault{Kind: FaultMalformedJSON}
+}
+
+// Truncate returns a fault that cuts the response body short.
+func Truncate() Fault {
+	return Fault{Kind: FaultTruncate}
+}
+
+// Reset returns a fault that resets the connection.
+func Reset() Fault {
+	return Fault{Kind: FaultReset}
+}
+
+// ExpireTokens returns a fault that revokes all issued tokens.
+func ExpireTokens() Fault {
+	return Fault{Kind: FaultTokenExpiry}
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithFaultSeed seeds the random source used by probabilistic fault rules.
+// Servers with the same seed and the same sequence of requests inject the
+// same faults. Defaults to 1.
+func WithFaultSeed(seed uint64) Option {
+	return func(o *options) {
+		o.faultSeed = seed
+	}
+}
+
+// ============================================================================
+// Server methods
+// ============================================================================
+
+// InjectFault installs a fault rule and returns a function that removes it.
+// It panics if the rule is invalid, since that is a bug in the test.
+func (s *Server) InjectFault(rule FaultRule) (remove func()) {
+	if err := rule.validate(); err != nil {
+		panic("auratest: " + err.Error())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faultSeq++
+	id := s.faultSeq
+	s.faults = append(s.faults, &activeFault{id: id, rule: rule})
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, f := range s.faults {
+			if f.id == id {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// ClearFaults removes every fault rule.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// validate reports whether r can be installed.
+func (r FaultRule) validate() error {
+	if _, err := path.Match(r.Path, "/"); err != nil {
+		return fmt.Errorf("invalid fault path pattern %q: %w", r.Path, err)
+	}
+	if r.Probability < 0 || r.Probability > 1 {
+		return fmt.Errorf("fault probability %v must be between 0 and 1", r.Probability)
+	}
+	for _, n := range r.Calls {
+		if n < 1 {
+			return fmt.Errorf("fault call number %d must be at least 1", n)
+		}
+	}
+	switch r.Fault.Kind {
+	case FaultStatus:
+		if r.Fault.Status < 100 || r.Fault.Status > 599 {
+			return fmt.Errorf("fault status %d is not an HTTP status code", r.Fault.Status)
+		}
+	case FaultLatency, FaultMalformedJSON, FaultTruncate, FaultReset, FaultTokenExpiry:
+	default:
+		return fmt.Errorf("unknown fault kind %q", r.Fault.Kind)
+	}
+	if r.Fault.Delay < 0 {
+		return fmt.Errorf("fault delay %v must not be negative", r.Fault.Delay)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to r.
+func (f *activeFault) matches(r *http.Request) bool {
+	if f.rule.Method != "" && f.rule.Method != r.Method {
+		return false
+	}
+	if f.rule.Path == "" {
+		return true
+	}
+	ok, _ := path.Match(f.rule.Path, r.URL.Path)
+	return ok
+}
+
+// fires counts a matching call and reports whether the rule fires on it.
+func (f *activeFault) fires(rng *rand.Rand) bool {
+	f.calls++
+	switch {
+	case len(f.rule.Calls) > 0:
+		for _, n := range f.rule.Calls {
+			if n == f.calls {
+				return true
+			}
+		}
+		return false
+	case f.rule.Probability > 0:
+		return rng.Float64() < f.rule.Probability
+	default:
+		return true
+	}
+}
+
+// pickFault counts r against every matching rule and returns the fault of
+// the first rule that fires.
+func (s *Server) pickFault(r *http.Request) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var picked Fault
+	found := false
+	for _, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+		if f.fires(s.rng) && !found {
+			picked, found = f.rule.Fault, true
+		}
+	}
+	return picked, found
+}
+
+// serveFault applies fault to the request, calling next where the fault
+// serves the request normally. It returns the status written, or 0 if the
+// connection was dropped.
+func (s *Server) serveFault(w http.ResponseWriter, r *http.Request, fault Fault, next http.Handler) int {
+	if fault.Delay > 0 {
+		t := time.NewTimer(fault.Delay)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return 0
+		}
+	}
+
+	switch fault.Kind {
+	case FaultStatus:
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((fault.RetryAfter+time.Second-1)/time.Second)))
+		}
+		writeError(w, fault.Status, "Injected fault: "+http.StatusText(fault.Status), "injected-fault", "")
+		return fault.Status
+
+	case FaultMalformedJSON:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": [{"id": "`))
+		return http.StatusOK
+
+	case FaultTruncate:
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		body := rec.Body.Bytes()
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rec.Code)
+		w.Write(body[:len(body)/2])
+		resetConn(w)
+		return 0
+
+	case FaultReset:
+		resetConn(w)
+		return 0
+
+	case FaultTokenExpiry:
+		s.mu.Lock()
+		clear(s.tokens)
+		s.mu.Unlock()
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	return rec.status
+}
+
+// resetConn closes the underlying connection with an RST where possible, or
+// aborts the handler so the server drops the connection.
+func resetConn(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if buf != nil && buf.Writer.Buffered() > 0 {
+		buf.Flush()
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
@@ -0,0 +1,192 @@
+package auratest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auratest"
+	"github.com/LackOfMorals/aura-client/internal/api"
+)
+
+func TestFaults_StatusByCallSequence(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	srv.InjectFault(auratest.FaultRule{
+		Method: http.MethodGet, Path: "/v1/tenants",
+		Fault: auratest.RateLimited(2 * time.Second), Calls: []int{1, 3},
+	})
+	srv.InjectFault(auratest.FaultRule{Path: "/v1/tenants", Fault: auratest.Status(http.StatusServiceUnavailable), Calls: []int{2, 3}})
+
+	// Call 1: 429; call 2: 503; call 3: both rules fire, the first added wins; call 4: ok.
+	want := []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusTooManyRequests, 0}
+	for i, status := range want {
+		_, err := client.Tenants.List(ctx)
+		var apiErr *api.Error
+		switch {
+		case status == 0 && err != nil:
+			t.Errorf("call %d: expected success, got %v", i+1, err)
+		case status != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != status):
+			t.Errorf("call %d: expected %d, got %v", i+1, status, err)
+		}
+	}
+
+	// Other endpoints are unaffected.
+	if _, err := client.Instances.List(ctx); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestFaults_RetryAfterHeader(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(auratest.FaultRule{Fault: auratest.RateLimited(1500 * time.Millisecond)})
+
+	resp, err := http.Get(srv.URL + "/v1/tenants")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "2" {
+		t.Errorf("Expected 429 with Retry-After 2, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestFaults_Probability(t *testing.T) {
+	count := func(seed uint64) []bool {
+		srv := auratest.NewServer(auratest.WithFaultSeed(seed))
+		defer srv.Close()
+		client := newClient(t, srv)
+		srv.InjectFault(auratest.FaultRule{Path: "/v1/instances", Fault: auratest.Status(http.StatusInternalServerError), Probability: 0.5})
+
+		var failed []bool
+		for i := 0; i < 40; i++ {
+			_, err := client.Instances.List(context.Background())
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	first, second := count(7), count(7)
+	n := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same seed to inject the same faults, differed at call %d", i+1)
+		}
+		if first[i] {
+			n++
+		}
+	}
+	if n < 10 || n > 30 {
+		t.Errorf("Expected about half of 40 calls to fail, got %d", n)
+	}
+}
+
+func TestFaults_MalformedJSON(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	srv.InjectFault(auratest.FaultRule{Path: "/v1/instances/*", Fault: auratest.MalformedJSON()})
+
+	inst := srv.AddInstance(aura.InstanceData{Name: "a", TenantID: auratest.DefaultTenantID})
+	if _, err := client.Instances.Get(context.Background(), inst.ID); err == nil {
+		t.Error("Expected a decoding error")
+	}
+}
+
+func TestFaults_Latency(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv, aura.WithTimeout(50*time.Millisecond))
+	remove := srv.InjectFault(auratest.FaultRule{Path: "/v1/tenants", Fault: auratest.Latency(time.Second)})
+
+	start := time.Now()
+	if _, err := client.Tenants.List(context.Background()); err == nil {
+		t.Fatal("Expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Expected the client to give up early, took %v", elapsed)
+	}
+
+	remove()
+	if _, err := client.Tenants.List(context.Background()); err != nil {
+		t.Errorf("Expected no error once the fault is removed, got %v", err)
+	}
+}
+
+func TestFaults_TokenExpiry(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+	srv.InjectFault(auratest.FaultRule{Path: "/v1/*", Fault: auratest.ExpireTokens(), Calls: []int{2}})
+
+	if _, err := client.Tenants.List(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err := client.Tenants.List(ctx)
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) || !apiErr.IsUnauthorized() {
+		t.Fatalf("Expected 401 after the token was revoked, got %v", err)
+	}
+
+	// A fresh client fetches a new token.
+	if _, err := newClient(t, srv).Tenants.List(ctx); err != nil {
+		t.Errorf("Expected a new token to work, got %v", err)
+	}
+}
+
+func TestFaults_TruncateAppliesState(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	inst := srv.AddInstance(aura.InstanceData{Name: "a", TenantID: auratest.DefaultTenantID})
+	srv.InjectFault(auratest.FaultRule{Method: http.MethodPost, Path: "/v1/instances/*/pause", Fault: auratest.Truncate()})
+
+	if _, err := newClient(t, srv).Instances.Pause(context.Background(), inst.ID); err == nil {
+		t.Error("Expected a truncated response to fail")
+	}
+	if got, _ := srv.Instance(inst.ID); got.Status != aura.StatusPaused {
+		t.Errorf("Expected the pause to have taken effect, got %s", got.Status)
+	}
+	reqs := srv.Requests()
+	if last := reqs[len(reqs)-1]; last.Fault != auratest.FaultTruncate || last.Status != 0 {
+		t.Errorf("Expected the truncation to be recorded, got %+v", last)
+	}
+}
+
+func TestFaults_Reset(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(auratest.FaultRule{Path: "/oauth/token", Fault: auratest.Reset(), Calls: []int{1}})
+
+	resp, err := http.Post(srv.URL+"/oauth/token", "application/x-www-form-urlencoded", nil)
+	if err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		t.Fatal("Expected the connection to be reset")
+	}
+
+	// Only the first call is reset.
+	if _, err := newClient(t, srv).Tenants.List(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestFaults_InvalidRulePanics(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	srv.InjectFault(auratest.FaultRule{Fault: auratest.Fault{Kind: auratest.FaultStatus, Status: 42}})
+}
@@ -0,0 +1,663 @@
+package auratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	utils "github.com/LackOfMorals/aura-client/internal/utils"
+)
+
+// gdsSessionSizes are the memory tiers, in GB, a sizing estimate is rounded
+// up to.
+var gdsSessionSizes = []int{1, 2, 4, 8, 16, 24, 32, 48, 64, 96, 128, 192, 256, 384, 512}
+
+// routes registers the v1 API and metrics endpoints on mux.
+func (s *Server) routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/instances", s.listInstances)
+	mux.HandleFunc("POST /v1/instances", s.createInstance)
+	mux.HandleFunc("GET /v1/instances/{id}", s.getInstance)
+	mux.HandleFunc("PATCH /v1/instances/{id}", s.updateInstance)
+	mux.HandleFunc("DELETE /v1/instances/{id}", s.deleteInstance)
+	mux.HandleFunc("POST /v1/instances/{id}/pause", s.pauseInstance)
+	mux.HandleFunc("POST /v1/instances/{id}/resume", s.resumeInstance)
+	mux.HandleFunc("POST /v1/instances/{id}/overwrite", s.overwriteInstance)
+
+	mux.HandleFunc("GET /v1/instances/{id}/snapshots", s.listSnapshots)
+	mux.HandleFunc("POST /v1/instances/{id}/snapshots", s.createSnapshot)
+	mux.HandleFunc("GET /v1/instances/{id}/snapshots/{sid}", s.getSnapshot)
+	mux.HandleFunc("POST /v1/instances/{id}/snapshots/{sid}/restore", s.restoreSnapshot)
+
+	mux.HandleFunc("GET /v1/tenants", s.listTenants)
+	mux.HandleFunc("GET /v1/tenants/{id}", s.getTenant)
+	mux.HandleFunc("GET /v1/tenants/{id}/metrics-integration", s.getTenantMetricsURL)
+
+	mux.HandleFunc("GET /v1/customer-managed-keys", s.listCMEKs)
+
+	mux.HandleFunc("GET /v1/graph-analytics/sessions", s.listGDSSessions)
+	mux.HandleFunc("POST /v1/graph-analytics/sessions", s.createGDSSession)
+	mux.HandleFunc("POST /v1/graph-analytics/sessions/sizing", s.estimateGDSSession)
+	mux.HandleFunc("GET /v1/graph-analytics/sessions/{id}", s.getGDSSession)
+	mux.HandleFunc("DELETE /v1/graph-analytics/sessions/{id}", s.deleteGDSSession)
+
+	mux.HandleFunc("GET /metrics/", s.getMetrics)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Not found", "not-found", "")
+	})
+}
+
+// ============================================================================
+// Instances
+// ============================================================================
+
+func (s *Server) listInstances(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settle()
+
+	out := aura.ListInstancesResponse{Data: []aura.ListInstanceData{}}
+	for _, inst := range s.instances {
+		out.Data = append(out.Data, aura.ListInstanceData{
+			ID:            inst.data.ID,
+			Name:          inst.data.Name,
+			Created:       inst.created.UTC().Format(time.RFC3339),
+			TenantID:      inst.data.TenantID,
+			CloudProvider: inst.data.CloudProvider,
+		})
+	}
+	sort.Slice(out.Data, func(i, j int) bool { return out.Data[i].ID < out.Data[j].ID })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createInstance(w http.ResponseWriter, r *http.Request) {
+	var req aura.CreateInstanceConfigData
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenant, ok := s.tenants[req.TenantID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Tenant %s not found", req.TenantID), "tenant-not-found", "tenant_id")
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Instance name is required", "invalid-field", "name")
+		return
+	}
+	config, ok := matchConfiguration(tenant.InstanceConfigurations, req)
+	if !ok {
+		writeError(w, http.StatusBadRequest,
+			fmt.Sprintf("Tenant %s does not offer a %s %s instance in %s/%s", tenant.ID, req.Memory, req.Type, req.CloudProvider, req.Region),
+			"invalid-configuration", "")
+		return
+	}
+
+	id := s.newInstanceID()
+	password := s.newSecret()
+	storage := config.Storage
+	if storage == "" {
+		storage = storageFor(req.Memory)
+	}
+	inst := &instance{
+		data: aura.InstanceData{
+			ID:            id,
+			Name:          req.Name,
+			TenantID:      req.TenantID,
+			CloudProvider: req.CloudProvider,
+			Region:        req.Region,
+			Type:          req.Type,
+			Memory:        req.Memory,
+			Storage:       &storage,
+			CDCEnrichment: "OFF",
+		},
+		created: s.opts.now(),
+	}
+	s.fillInstanceURLs(&inst.data)
+	s.startInstanceTransition(inst, aura.StatusCreating, aura.StatusRunning)
+	s.instances[id] = inst
+
+	writeJSON(w, http.StatusAccepted, aura.CreateInstanceResponse{Data: aura.CreateInstanceData{
+		ID:            id,
+		Name:          inst.data.Name,
+		TenantID:      inst.data.TenantID,
+		CloudProvider: inst.data.CloudProvider,
+		ConnectionURL: inst.data.ConnectionURL,
+		Region:        inst.data.Region,
+		Type:          inst.data.Type,
+		Username:      "neo4j",
+		Password:      password,
+	}})
+}
+
+// matchConfiguration returns the configuration that req asks for. A tenant
+// without configurations accepts anything.
+func matchConfiguration(configs []aura.TenantInstanceConfiguration, req aura.CreateInstanceConfigData) (aura.TenantInstanceConfiguration, bool) {
+	if len(configs) == 0 {
+		return aura.TenantInstanceConfiguration{}, true
+	}
+	for _, c := range configs {
+		if c.CloudProvider == req.CloudProvider && c.Region == req.Region && c.Type == req.Type && c.Memory == req.Memory &&
+			(req.Version == "" || c.Version == req.Version) {
+			return c, true
+		}
+	}
+	return aura.TenantInstanceConfiguration{}, false
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, aura.GetInstanceResponse{Data: reportInstance(inst)})
+}
+
+func (s *Server) updateInstance(w http.ResponseWriter, r *http.Request) {
+	var req aura.UpdateInstanceData
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	switch req.CDCEnrichmentMode {
+	case "", "OFF", "DIFF", "FULL":
+	default:
+		writeError(w, http.StatusBadRequest, "cdc_enrichment_mode must be OFF, DIFF or FULL", "invalid-field", "cdc_enrichment_mode")
+		return
+	}
+	if req.Memory != "" && req.Memory != inst.data.Memory {
+		if !requireStatus(w, inst, aura.StatusRunning) {
+			return
+		}
+		if _, err := utils.ParseMemoryGB(req.Memory); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "invalid-field", "memory")
+			return
+		}
+		inst.data.Memory = req.Memory
+		storage := storageFor(req.Memory)
+		inst.data.Storage = &storage
+		s.startInstanceTransition(inst, aura.StatusUpdating, aura.StatusRunning)
+	}
+	if req.Name != "" {
+		inst.data.Name = req.Name
+	}
+	if req.SecondariesCount != nil {
+		inst.data.Secondaries = *req.SecondariesCount
+	}
+	if req.CDCEnrichmentMode != "" {
+		inst.data.CDCEnrichment = req.CDCEnrichmentMode
+	}
+	writeJSON(w, http.StatusOK, aura.GetInstanceResponse{Data: reportInstance(inst)})
+}
+
+func (s *Server) deleteInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	if inst.data.Status == aura.StatusDestroying {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Instance %s is already being deleted", inst.data.ID), "invalid-state", "")
+		return
+	}
+	s.startInstanceTransition(inst, aura.StatusDestroying, "")
+	inst.pending.remove = true
+	writeJSON(w, http.StatusAccepted, aura.DeleteInstanceResponse{Data: reportInstance(inst)})
+}
+
+func (s *Server) pauseInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok || !requireStatus(w, inst, aura.StatusRunning) {
+		return
+	}
+	s.startInstanceTransition(inst, aura.StatusPausing, aura.StatusPaused)
+	writeJSON(w, http.StatusAccepted, aura.GetInstanceResponse{Data: reportInstance(inst)})
+}
+
+func (s *Server) resumeInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok || !requireStatus(w, inst, aura.StatusPaused) {
+		return
+	}
+	s.startInstanceTransition(inst, aura.StatusResuming, aura.StatusRunning)
+	writeJSON(w, http.StatusAccepted, aura.GetInstanceResponse{Data: reportInstance(inst)})
+}
+
+func (s *Server) overwriteInstance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SourceInstanceID string `json:"source_instance_id"`
+		SourceSnapshotID string `json:"source_snapshot_id"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok || !requireStatus(w, inst, aura.StatusRunning) {
+		return
+	}
+	switch {
+	case req.SourceInstanceID != "":
+		if req.SourceInstanceID == inst.data.ID {
+			writeError(w, http.StatusBadRequest, "An instance cannot be overwritten from itself", "invalid-field", "source_instance_id")
+			return
+		}
+		if _, ok := s.lookupInstance(w, req.SourceInstanceID); !ok {
+			return
+		}
+	case req.SourceSnapshotID != "":
+		if _, ok := s.snapshots[req.SourceSnapshotID]; !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Snapshot %s not found", req.SourceSnapshotID), "not-found", "source_snapshot_id")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "One of source_instance_id or source_snapshot_id is required", "invalid-field", "")
+		return
+	}
+	s.startInstanceTransition(inst, aura.StatusOverwriting, aura.StatusRunning)
+	writeJSON(w, http.StatusAccepted, aura.OverwriteInstanceResponse{Data: s.newUUID()})
+}
+
+// lookupInstance returns the instance with id after settling due
+// transitions, or writes a 404.
+func (s *Server) lookupInstance(w http.ResponseWriter, id string) (*instance, bool) {
+	s.settle()
+	inst, ok := s.instances[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Instance %s not found", id), "not-found", "")
+	}
+	return inst, ok
+}
+
+// requireStatus writes a 409 unless inst is settled in status want.
+func requireStatus(w http.ResponseWriter, inst *instance, want aura.InstanceStatus) bool {
+	if inst.pending == nil && inst.data.Status == want {
+		return true
+	}
+	writeError(w, http.StatusConflict,
+		fmt.Sprintf("Instance %s is %s, must be %s", inst.data.ID, inst.data.Status, want), "invalid-state", "")
+	return false
+}
+
+// ============================================================================
+// Snapshots
+// ============================================================================
+
+func (s *Server) listSnapshots(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookupInstance(w, r.PathValue("id")); !ok {
+		return
+	}
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			writeError(w, http.StatusBadRequest, "date must be in YYYY-MM-DD format", "invalid-field", "date")
+			return
+		}
+	}
+	snaps := s.snapshotsOf(r.PathValue("id"), date)
+	for _, snap := range s.snapshots {
+		if snap.data.InstanceID == r.PathValue("id") && snap.pending != nil {
+			snap.pending.reported = true
+		}
+	}
+	writeJSON(w, http.StatusOK, aura.GetSnapshotsResponse{Data: snaps})
+}
+
+func (s *Server) createSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.lookupInstance(w, r.PathValue("id"))
+	if !ok || !requireStatus(w, inst, aura.StatusRunning) {
+		return
+	}
+	snap := &snapshot{
+		data: aura.GetSnapshotData{
+			InstanceID: inst.data.ID,
+			SnapshotID: s.newUUID(),
+			Profile:    "AdHoc",
+			Status:     SnapshotPending,
+			Timestamp:  s.opts.now().UTC(),
+			Exportable: true,
+		},
+		pending: &transition{to: SnapshotCompleted, at: s.opts.now().Add(s.opts.transitionDelay)},
+	}
+	s.snapshots[snap.data.SnapshotID] = snap
+	writeJSON(w, http.StatusAccepted, aura.CreateSnapshotResponse{Data: aura.CreateSnapshotData{SnapshotID: snap.data.SnapshotID}})
+}
+
+func (s *Server) getSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.lookupSnapshot(w, r.PathValue("id"), r.PathValue("sid"))
+	if !ok {
+		return
+	}
+	if snap.pending != nil {
+		snap.pending.reported = true
+	}
+	writeJSON(w, http.StatusOK, aura.GetSnapshotDataResponse{Data: snap.data})
+}
+
+func (s *Server) restoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.lookupSnapshot(w, r.PathValue("id"), r.PathValue("sid"))
+	if !ok {
+		return
+	}
+	if snap.data.Status != SnapshotCompleted {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Snapshot %s is %s, must be %s", snap.data.SnapshotID, snap.data.Status, SnapshotCompleted), "invalid-state", "")
+		return
+	}
+	inst := s.instances[snap.data.InstanceID]
+	if !requireStatus(w, inst, aura.StatusRunning) {
+		return
+	}
+	s.startInstanceTransition(inst, aura.StatusRestoring, aura.StatusRunning)
+	writeJSON(w, http.StatusAccepted, aura.RestoreSnapshotResponse{Data: reportInstance(inst)})
+}
+
+// lookupSnapshot returns a snapshot of an existing instance, or writes a 404.
+func (s *Server) lookupSnapshot(w http.ResponseWriter, instanceID, snapshotID string) (*snapshot, bool) {
+	if _, ok := s.lookupInstance(w, instanceID); !ok {
+		return nil, false
+	}
+	snap, ok := s.snapshots[snapshotID]
+	if !ok || snap.data.InstanceID != instanceID {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Snapshot %s not found", snapshotID), "not-found", "")
+		return nil, false
+	}
+	return snap, true
+}
+
+// ============================================================================
+// Tenants and customer-managed keys
+// ============================================================================
+
+func (s *Server) listTenants(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := aura.ListTenantsResponse{Data: []aura.TenantsResponseData{}}
+	for _, t := range s.tenants {
+		out.Data = append(out.Data, aura.TenantsResponseData{ID: t.ID, Name: t.Name})
+	}
+	sort.Slice(out.Data, func(i, j int) bool { return out.Data[i].ID < out.Data[j].ID })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getTenant(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.lookupTenant(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, aura.GetTenantResponse{Data: *t})
+}
+
+func (s *Server) getTenantMetricsURL(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.lookupTenant(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, aura.GetTenantMetricsURLResponse{Data: aura.GetTenantMetricsURLData{
+		Endpoint: s.URL + "/metrics/tenants/" + t.ID,
+	}})
+}
+
+// lookupTenant returns the tenant with id, or writes a 404.
+func (s *Server) lookupTenant(w http.ResponseWriter, id string) (*aura.TenantResponseData, bool) {
+	t, ok := s.tenants[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Tenant %s not found", id), "not-found", "")
+	}
+	return t, ok
+}
+
+func (s *Server) listCMEKs(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.URL.Query().Get("tenantID")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := aura.GetCmeksResponse{Data: []aura.GetCmeksData{}}
+	for _, key := range s.cmeks {
+		if tenantID == "" || key.TenantID == tenantID {
+			out.Data = append(out.Data, key)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// ============================================================================
+// Graph analytics
+// ============================================================================
+
+func (s *Server) listGDSSessions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settle()
+	out := aura.GetGDSSessionListResponse{Data: []aura.GetGDSSessionData{}}
+	for _, session := range s.sessions {
+		if session.pending != nil {
+			session.pending.reported = true
+		}
+		out.Data = append(out.Data, session.data)
+	}
+	sort.Slice(out.Data, func(i, j int) bool { return out.Data[i].ID < out.Data[j].ID })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createGDSSession(w http.ResponseWriter, r *http.Request) {
+	var req aura.CreateGDSSessionConfigData
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Session name is required", "invalid-field", "name")
+		return
+	}
+	if (req.InstanceID == "") == (req.DatabaseID == "") {
+		writeError(w, http.StatusBadRequest, "Exactly one of instance_id or database_uuid is required", "invalid-field", "instance_id")
+		return
+	}
+	if _, err := utils.ParseMemoryGB(req.Memory); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid-field", "memory")
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = utils.ParseTTL(req.TTL); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error(), "invalid-field", "ttl")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookupTenant(w, req.TenantID); !ok {
+		return
+	}
+	provider, region := req.CloudProvider, req.Region
+	if req.InstanceID != "" {
+		inst, ok := s.lookupInstance(w, req.InstanceID)
+		if !ok {
+			return
+		}
+		provider, region = inst.data.CloudProvider, inst.data.Region
+	}
+
+	now := s.opts.now().UTC()
+	session := &gdsSession{
+		data: aura.GetGDSSessionData{
+			ID:            s.newSessionID(),
+			Name:          req.Name,
+			Memory:        req.Memory,
+			InstanceID:    req.InstanceID,
+			DatabaseID:    req.DatabaseID,
+			Status:        GDSSessionCreating,
+			CreatedAt:     now,
+			TTL:           req.TTL,
+			TenantID:      req.TenantID,
+			CloudProvider: provider,
+			Region:        region,
+		},
+		pending: &transition{to: GDSSessionReady, at: now.Add(s.opts.transitionDelay)},
+	}
+	session.data.Host = session.data.ID + ".sessions.neo4j.io"
+	if ttl > 0 {
+		session.data.ExpiresAt = now.Add(ttl)
+	}
+	session.pending.reported = true // the create response reports the status
+	s.sessions[session.data.ID] = session
+	writeJSON(w, http.StatusAccepted, aura.GetGDSSessionResponse{Data: session.data})
+}
+
+func (s *Server) estimateGDSSession(w http.ResponseWriter, r *http.Request) {
+	var req aura.GetGDSSessionSizeEstimation
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.NodeCount < 0 || req.RelationshipCount < 0 {
+		writeError(w, http.StatusBadRequest, "Counts must not be negative", "invalid-field", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, aura.GDSSessionSizeEstimationResponse{Data: estimateGDSSessionSize(req)})
+}
+
+// estimateGDSSessionSize is a deterministic stand-in for the Aura sizing
+// model: a fixed cost per node, relationship and property, with a 1 GB
+// floor, rounded up to the next session size.
+func estimateGDSSessionSize(req aura.GetGDSSessionSizeEstimation) aura.GDSSessionSizeEstimationData {
+	bytes := int64(req.NodeCount)*(64+16*int64(req.NodePropertyCount)) +
+		int64(req.RelationshipCount)*(32+16*int64(req.RelationshipPropertyCount))
+	bytes += bytes * int64(len(req.AlgorithmCategories)) / 2
+	gb := int((bytes + (1<<30 - 1)) >> 30)
+	if gb < 1 {
+		gb = 1
+	}
+	recommended := gdsSessionSizes[len(gdsSessionSizes)-1]
+	for _, size := range gdsSessionSizes {
+		if size >= gb {
+			recommended = size
+			break
+		}
+	}
+	return aura.GDSSessionSizeEstimationData{
+		EstimatedMemory: utils.FormatMemoryGB(gb),
+		RecommendedSize: utils.FormatMemoryGB(recommended),
+	}
+}
+
+func (s *Server) getGDSSession(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.lookupGDSSession(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	if session.pending != nil {
+		session.pending.reported = true
+	}
+	writeJSON(w, http.StatusOK, aura.GetGDSSessionResponse{Data: session.data})
+}
+
+func (s *Server) deleteGDSSession(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.lookupGDSSession(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	delete(s.sessions, session.data.ID)
+	writeJSON(w, http.StatusAccepted, aura.DeleteGDSSessionResponse{Data: aura.DeleteGDSSession{ID: session.data.ID}})
+}
+
+// lookupGDSSession returns the session with id after settling due
+// transitions and expiring sessions past their TTL, or writes a 404.
+func (s *Server) lookupGDSSession(w http.ResponseWriter, id string) (*gdsSession, bool) {
+	s.settle()
+	session, ok := s.sessions[id]
+	if ok && !session.data.ExpiresAt.IsZero() && !s.opts.now().Before(session.data.ExpiresAt) {
+		delete(s.sessions, id)
+		ok = false
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("GDS session %s not found", id), "not-found", "")
+	}
+	return session, ok
+}
+
+// ============================================================================
+// Metrics
+// ============================================================================
+
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	text, ok := s.metrics[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "No metrics set for "+strings.TrimPrefix(r.URL.Path, "/metrics/"), "not-found", "")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(text))
+}
+
+// ============================================================================
+// Encoding
+// ============================================================================
+
+// decodeBody decodes a JSON request body into v, writing a 400 on failure.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Request body is not valid JSON: "+err.Error(), "invalid-body", "")
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the shape the Aura API uses.
+func writeError(w http.ResponseWriter, status int, message, reason, field string) {
+	type detail struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason,omitempty"`
+		Field   string `json:"field,omitempty"`
+	}
+	writeJSON(w, status, struct {
+		Errors []detail `json:"errors"`
+	}{Errors: []detail{{Message: message, Reason: reason, Field: field}}})
+}
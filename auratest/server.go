@@ -0,0 +1,617 @@
+// Package auratest provides an in-memory fake of the Aura API for testing
+// code built on the aura package without network access or an Aura account.
+//
+// A Server is an httptest.Server that issues OAuth tokens and emulates the v1
+// instances, snapshots, tenants, customer-managed-keys and graph-analytics
+// endpoints, including the status transitions a real instance goes through:
+//
+//	srv := auratest.NewServer()
+//	defer srv.Close()
+//
+//	client, err := srv.Client() // an *aura.AuraAPIClient using WithInsecureBaseURL
+//	created, err := client.Instances.Create(ctx, &aura.CreateInstanceConfigData{
+//		Name: "test", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+//		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB",
+//	})
+//	inst, err := client.Instances.Get(ctx, created.Data.ID) // status "creating"
+//	inst, err = client.Instances.Get(ctx, created.Data.ID)  // status "running"
+//
+// State can be seeded with the Add methods and inspected with Instance,
+// Snapshots and GDSSession. InjectFault scripts failures such as 503s,
+// latency, malformed JSON and connection resets. The server is safe for
+// concurrent use.
+package auratest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Credentials accepted by a Server unless WithCredentials is given.
+const (
+	ClientID     = "auratest-client-id"
+	ClientSecret = "auratest-client-secret"
+)
+
+// DefaultTenantID is the ID of the tenant every Server starts with.
+const DefaultTenantID = "a1b2c3d4-0000-4000-8000-000000000001"
+
+// Server defaults, used when the corresponding option is not given.
+const (
+	defaultTokenTTL = time.Hour
+)
+
+// Snapshot statuses reported by a Server.
+const (
+	SnapshotPending   = "Pending"
+	SnapshotCompleted = "Completed"
+)
+
+// GDS session statuses reported by a Server.
+const (
+	GDSSessionCreating = "Creating"
+	GDSSessionReady    = "Ready"
+)
+
+// Request is one request received by a Server, recorded in order.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Status int // 0 if the connection was dropped
+	At     time.Time
+	// Fault is the kind of fault injected into the request, if any.
+	Fault FaultKind
+}
+
+// Option configures a Server.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	clientID        string
+	clientSecret    string
+	tokenTTL        time.Duration
+	transitionDelay time.Duration
+	now             func() time.Time
+	faultSeed       uint64
+}
+
+// Server is a fake Aura API. Create one with NewServer and stop it with Close.
+type Server struct {
+	// URL is the base URL of the server, for aura.WithInsecureBaseURL.
+	URL string
+
+	srv  *httptest.Server
+	opts options
+
+	mu        sync.Mutex
+	seq       uint64
+	tokens    map[string]time.Time // access token to expiry
+	tenants   map[string]*aura.TenantResponseData
+	instances map[string]*instance
+	snapshots map[string]*snapshot // keyed by snapshot ID
+	cmeks     []aura.GetCmeksData
+	sessions  map[string]*gdsSession
+	metrics   map[string]string // exposition text keyed by metrics path
+	requests  []Request
+	faults    []*activeFault
+	faultSeq  int
+	rng       *rand.Rand
+}
+
+// transition is a pending change from a transitional status. It completes
+// once it has been reported at least once and its delay has elapsed, so a
+// caller always observes the transitional status before the final one.
+type transition struct {
+	to       string
+	at       time.Time
+	reported bool
+	remove   bool // delete the resource when the transition completes
+}
+
+// due reports whether t can complete at now.
+func (t *transition) due(now time.Time) bool {
+	return t != nil && t.reported && !now.Before(t.at)
+}
+
+type instance struct {
+	data    aura.InstanceData
+	created time.Time
+	pending *transition
+}
+
+type snapshot struct {
+	data    aura.GetSnapshotData
+	pending *transition
+}
+
+type gdsSession struct {
+	data    aura.GetGDSSessionData
+	pending *transition
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithCredentials sets the client ID and secret /oauth/token accepts.
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(o *options) {
+		o.clientID, o.clientSecret = clientID, clientSecret
+	}
+}
+
+// WithTokenTTL sets the lifetime of issued tokens. Defaults to one hour. The
+// aura client refreshes tokens within 60 seconds of expiry, so a TTL at or
+// below that forces a new token for every request.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.tokenTTL = ttl
+	}
+}
+
+// WithTransitionDelay sets the minimum time a resource stays in a
+// transitional status such as creating or pausing. Whatever the delay, the
+// transitional status is reported at least once before the final status.
+// Defaults to 0.
+func WithTransitionDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.transitionDelay = d
+	}
+}
+
+// WithClock sets the clock used for timestamps, token expiry and transition
+// delays. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// ============================================================================
+// Server
+// ============================================================================
+
+// NewServer starts a Server with one tenant, DefaultTenantID, that offers a
+// small set of instance configurations on each cloud provider.
+func NewServer(opts ...Option) *Server {
+	o := options{clientID: ClientID, clientSecret: ClientSecret, tokenTTL: defaultTokenTTL, now: time.Now, faultSeed: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &Server{
+		opts:      o,
+		tokens:    make(map[string]time.Time),
+		tenants:   make(map[string]*aura.TenantResponseData),
+		instances: make(map[string]*instance),
+		snapshots: make(map[string]*snapshot),
+		sessions:  make(map[string]*gdsSession),
+		metrics:   make(map[string]string),
+		rng:       rand.New(rand.NewPCG(o.faultSeed, o.faultSeed)),
+	}
+	s.AddTenant(aura.TenantResponseData{
+		ID:                     DefaultTenantID,
+		Name:                   "auratest",
+		InstanceConfigurations: DefaultInstanceConfigurations(),
+	})
+
+	s.srv = httptest.NewServer(s.handler())
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns an aura client for the server, authenticated with the
+// server's credentials. opts are applied after the defaults.
+func (s *Server) Client(opts ...aura.Option) (*aura.AuraAPIClient, error) {
+	base := []aura.Option{
+		aura.WithCredentials(s.opts.clientID, s.opts.clientSecret),
+		aura.WithInsecureBaseURL(s.URL),
+		aura.WithMaxRetry(1),
+	}
+	return aura.NewClient(append(base, opts...)...)
+}
+
+// DefaultInstanceConfigurations returns the configurations offered by the
+// default tenant. Create requests must match one of a tenant's
+// configurations, unless the tenant has none.
+func DefaultInstanceConfigurations() []aura.TenantInstanceConfiguration {
+	var configs []aura.TenantInstanceConfiguration
+	for _, p := range []struct{ provider, region, name string }{
+		{"gcp", "europe-west1", "Belgium (europe-west1)"},
+		{"aws", "us-east-1", "US East, N. Virginia (us-east-1)"},
+		{"azure", "westeurope", "West Europe (westeurope)"},
+	} {
+		for _, typ := range []string{"free-db", "professional-db", "enterprise-db"} {
+			for _, mem := range []string{"1GB", "2GB", "4GB", "8GB", "16GB"} {
+				if typ == "free-db" && mem != "1GB" {
+					continue
+				}
+				configs = append(configs, aura.TenantInstanceConfiguration{
+					CloudProvider: p.provider, Region: p.region, RegionName: p.name,
+					Type: typ, Memory: mem, Storage: storageFor(mem), Version: "5",
+				})
+			}
+		}
+	}
+	return configs
+}
+
+// storageFor returns the storage Aura pairs with an instance memory size.
+func storageFor(memory string) string {
+	var gb int
+	if _, err := fmt.Sscanf(memory, "%dGB", &gb); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%dGB", gb*2)
+}
+
+// ============================================================================
+// Seeding and inspection
+// ============================================================================
+
+// AddTenant adds or replaces a tenant.
+func (s *Server) AddTenant(t aura.TenantResponseData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[t.ID] = &t
+}
+
+// AddInstance adds or replaces an instance in its current status. Empty
+// ID, Status, ConnectionURL and MetricsURL fields are filled in.
+func (s *Server) AddInstance(inst aura.InstanceData) aura.InstanceData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inst.ID == "" {
+		inst.ID = s.newInstanceID()
+	}
+	if inst.Status == "" {
+		inst.Status = aura.StatusRunning
+	}
+	s.fillInstanceURLs(&inst)
+	s.instances[inst.ID] = &instance{data: inst, created: s.opts.now()}
+	return inst
+}
+
+// AddSnapshot adds or replaces a snapshot. Empty SnapshotID, Status and
+// Timestamp fields are filled in.
+func (s *Server) AddSnapshot(snap aura.GetSnapshotData) aura.GetSnapshotData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snap.SnapshotID == "" {
+		snap.SnapshotID = s.newUUID()
+	}
+	if snap.Status == "" {
+		snap.Status = SnapshotCompleted
+	}
+	if snap.Timestamp.IsZero() {
+		snap.Timestamp = s.opts.now().UTC()
+	}
+	s.snapshots[snap.SnapshotID] = &snapshot{data: snap}
+	return snap
+}
+
+// AddCMEK adds a customer-managed encryption key. An empty ID is filled in.
+func (s *Server) AddCMEK(key aura.GetCmeksData) aura.GetCmeksData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key.ID == "" {
+		key.ID = s.newUUID()
+	}
+	s.cmeks = append(s.cmeks, key)
+	return key
+}
+
+// AddGDSSession adds or replaces a GDS session. Empty ID, Status and
+// CreatedAt fields are filled in.
+func (s *Server) AddGDSSession(session aura.GetGDSSessionData) aura.GetGDSSessionData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session.ID == "" {
+		session.ID = s.newSessionID()
+	}
+	if session.Status == "" {
+		session.Status = GDSSessionReady
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = s.opts.now().UTC()
+	}
+	s.sessions[session.ID] = &gdsSession{data: session}
+	return session
+}
+
+// SetInstanceMetrics sets the Prometheus text served at the instance's
+// MetricsURL. Until it is set the endpoint returns 404.
+func (s *Server) SetInstanceMetrics(instanceID, exposition string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics["/metrics/instances/"+instanceID] = exposition
+}
+
+// SetTenantMetrics sets the Prometheus text served at the tenant's metrics
+// integration endpoint. Until it is set the endpoint returns 404.
+func (s *Server) SetTenantMetrics(tenantID, exposition string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics["/metrics/tenants/"+tenantID] = exposition
+}
+
+// Instance returns the current state of an instance, without reporting a
+// transitional status to the transition.
+func (s *Server) Instance(id string) (aura.InstanceData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settle()
+	inst, ok := s.instances[id]
+	if !ok {
+		return aura.InstanceData{}, false
+	}
+	return inst.data, true
+}
+
+// Snapshots returns the snapshots of an instance, newest first.
+func (s *Server) Snapshots(instanceID string) []aura.GetSnapshotData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settle()
+	return s.snapshotsOf(instanceID, "")
+}
+
+// GDSSession returns the current state of a GDS session.
+func (s *Server) GDSSession(id string) (aura.GetGDSSessionData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settle()
+	session, ok := s.sessions[id]
+	if !ok {
+		return aura.GetGDSSessionData{}, false
+	}
+	return session.data, true
+}
+
+// Settle completes every pending transition immediately.
+func (s *Server) Settle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, inst := range s.instances {
+		if inst.pending != nil {
+			inst.pending.reported, inst.pending.at = true, time.Time{}
+		}
+	}
+	for _, snap := range s.snapshots {
+		if snap.pending != nil {
+			snap.pending.reported, snap.pending.at = true, time.Time{}
+		}
+	}
+	for _, session := range s.sessions {
+		if session.pending != nil {
+			session.pending.reported, session.pending.at = true, time.Time{}
+		}
+	}
+	s.settle()
+}
+
+// Requests returns every request received so far, including token requests.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ============================================================================
+// State helpers (callers hold s.mu)
+// ============================================================================
+
+// settle completes every transition that is due.
+func (s *Server) settle() {
+	now := s.opts.now()
+	for id, inst := range s.instances {
+		if !inst.pending.due(now) {
+			continue
+		}
+		if inst.pending.remove {
+			delete(s.instances, id)
+			continue
+		}
+		inst.data.Status = aura.InstanceStatus(inst.pending.to)
+		inst.pending = nil
+	}
+	for _, snap := range s.snapshots {
+		if snap.pending.due(now) {
+			snap.data.Status = snap.pending.to
+			snap.pending = nil
+		}
+	}
+	for _, session := range s.sessions {
+		if session.pending.due(now) {
+			session.data.Status = session.pending.to
+			session.pending = nil
+		}
+	}
+}
+
+// startInstanceTransition moves inst to status now and to final later.
+func (s *Server) startInstanceTransition(inst *instance, status, final aura.InstanceStatus) {
+	inst.data.Status = status
+	inst.pending = &transition{to: string(final), at: s.opts.now().Add(s.opts.transitionDelay)}
+}
+
+// reportInstance returns the data of inst and marks its transitional status
+// as reported.
+func reportInstance(inst *instance) aura.InstanceData {
+	if inst.pending != nil {
+		inst.pending.reported = true
+	}
+	return inst.data
+}
+
+// snapshotsOf returns the snapshots of an instance taken on date
+// (YYYY-MM-DD, or empty for all), newest first.
+func (s *Server) snapshotsOf(instanceID, date string) []aura.GetSnapshotData {
+	out := []aura.GetSnapshotData{}
+	for _, snap := range s.snapshots {
+		if snap.data.InstanceID != instanceID {
+			continue
+		}
+		if date != "" && snap.data.Timestamp.UTC().Format(time.DateOnly) != date {
+			continue
+		}
+		out = append(out, snap.data)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Timestamp.Equal(out[j].Timestamp) {
+			return out[i].Timestamp.After(out[j].Timestamp)
+		}
+		return out[i].SnapshotID < out[j].SnapshotID
+	})
+	return out
+}
+
+// fillInstanceURLs sets the connection and metrics URLs of inst if empty.
+func (s *Server) fillInstanceURLs(inst *aura.InstanceData) {
+	if inst.ConnectionURL == "" {
+		inst.ConnectionURL = fmt.Sprintf("neo4j+s://%s.databases.neo4j.io", inst.ID)
+	}
+	if inst.MetricsURL == "" {
+		inst.MetricsURL = s.URL + "/metrics/instances/" + inst.ID
+	}
+}
+
+// next returns deterministic pseudo-random bytes, so that a fresh server
+// always generates the same IDs in the same order.
+func (s *Server) next() [32]byte {
+	s.seq++
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], s.seq)
+	return sha256.Sum256(append([]byte("auratest"), b[:]...))
+}
+
+func (s *Server) newInstanceID() string {
+	for {
+		h := s.next()
+		id := hex.EncodeToString(h[:4])
+		if _, taken := s.instances[id]; !taken {
+			return id
+		}
+	}
+}
+
+func (s *Server) newUUID() string {
+	h := s.next()
+	h[6] = h[6]&0x0f | 0x40 // version 4
+	h[8] = h[8]&0x3f | 0x80 // RFC 4122 variant
+	x := hex.EncodeToString(h[:16])
+	return strings.Join([]string{x[0:8], x[8:12], x[12:16], x[16:20], x[20:32]}, "-")
+}
+
+func (s *Server) newSessionID() string {
+	h := s.next()
+	return hex.EncodeToString(h[:4]) + "-" + hex.EncodeToString(h[4:6])
+}
+
+func (s *Server) newSecret() string {
+	h := s.next()
+	return hex.EncodeToString(h[:16])
+}
+
+// ============================================================================
+// HTTP plumbing
+// ============================================================================
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// handler routes requests, authenticating everything except /oauth/token,
+// and injects faults.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/token", s.handleToken)
+	api := http.NewServeMux()
+	s.routes(api)
+	mux.Handle("/", s.authenticate(api))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery}
+		if fault, ok := s.pickFault(r); ok {
+			req.Fault = fault.Kind
+			req.Status = s.serveFault(w, r, fault, mux)
+		} else {
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			mux.ServeHTTP(rec, r)
+			req.Status = rec.status
+		}
+		s.mu.Lock()
+		req.At = s.opts.now()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+	})
+}
+
+// authenticate rejects requests without a current bearer token.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		expiry, known := s.tokens[token]
+		valid := ok && known && s.opts.now().Before(expiry)
+		s.mu.Unlock()
+		if !valid {
+			writeError(w, http.StatusUnauthorized, "Invalid or expired token", "unauthorized", "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleToken implements the OAuth client credentials grant.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != s.opts.clientID || secret != s.opts.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	token := s.newSecret()
+	s.tokens[token] = s.opts.now().Add(s.opts.tokenTTL)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(s.opts.tokenTTL / time.Second),
+	})
+}
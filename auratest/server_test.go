@@ -0,0 +1,279 @@
+package auratest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auratest"
+	"github.com/LackOfMorals/aura-client/internal/api"
+)
+
+func newClient(t *testing.T, srv *auratest.Server, opts ...aura.Option) *aura.AuraAPIClient {
+	t.Helper()
+	client, err := srv.Client(opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+func statusOf(t *testing.T, client *aura.AuraAPIClient, id string) aura.InstanceStatus {
+	t.Helper()
+	resp, err := client.Instances.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return resp.Data.Status
+}
+
+func createInstance(t *testing.T, client *aura.AuraAPIClient) *aura.CreateInstanceResponse {
+	t.Helper()
+	created, err := client.Instances.Create(context.Background(), &aura.CreateInstanceConfigData{
+		Name: "test", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return created
+}
+
+func TestServer_InstanceLifecycle(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	created := createInstance(t, client)
+	if created.Data.Username != "neo4j" || created.Data.Password == "" || created.Data.ConnectionURL == "" {
+		t.Errorf("Unexpected create response %+v", created.Data)
+	}
+	id := created.Data.ID
+
+	steps := []struct {
+		action func() error
+		want   []aura.InstanceStatus
+	}{
+		{nil, []aura.InstanceStatus{aura.StatusCreating, aura.StatusRunning}},
+		{func() error { _, err := client.Instances.Pause(ctx, id); return err }, []aura.InstanceStatus{aura.StatusPaused}},
+		{func() error { _, err := client.Instances.Resume(ctx, id); return err }, []aura.InstanceStatus{aura.StatusRunning}},
+		{func() error {
+			_, err := client.Instances.Update(ctx, id, &aura.UpdateInstanceData{Memory: "16GB"})
+			return err
+		}, []aura.InstanceStatus{aura.StatusRunning}},
+	}
+	for i, step := range steps {
+		if step.action != nil {
+			if err := step.action(); err != nil {
+				t.Fatalf("step %d: expected no error, got %v", i, err)
+			}
+		}
+		for _, want := range step.want {
+			if got := statusOf(t, client, id); got != want {
+				t.Fatalf("step %d: expected %s, got %s", i, want, got)
+			}
+		}
+	}
+
+	list, err := client.Instances.List(ctx)
+	if err != nil || len(list.Data) != 1 || list.Data[0].ID != id {
+		t.Fatalf("Expected the instance to be listed, got %+v %v", list, err)
+	}
+
+	deleted, err := client.Instances.Delete(ctx, id)
+	if err != nil || deleted.Data.Status != aura.StatusDestroying {
+		t.Fatalf("Expected destroying, got %+v %v", deleted, err)
+	}
+	_, err = client.Instances.Get(ctx, id)
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		t.Errorf("Expected 404 after delete, got %v", err)
+	}
+}
+
+func TestServer_InvalidState(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	id := createInstance(t, client).Data.ID
+	// Still creating.
+	_, err := client.Instances.Pause(ctx, id)
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 while creating, got %v", err)
+	}
+
+	srv.Settle()
+	if _, err := client.Instances.Resume(ctx, id); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 resuming a running instance, got %v", err)
+	}
+
+	_, err = client.Instances.Create(ctx, &aura.CreateInstanceConfigData{
+		Name: "bad", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "free-db", Memory: "8GB",
+	})
+	if !errors.As(err, &apiErr) || !apiErr.IsBadRequest() {
+		t.Errorf("Expected 400 for an unoffered configuration, got %v", err)
+	}
+}
+
+func TestServer_TransitionDelay(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	srv := auratest.NewServer(auratest.WithTransitionDelay(time.Minute), auratest.WithClock(func() time.Time { return now }))
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	id := createInstance(t, client).Data.ID
+	for i := 0; i < 3; i++ {
+		if got := statusOf(t, client, id); got != aura.StatusCreating {
+			t.Fatalf("Expected creating before the delay, got %s", got)
+		}
+	}
+	now = now.Add(time.Minute)
+	if got := statusOf(t, client, id); got != aura.StatusRunning {
+		t.Errorf("Expected running after the delay, got %s", got)
+	}
+}
+
+func TestServer_Snapshots(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	id := createInstance(t, client).Data.ID
+	srv.Settle()
+
+	created, err := client.Snapshots.Create(ctx, id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sid := created.Data.SnapshotID
+
+	snap, err := client.Snapshots.Get(ctx, id, sid)
+	if err != nil || snap.Data.Status != auratest.SnapshotPending {
+		t.Fatalf("Expected pending snapshot, got %+v %v", snap, err)
+	}
+	list, err := client.Snapshots.List(ctx, id, nil)
+	if err != nil || len(list.Data) != 1 || list.Data[0].Status != auratest.SnapshotCompleted {
+		t.Fatalf("Expected one completed snapshot, got %+v %v", list, err)
+	}
+
+	restored, err := client.Snapshots.Restore(ctx, id, sid)
+	if err != nil || restored.Data.Status != aura.StatusRestoring {
+		t.Fatalf("Expected restoring, got %+v %v", restored, err)
+	}
+	if got := statusOf(t, client, id); got != aura.StatusRunning {
+		t.Errorf("Expected running after restore, got %s", got)
+	}
+
+	if _, err := client.Instances.OverwriteFromSnapshot(ctx, id, sid); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if inst, _ := srv.Instance(id); inst.Status != aura.StatusOverwriting {
+		t.Errorf("Expected overwriting, got %s", inst.Status)
+	}
+}
+
+func TestServer_TenantsAndKeys(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	key := srv.AddCMEK(aura.GetCmeksData{Name: "key", TenantID: auratest.DefaultTenantID})
+	srv.AddCMEK(aura.GetCmeksData{Name: "other", TenantID: "a1b2c3d4-0000-4000-8000-000000000002"})
+
+	tenants, err := client.Tenants.List(ctx)
+	if err != nil || len(tenants.Data) != 1 || tenants.Data[0].ID != auratest.DefaultTenantID {
+		t.Fatalf("Expected the default tenant, got %+v %v", tenants, err)
+	}
+	tenant, err := client.Tenants.Get(ctx, auratest.DefaultTenantID)
+	if err != nil || len(tenant.Data.InstanceConfigurations) == 0 {
+		t.Fatalf("Expected instance configurations, got %+v %v", tenant, err)
+	}
+	keys, err := client.Cmek.List(ctx, auratest.DefaultTenantID)
+	if err != nil || len(keys.Data) != 1 || keys.Data[0].ID != key.ID {
+		t.Fatalf("Expected one key for the tenant, got %+v %v", keys, err)
+	}
+
+	srv.SetTenantMetrics(auratest.DefaultTenantID, "neo4j_aura_cpu_usage{instance_id=\"abcd1234\"} 0.5\n")
+	endpoint, err := client.Tenants.GetMetrics(ctx, auratest.DefaultTenantID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	metrics, err := client.Prometheus.FetchRawMetrics(ctx, endpoint.Data.Endpoint)
+	if err != nil || len(metrics.Metrics["neo4j_aura_cpu_usage"]) != 1 {
+		t.Errorf("Expected tenant metrics, got %+v %v", metrics, err)
+	}
+}
+
+func TestServer_GDSSessions(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	srv := auratest.NewServer(auratest.WithClock(func() time.Time { return now }))
+	defer srv.Close()
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	estimate, err := client.GraphAnalytics.Estimate(ctx, &aura.GetGDSSessionSizeEstimation{
+		NodeCount: 10_000_000, RelationshipCount: 50_000_000, AlgorithmCategories: []string{"centrality"},
+	})
+	if err != nil || estimate.Data.RecommendedSize != "4GB" {
+		t.Fatalf("Expected a 4GB recommendation, got %+v %v", estimate, err)
+	}
+
+	inst := srv.AddInstance(aura.InstanceData{Name: "source", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp", Region: "europe-west1"})
+	created, err := client.GraphAnalytics.Create(ctx, &aura.CreateGDSSessionConfigData{
+		Name: "analysis", TTL: "PT1H", TenantID: auratest.DefaultTenantID, InstanceID: inst.ID, Memory: "4GB",
+	})
+	if err != nil || created.Data.Status != auratest.GDSSessionCreating || created.Data.Region != "europe-west1" {
+		t.Fatalf("Expected creating session, got %+v %v", created, err)
+	}
+	session, err := client.GraphAnalytics.Get(ctx, created.Data.ID)
+	if err != nil || session.Data.Status != auratest.GDSSessionReady || !session.Data.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Expected ready session expiring in an hour, got %+v %v", session, err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := client.GraphAnalytics.Get(ctx, created.Data.ID); err == nil {
+		t.Error("Expected the session to have expired")
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	srv := auratest.NewServer(auratest.WithCredentials("id", "secret"))
+	defer srv.Close()
+	ctx := context.Background()
+
+	bad, err := aura.NewClient(aura.WithCredentials("id", "wrong"), aura.WithInsecureBaseURL(srv.URL), aura.WithMaxRetry(1))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := bad.Tenants.List(ctx); err == nil {
+		t.Error("Expected wrong credentials to be rejected")
+	}
+
+	if _, err := newClient(t, srv).Tenants.List(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	reqs := srv.Requests()
+	last := reqs[len(reqs)-1]
+	if last.Method != http.MethodGet || last.Path != "/v1/tenants" || last.Status != http.StatusOK {
+		t.Errorf("Unexpected last request %+v", last)
+	}
+
+	resp, err := http.Get(srv.URL + "/v1/tenants")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", resp.StatusCode)
+	}
+}
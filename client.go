@@ -18,6 +18,7 @@ package aura
 import (
 	"errors"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -60,11 +61,13 @@ type AuraAPIClient struct {
 
 // config holds internal configuration (unexported).
 type config struct {
-	baseURL      string        // the base URL of the Aura API
-	apiTimeout   time.Duration // how long to wait for a response from an Aura API endpoint
-	apiRetryMax  int           // the number of retries to attempt
-	clientID     string        // client ID used to obtain an OAuth token
-	clientSecret string        // client secret used to obtain an OAuth token
+	baseURL      string            // the base URL of the Aura API
+	apiTimeout   time.Duration     // how long to wait for a response from an Aura API endpoint
+	apiRetryMax  int               // the number of retries to attempt
+	clientID     string            // client ID used to obtain an OAuth token
+	clientSecret string            // client secret used to obtain an OAuth token
+	transport    http.RoundTripper // optional HTTP transport replacing the default
+	watch        watchConfig       // polling intervals used by Watch
 }
 
 // Option is a functional option for configuring the AuraAPIClient.
@@ -166,6 +169,19 @@ func WithInsecureBaseURL(baseURL string) Option {
 	}
 }
 
+// WithTransport sets the http.RoundTripper used for every request, including
+// token requests. Use it to record or replay traffic, or to add
+// instrumentation. Defaults to a pooled http.Transport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) error {
+		if transport == nil {
+			return errors.New("transport cannot be nil")
+		}
+		o.config.transport = transport
+		return nil
+	}
+}
+
 // NewClient creates a new Aura API client with functional options.
 func NewClient(opts ...Option) (*AuraAPIClient, error) {
 	o := defaultOptions()
@@ -208,6 +224,7 @@ func NewClient(opts ...Option) (*AuraAPIClient, error) {
 		Timeout:      o.config.apiTimeout,
 		MaxRetry:     o.config.apiRetryMax,
 		UserAgent:    "aura-go-client/" + AuraAPIClientVersion,
+		Transport:    o.config.transport,
 	}, o.logger)
 
 	clientLogger := o.logger.With(slog.String("component", "AuraAPIClient"))
@@ -226,6 +243,7 @@ func NewClient(opts ...Option) (*AuraAPIClient, error) {
 		api:     apiSvc,
 		timeout: o.config.apiTimeout,
 		logger:  clientLogger.With(slog.String("service", "instanceService")),
+		watch:   o.config.watch,
 	}
 	service.Snapshots = &snapshotService{
 		api:     apiSvc,
@@ -241,6 +259,7 @@ func NewClient(opts ...Option) (*AuraAPIClient, error) {
 		api:     apiSvc,
 		timeout: o.config.apiTimeout,
 		logger:  clientLogger.With(slog.String("service", "gDSSessionService")),
+		watch:   o.config.watch,
 	}
 	service.Prometheus = &prometheusService{
 		api:     apiSvc,
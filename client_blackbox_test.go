@@ -117,6 +117,12 @@ func (m *mockInstanceService) OverwriteFromSnapshot(_ context.Context, id, srcSn
 	return m.OverwriteResp, m.OverwriteErr
 }
 
+func (m *mockInstanceService) Watch(_ context.Context, _ ...string) (<-chan aura.InstanceEvent, error) {
+	m.LastMethod = "Watch"
+	m.CallCount++
+	return nil, nil
+}
+
 // --- Tenants -----------------------------------------------------------------
 
 type mockTenantService struct {
@@ -227,6 +233,9 @@ type mockGDSSessionService struct {
 	DeleteResp   *aura.DeleteGDSSessionResponse
 	DeleteErr    error
 
+	CreateSizedResp *aura.CreateSizedGDSSessionResponse
+	CreateSizedErr  error
+
 	LastMethod    string
 	LastSessionID string
 	CallCount     int
@@ -259,6 +268,16 @@ func (m *mockGDSSessionService) Delete(_ context.Context, id string) (*aura.Dele
 	m.CallCount++
 	return m.DeleteResp, m.DeleteErr
 }
+func (m *mockGDSSessionService) CreateSized(_ context.Context, _ *aura.GetGDSSessionSizeEstimation, _ *aura.CreateGDSSessionConfigData, _ ...aura.GDSSessionSizingOption) (*aura.CreateSizedGDSSessionResponse, error) {
+	m.LastMethod = "CreateSized"
+	m.CallCount++
+	return m.CreateSizedResp, m.CreateSizedErr
+}
+func (m *mockGDSSessionService) Watch(_ context.Context, _ ...string) (<-chan aura.GDSSessionEvent, error) {
+	m.LastMethod = "Watch"
+	m.CallCount++
+	return nil, nil
+}
 
 // --- Prometheus --------------------------------------------------------------
 
@@ -269,6 +288,8 @@ type mockPrometheusService struct {
 	GetValErr  error
 	HealthResp *aura.PrometheusHealthMetrics
 	HealthErr  error
+	QueryResp  []aura.MetricSample
+	QueryErr   error
 
 	LastMethod     string
 	LastInstanceID string
@@ -276,7 +297,7 @@ type mockPrometheusService struct {
 	CallCount      int
 }
 
-func (m *mockPrometheusService) FetchRawMetrics(_ context.Context, _ string) (*aura.PrometheusMetricsResponse, error) {
+func (m *mockPrometheusService) FetchRawMetrics(_ context.Context, _ string, _ ...aura.FetchOption) (*aura.PrometheusMetricsResponse, error) {
 	m.LastMethod = "FetchRawMetrics"
 	m.CallCount++
 	return m.FetchResp, m.FetchErr
@@ -287,7 +308,18 @@ func (m *mockPrometheusService) GetMetricValue(_ context.Context, _ *aura.Promet
 	m.CallCount++
 	return m.GetValResp, m.GetValErr
 }
-func (m *mockPrometheusService) GetInstanceHealth(_ context.Context, instanceID, _ string) (*aura.PrometheusHealthMetrics, error) {
+func (m *mockPrometheusService) Query(_ context.Context, _ *aura.PrometheusMetricsResponse, _ string) ([]aura.MetricSample, error) {
+	m.LastMethod = "Query"
+	m.CallCount++
+	return m.QueryResp, m.QueryErr
+}
+func (m *mockPrometheusService) InstanceHealthFromMetrics(_ context.Context, instanceID string, _ *aura.PrometheusMetricsResponse, _ ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
+	m.LastMethod = "InstanceHealthFromMetrics"
+	m.LastInstanceID = instanceID
+	m.CallCount++
+	return m.HealthResp, m.HealthErr
+}
+func (m *mockPrometheusService) GetInstanceHealth(_ context.Context, instanceID, _ string, _ ...aura.HealthOption) (*aura.PrometheusHealthMetrics, error) {
 	m.LastMethod = "GetInstanceHealth"
 	m.LastInstanceID = instanceID
 	m.CallCount++
@@ -1577,3 +1609,10 @@ func (m *mockCancelAwareInstanceService) OverwriteFromSnapshot(ctx context.Conte
 	}
 	return &aura.OverwriteInstanceResponse{}, nil
 }
+
+func (m *mockCancelAwareInstanceService) Watch(ctx context.Context, _ ...string) (<-chan aura.InstanceEvent, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, nil
+}
@@ -187,6 +187,21 @@ func TestWithLogger_Nil(t *testing.T) {
 	}
 }
 
+// TestWithTransport_Nil validates error for nil transport
+func TestWithTransport_Nil(t *testing.T) {
+	client, err := NewClient(
+		WithCredentials("test-id", "test-secret"),
+		WithTransport(nil),
+	)
+
+	if err == nil || err.Error() != "transport cannot be nil" {
+		t.Errorf("expected transport error, got %v", err)
+	}
+	if client != nil {
+		t.Error("expected client to be nil")
+	}
+}
+
 // TestWithBaseURL_Valid verifies custom base URL configuration
 func TestWithBaseURL_Valid(t *testing.T) {
 	client, err := NewClient(
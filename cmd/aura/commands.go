@@ -0,0 +1,714 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auraformat"
+	utils "github.com/LackOfMorals/aura-client/internal/utils"
+)
+
+// commands lists every command in help order.
+var commands = []command{
+	// Tenants
+	{group: "tenants", name: "list", summary: "List tenants",
+		run: func(ctx context.Context, c *cli, _ any, _ []string) (any, error) {
+			return data(c.client.Tenants.List(ctx))
+		}},
+	{group: "tenants", name: "get", args: "<tenant-id>", summary: "Show a tenant and its instance configurations",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(utils.ValidateTenantID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Tenants.Get(ctx, args[0]))
+		}},
+
+	// Instances
+	{group: "instances", name: "list", summary: "List instances",
+		run: func(ctx context.Context, c *cli, _ any, _ []string) (any, error) {
+			return data(c.client.Instances.List(ctx))
+		}},
+	{group: "instances", name: "get", args: "<instance-id>", summary: "Show an instance",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Instances.Get(ctx, args[0]))
+		}},
+	{group: "instances", name: "create", summary: "Create an instance; --show-secrets prints its one-time password",
+		setup: func(fs *flag.FlagSet) any {
+			f := &aura.CreateInstanceConfigData{}
+			fs.StringVar(&f.Name, "name", "", "instance name (required)")
+			fs.StringVar(&f.TenantID, "tenant", "", "tenant ID (defaults to the global --tenant)")
+			fs.StringVar(&f.CloudProvider, "cloud", "", "cloud provider: gcp, aws or azure (required)")
+			fs.StringVar(&f.Region, "region", "", "region, e.g. europe-west1 (required)")
+			fs.StringVar(&f.Type, "type", "", "instance type, e.g. enterprise-db (required)")
+			fs.StringVar(&f.Memory, "memory", "", "memory, e.g. 8GB (required)")
+			fs.StringVar(&f.Version, "version", "", "Neo4j version")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, _ []string) (any, error) {
+			req := flags.(*aura.CreateInstanceConfigData)
+			req.TenantID = firstNonEmpty(req.TenantID, c.settings.TenantID)
+			if err := required(map[string]string{"name": req.Name, "tenant": req.TenantID, "cloud": req.CloudProvider,
+				"region": req.Region, "type": req.Type, "memory": req.Memory}); err != nil {
+				return nil, err
+			}
+			created, err := data(c.client.Instances.Create(ctx, req))
+			if err == nil && !c.output.ShowSecrets {
+				fmt.Fprintln(c.stderr, "aura: the instance password was redacted and cannot be retrieved again; use --show-secrets to print it")
+			}
+			return created, err
+		}},
+	{group: "instances", name: "delete", args: "<instance-id>", summary: "Delete an instance",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.Bool("yes", false, "confirm the deletion (required)")
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			if !*flags.(*bool) {
+				return nil, &usageError{"instances delete: refusing to delete " + args[0] + " without --yes"}
+			}
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Instances.Delete(ctx, args[0]))
+		}},
+	{group: "instances", name: "pause", args: "<instance-id>", summary: "Pause a running instance",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Instances.Pause(ctx, args[0]))
+		}},
+	{group: "instances", name: "resume", args: "<instance-id>", summary: "Resume a paused instance",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Instances.Resume(ctx, args[0]))
+		}},
+	{group: "instances", name: "update", args: "<instance-id>", summary: "Rename or resize an instance",
+		setup: func(fs *flag.FlagSet) any {
+			f := &aura.UpdateInstanceData{}
+			fs.StringVar(&f.Name, "name", "", "new name")
+			fs.StringVar(&f.Memory, "memory", "", "new memory, e.g. 16GB")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			req := flags.(*aura.UpdateInstanceData)
+			if req.Name == "" && req.Memory == "" {
+				return nil, &usageError{"instances update: at least one of --name or --memory is required"}
+			}
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Instances.Update(ctx, args[0], req))
+		}},
+	{group: "instances", name: "overwrite", args: "<instance-id>", summary: "Overwrite an instance from another instance or a snapshot",
+		setup: func(fs *flag.FlagSet) any {
+			f := &overwriteFlags{}
+			fs.StringVar(&f.instance, "from-instance", "", "source instance ID")
+			fs.StringVar(&f.snapshot, "from-snapshot", "", "source snapshot ID")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			f := flags.(*overwriteFlags)
+			if (f.instance == "") == (f.snapshot == "") {
+				return nil, &usageError{"instances overwrite: exactly one of --from-instance or --from-snapshot is required"}
+			}
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			if f.instance != "" {
+				return data(c.client.Instances.OverwriteFromInstance(ctx, args[0], f.instance))
+			}
+			return data(c.client.Instances.OverwriteFromSnapshot(ctx, args[0], f.snapshot))
+		}},
+
+	{group: "instances", name: "watch", args: "[<instance-id>...]", summary: "Print instance changes as they happen, for all instances if none are given",
+		setup: func(fs *flag.FlagSet) any {
+			f := &watchFlags{}
+			f.register(fs, "exit once every given instance has this status, e.g. running")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			f := flags.(*watchFlags)
+			until := f.until
+			if until != "" && len(args) == 0 {
+				return nil, &usageError{"instances watch: --until requires instance IDs"}
+			}
+			if err := invalid(validateAll(utils.ValidateInstanceID, args)); err != nil {
+				return nil, err
+			}
+			client, err := f.client(c)
+			if err != nil {
+				return nil, err
+			}
+			events, err := client.Instances.Watch(ctx, args...)
+			if err != nil {
+				return nil, err
+			}
+			return nil, stream(ctx, c, events, args, until, func(e aura.InstanceEvent) watchEvent {
+				return watchEvent{id: e.InstanceID, after: e.After, typ: e.Type, err: e.Err}
+			})
+		}},
+
+	// Snapshots
+	{group: "snapshots", name: "list", args: "<instance-id>", summary: "List snapshots of an instance",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.String("date", "", "only snapshots taken on this day, YYYY-MM-DD")
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			var date *aura.SnapshotDate
+			if day := *flags.(*string); day != "" {
+				t, err := time.Parse(time.DateOnly, day)
+				if err != nil {
+					return nil, &usageError{fmt.Sprintf("snapshots list: --date %q must be YYYY-MM-DD", day)}
+				}
+				date = &aura.SnapshotDate{Year: t.Year(), Month: t.Month(), Day: t.Day()}
+			}
+			return data(c.client.Snapshots.List(ctx, args[0], date))
+		}},
+	{group: "snapshots", name: "create", args: "<instance-id>", summary: "Take an on-demand snapshot",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.Snapshots.Create(ctx, args[0]))
+		}},
+	{group: "snapshots", name: "get", args: "<instance-id> <snapshot-id>", summary: "Show a snapshot",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(errors.Join(utils.ValidateInstanceID(args[0]), utils.ValidateSnapshotID(args[1]))); err != nil {
+				return nil, err
+			}
+			return data(c.client.Snapshots.Get(ctx, args[0], args[1]))
+		}},
+	{group: "snapshots", name: "restore", args: "<instance-id> <snapshot-id>", summary: "Restore an instance from a snapshot",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(errors.Join(utils.ValidateInstanceID(args[0]), utils.ValidateSnapshotID(args[1]))); err != nil {
+				return nil, err
+			}
+			return data(c.client.Snapshots.Restore(ctx, args[0], args[1]))
+		}},
+
+	// Customer-managed keys
+	{group: "cmek", name: "list", summary: "List customer-managed encryption keys",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.String("tenant", "", "only keys of this tenant (defaults to the global --tenant)")
+		},
+		run: func(ctx context.Context, c *cli, flags any, _ []string) (any, error) {
+			return data(c.client.Cmek.List(ctx, firstNonEmpty(*flags.(*string), c.settings.TenantID)))
+		}},
+
+	// Graph Data Science sessions
+	{group: "gds", name: "list", summary: "List GDS sessions",
+		run: func(ctx context.Context, c *cli, _ any, _ []string) (any, error) {
+			return data(c.client.GraphAnalytics.List(ctx))
+		}},
+	{group: "gds", name: "create", summary: "Create a GDS session",
+		setup: func(fs *flag.FlagSet) any {
+			f := &aura.CreateGDSSessionConfigData{}
+			fs.StringVar(&f.Name, "name", "", "session name (required)")
+			fs.StringVar(&f.Memory, "memory", "", "memory, e.g. 8GB (required)")
+			fs.StringVar(&f.TTL, "ttl", "", "time to live, e.g. 2h or PT2H")
+			fs.StringVar(&f.TenantID, "tenant", "", "tenant ID (defaults to the global --tenant)")
+			fs.StringVar(&f.InstanceID, "instance-id", "", "attach to this Aura instance")
+			fs.StringVar(&f.DatabaseID, "database-id", "", "attach to this self-managed database")
+			fs.StringVar(&f.CloudProvider, "cloud", "", "cloud provider, for self-managed databases")
+			fs.StringVar(&f.Region, "region", "", "region, for self-managed databases")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, _ []string) (any, error) {
+			req := flags.(*aura.CreateGDSSessionConfigData)
+			req.TenantID = firstNonEmpty(req.TenantID, c.settings.TenantID)
+			if err := required(map[string]string{"name": req.Name, "memory": req.Memory}); err != nil {
+				return nil, err
+			}
+			return data(c.client.GraphAnalytics.Create(ctx, req))
+		}},
+	{group: "gds", name: "estimate", summary: "Estimate the memory a GDS session needs",
+		setup: func(fs *flag.FlagSet) any {
+			f := &estimateFlags{}
+			fs.IntVar(&f.NodeCount, "nodes", 0, "number of nodes (required)")
+			fs.IntVar(&f.RelationshipCount, "relationships", 0, "number of relationships (required)")
+			fs.IntVar(&f.NodeLabelCount, "node-labels", 0, "number of node labels")
+			fs.IntVar(&f.NodePropertyCount, "node-properties", 0, "number of node properties")
+			fs.IntVar(&f.RelationshipPropertyCount, "relationship-properties", 0, "number of relationship properties")
+			fs.StringVar(&f.algorithms, "algorithms", "", "comma-separated algorithm categories, e.g. centrality,community-detection")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, _ []string) (any, error) {
+			f := flags.(*estimateFlags)
+			var missing []string
+			if f.NodeCount <= 0 {
+				missing = append(missing, "--nodes")
+			}
+			if f.RelationshipCount <= 0 {
+				missing = append(missing, "--relationships")
+			}
+			if len(missing) > 0 {
+				return nil, &usageError{"gds estimate: missing required flags: " + strings.Join(missing, ", ")}
+			}
+			req := f.GetGDSSessionSizeEstimation
+			req.AlgorithmCategories = splitList(f.algorithms)
+			return data(c.client.GraphAnalytics.Estimate(ctx, &req))
+		}},
+	{group: "gds", name: "delete", args: "<session-id>", summary: "Delete a GDS session",
+		run: func(ctx context.Context, c *cli, _ any, args []string) (any, error) {
+			if err := invalid(utils.ValidateGDSSessionID(args[0])); err != nil {
+				return nil, err
+			}
+			return data(c.client.GraphAnalytics.Delete(ctx, args[0]))
+		}},
+	{group: "gds", name: "watch", args: "[<session-id>...]", summary: "Print GDS session changes as they happen, for all sessions if none are given",
+		setup: func(fs *flag.FlagSet) any {
+			f := &watchFlags{}
+			f.register(fs, "exit once every given session has this status, e.g. Ready")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			f := flags.(*watchFlags)
+			until := f.until
+			if until != "" && len(args) == 0 {
+				return nil, &usageError{"gds watch: --until requires session IDs"}
+			}
+			if err := invalid(validateAll(utils.ValidateGDSSessionID, args)); err != nil {
+				return nil, err
+			}
+			client, err := f.client(c)
+			if err != nil {
+				return nil, err
+			}
+			events, err := client.GraphAnalytics.Watch(ctx, args...)
+			if err != nil {
+				return nil, err
+			}
+			return nil, stream(ctx, c, events, args, until, func(e aura.GDSSessionEvent) watchEvent {
+				return watchEvent{id: e.SessionID, after: e.After, typ: e.Type, err: e.Err}
+			})
+		}},
+
+	// Manifests
+	{group: "manifest", name: "plan", args: "<file>", summary: "Show the changes that would bring the instances in line with a manifest",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.Bool("prune", false, "plan deletion of unlisted instances in the manifest's tenants, as if the manifest set prune")
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			plan, err := planManifest(ctx, c, args[0], *flags.(*bool))
+			if err != nil {
+				return nil, err
+			}
+			if !plan.HasChanges() {
+				fmt.Fprintln(c.stderr, "aura: no changes; the instances match the manifest")
+			}
+			return rowsOrReport(c.output.Format, plan, plan.Steps), nil
+		}},
+	{group: "manifest", name: "apply", args: "<file>", summary: "Create, update and delete instances to match a manifest",
+		setup: func(fs *flag.FlagSet) any {
+			f := &applyFlags{}
+			fs.BoolVar(&f.yes, "yes", false, "confirm the changes (required)")
+			fs.BoolVar(&f.prune, "prune", false, "delete unlisted instances in the manifest's tenants, as if the manifest set prune")
+			fs.IntVar(&f.concurrency, "concurrency", 4, "number of changes applied in parallel")
+			f.watch.registerIntervals(fs)
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			f := flags.(*applyFlags)
+			if !f.yes {
+				return nil, &usageError{"manifest apply: refusing to change instances without --yes; run manifest plan to review the changes"}
+			}
+			client, err := f.watch.client(c)
+			if err != nil {
+				return nil, err
+			}
+			c.client = client
+			plan, err := planManifest(ctx, c, args[0], f.prune)
+			if err != nil {
+				return nil, err
+			}
+			if !plan.HasChanges() {
+				fmt.Fprintln(c.stderr, "aura: no changes; the instances match the manifest")
+				return nil, nil
+			}
+			report, err := c.client.ApplyPlan(ctx, plan, aura.ApplyOptions{Concurrency: f.concurrency})
+			if err != nil {
+				return nil, &usageError{err.Error()}
+			}
+			if err := auraformat.Write(c.stdout, rowsOrReport(c.output.Format, report, report.Results), c.output); err != nil {
+				return nil, &usageError{err.Error()}
+			}
+			printCredentials(c, report)
+			if report.Failed > 0 {
+				return nil, fmt.Errorf("%d of %d changes failed", report.Failed, len(report.Results))
+			}
+			return nil, nil
+		}},
+
+	// Drift
+	{group: "drift", name: "capture", args: "<file>", summary: "Save the configuration of every instance as a baseline for drift check",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.String("tenant", "", "capture only instances of this tenant (defaults to the global --tenant)")
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			tenantID := firstNonEmpty(*flags.(*string), c.settings.TenantID)
+			baseline, err := c.client.CaptureBaseline(ctx, aura.BaselineOptions{TenantID: tenantID})
+			if err != nil {
+				return nil, err
+			}
+			if err := baseline.Save(args[0]); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(c.stderr, "aura: saved %d instances to %s\n", len(baseline.Instances), args[0])
+			return nil, nil
+		}},
+	{group: "drift", name: "check", args: "<file>", summary: "Report instances changed since a baseline; exits 11 on drift",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.String("fields", strings.Join(aura.DefaultDriftFields, ","),
+				"comma-separated fields to compare, from "+strings.Join(aura.DriftFields(), ", "))
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			baseline, err := aura.LoadBaseline(args[0])
+			if err != nil {
+				return nil, err
+			}
+			report, err := c.client.DetectDrift(ctx, baseline, aura.BaselineOptions{Fields: splitList(*flags.(*string))})
+			if err != nil {
+				return nil, err
+			}
+			if err := auraformat.Write(c.stdout, rowsOrReport(c.output.Format, report, report.Drifts), c.output); err != nil {
+				return nil, &usageError{err.Error()}
+			}
+			if report.HasDrift() {
+				return nil, &driftError{fmt.Sprintf("%d of %d instances drifted from the baseline of %s",
+					report.Drifted, report.Checked, report.BaselineAt.Format(time.RFC3339))}
+			}
+			return nil, nil
+		}},
+
+	// Inventory
+	{group: "inventory", name: "export", args: "<file>", summary: "Export every tenant's resources as JSON, CSV or Markdown; - writes to stdout",
+		setup: func(fs *flag.FlagSet) any {
+			f := &inventoryFlags{}
+			fs.StringVar(&f.format, "format", "", "json, csv or markdown (defaults to the file extension, or markdown)")
+			fs.StringVar(&f.tenants, "tenants", "", "comma-separated tenant IDs to include (default all)")
+			fs.IntVar(&f.concurrency, "concurrency", 4, "number of API calls made in parallel")
+			fs.IntVar(&f.lookback, "snapshot-days", 7, "days searched back for each instance's latest snapshot")
+			return f
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			f := flags.(*inventoryFlags)
+			format := strings.ToLower(firstNonEmpty(f.format, strings.TrimPrefix(filepath.Ext(args[0]), "."), "markdown"))
+			switch format {
+			case "json", "csv", "markdown", "md":
+			default:
+				return nil, &usageError{fmt.Sprintf("inventory export: unknown format %q; use --format json, csv or markdown", format)}
+			}
+			tenants := splitList(f.tenants)
+			if err := invalid(validateAll(utils.ValidateTenantID, tenants)); err != nil {
+				return nil, err
+			}
+			inv, err := c.client.Inventory(ctx, aura.InventoryOptions{
+				TenantIDs: tenants, Concurrency: f.concurrency, SnapshotLookbackDays: f.lookback,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			var buf bytes.Buffer
+			if err := aura.WriteInventory(&buf, inv, format); err != nil {
+				return nil, err
+			}
+			if args[0] == "-" {
+				_, err = c.stdout.Write(buf.Bytes())
+			} else {
+				err = os.WriteFile(args[0], buf.Bytes(), 0o644)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range inv.Errors {
+				fmt.Fprintf(c.stderr, "aura: %s %s: %s\n", e.Resource, e.ID, e.Error)
+			}
+			if len(inv.Errors) > 0 {
+				return nil, fmt.Errorf("the inventory is incomplete: %d calls failed", len(inv.Errors))
+			}
+			return nil, nil
+		}},
+
+	// Metrics
+	{group: "metrics", name: "health", args: "<instance-id>", summary: "Assess the health of an instance from its metrics",
+		setup: func(fs *flag.FlagSet) any {
+			return fs.String("url", "", "metrics URL (defaults to the instance's metrics integration URL)")
+		},
+		run: func(ctx context.Context, c *cli, flags any, args []string) (any, error) {
+			if err := invalid(utils.ValidateInstanceID(args[0])); err != nil {
+				return nil, err
+			}
+			url := *flags.(*string)
+			if url == "" {
+				inst, err := c.client.Instances.Get(ctx, args[0])
+				if err != nil {
+					return nil, err
+				}
+				if url = inst.Data.MetricsURL; url == "" {
+					return nil, fmt.Errorf("instance %s has no metrics URL; pass --url", args[0])
+				}
+			}
+			return c.client.Prometheus.GetInstanceHealth(ctx, args[0], url)
+		}},
+}
+
+type overwriteFlags struct {
+	instance, snapshot string
+}
+
+// overwriteJob is printed for an overwrite, which returns only a job ID.
+type overwriteJob struct {
+	JobID string `json:"job_id"`
+}
+
+type estimateFlags struct {
+	aura.GetGDSSessionSizeEstimation
+	algorithms string
+}
+
+type inventoryFlags struct {
+	format, tenants       string
+	concurrency, lookback int
+}
+
+type applyFlags struct {
+	yes, prune  bool
+	concurrency int
+	watch       watchFlags
+}
+
+// planManifest loads a manifest and plans it, printing the plan's warnings.
+func planManifest(ctx context.Context, c *cli, path string, prune bool) (*aura.Plan, error) {
+	manifest, err := aura.LoadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Prune = manifest.Prune || prune
+	plan, err := c.client.PlanManifest(ctx, manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintln(c.stderr, "aura: warning: "+w)
+	}
+	return plan, nil
+}
+
+// rowsOrReport returns rows for tables and CSV, which show one line per
+// element, and the whole report for the structured formats.
+func rowsOrReport(format auraformat.Format, report, rows any) any {
+	if format == auraformat.FormatTable || format == auraformat.FormatCSV {
+		return rows
+	}
+	return report
+}
+
+// printCredentials prints the connection details of created instances to
+// stderr, so that structured output on stdout stays parseable. Passwords are
+// only printed with --show-secrets.
+func printCredentials(c *cli, report *aura.ApplyReport) {
+	for _, r := range report.Results {
+		if r.Credentials == nil {
+			continue
+		}
+		if !c.output.ShowSecrets {
+			fmt.Fprintf(c.stderr, "aura: the password of new instance %s (%s) was redacted and cannot be retrieved again; use --show-secrets to print it\n", r.Credentials.ID, r.Credentials.Name)
+			continue
+		}
+		fmt.Fprintf(c.stderr, "aura: new instance %s (%s): %s username %s password %s\n",
+			r.Credentials.ID, r.Credentials.Name, r.Credentials.ConnectionURL, r.Credentials.Username, r.Credentials.Password)
+	}
+}
+
+// data returns the Data field of a response, so commands print the resource
+// itself rather than the API envelope.
+func data[T any](resp *T, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch r := any(resp).(type) {
+	case *aura.ListTenantsResponse:
+		return r.Data, nil
+	case *aura.GetTenantResponse:
+		return r.Data, nil
+	case *aura.ListInstancesResponse:
+		return r.Data, nil
+	case *aura.GetInstanceResponse:
+		return r.Data, nil
+	case *aura.CreateInstanceResponse:
+		return r.Data, nil
+	case *aura.DeleteInstanceResponse:
+		return r.Data, nil
+	case *aura.OverwriteInstanceResponse:
+		return overwriteJob{JobID: r.Data}, nil
+	case *aura.GetSnapshotsResponse:
+		return r.Data, nil
+	case *aura.CreateSnapshotResponse:
+		return r.Data, nil
+	case *aura.GetSnapshotDataResponse:
+		return r.Data, nil
+	case *aura.RestoreSnapshotResponse:
+		return r.Data, nil
+	case *aura.GetCmeksResponse:
+		return r.Data, nil
+	case *aura.GetGDSSessionListResponse:
+		return r.Data, nil
+	case *aura.GetGDSSessionResponse:
+		return r.Data, nil
+	case *aura.GDSSessionSizeEstimationResponse:
+		return r.Data, nil
+	case *aura.DeleteGDSSessionResponse:
+		return r.Data, nil
+	default:
+		return resp, nil
+	}
+}
+
+// invalid wraps a validation error, or returns nil.
+func invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &invalidArgError{err}
+}
+
+// watchFlags are the flags of the watch commands.
+type watchFlags struct {
+	until                  string
+	interval, transitional time.Duration
+}
+
+func (f *watchFlags) register(fs *flag.FlagSet, untilUsage string) {
+	fs.StringVar(&f.until, "until", "", untilUsage)
+	f.registerIntervals(fs)
+}
+
+// registerIntervals registers the polling flags alone, for commands that wait
+// without streaming.
+func (f *watchFlags) registerIntervals(fs *flag.FlagSet) {
+	fs.DurationVar(&f.interval, "interval", 30*time.Second, "time between polls of a resource in a steady state")
+	fs.DurationVar(&f.transitional, "transitional-interval", 5*time.Second, "time between polls of a resource in a transitional state")
+}
+
+// client returns a client polling at the requested intervals.
+func (f *watchFlags) client(c *cli) (*aura.AuraAPIClient, error) {
+	client, err := c.newClient(aura.WithWatchIntervals(f.interval, f.transitional))
+	if err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	return client, nil
+}
+
+// watchEvent is the part of an instance or GDS session event that stream
+// needs.
+type watchEvent struct {
+	id, after string
+	typ       aura.WatchEventType
+	err       error
+}
+
+// stream prints watch events as they arrive, until the channel closes or,
+// when until is set, every ID in ids has reached that status. Poll errors are
+// printed and do not stop the watch. If the channel closes, because ctx was
+// cancelled, before every ID has reached until, stream returns an error so
+// that the command does not exit as if it had.
+func stream[E any](ctx context.Context, c *cli, events <-chan E, ids []string, until string, describe func(E) watchEvent) error {
+	waiting := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		waiting[id] = true
+	}
+	opts := c.output
+	for e := range events {
+		if err := auraformat.Write(c.stdout, e, opts); err != nil {
+			return &usageError{err.Error()}
+		}
+		opts.NoHeaders = true
+
+		w := describe(e)
+		if w.err != nil {
+			fmt.Fprintf(c.stderr, "aura: %s: %v\n", w.id, w.err)
+		}
+		if until == "" {
+			continue
+		}
+		switch w.typ {
+		case aura.WatchEventDeleted:
+			return fmt.Errorf("%s was deleted before reaching %s", w.id, until)
+		case aura.WatchEventObserved, aura.WatchEventStatus:
+			if strings.EqualFold(w.after, until) {
+				delete(waiting, w.id)
+			} else {
+				waiting[w.id] = true
+			}
+		}
+		if len(waiting) == 0 {
+			return nil
+		}
+	}
+	if until == "" {
+		return nil
+	}
+	pending := make([]string, 0, len(waiting))
+	for id := range waiting {
+		pending = append(pending, id)
+	}
+	sort.Strings(pending)
+	err := ctx.Err()
+	if err == nil {
+		err = errors.New("watch ended")
+	}
+	return fmt.Errorf("%s did not reach %s: %w", strings.Join(pending, ", "), until, err)
+}
+
+// validateAll runs validate on every ID and joins the errors.
+func validateAll(validate func(string) error, ids []string) error {
+	var errs []error
+	for _, id := range ids {
+		errs = append(errs, validate(id))
+	}
+	return errors.Join(errs...)
+}
+
+// required returns a usage error naming every flag that is empty.
+func required(flags map[string]string) error {
+	var missing []string
+	for name, value := range flags {
+		if value == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return &usageError{"missing required flags: " + strings.Join(missing, ", ")}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by the CLI. They override the profile file and
+// are overridden by flags.
+const (
+	envClientID     = "AURA_CLIENT_ID"
+	envClientSecret = "AURA_CLIENT_SECRET"
+	envBaseURL      = "AURA_BASE_URL"
+	envTenantID     = "AURA_TENANT_ID"
+	envTimeout      = "AURA_TIMEOUT"
+	envProfile      = "AURA_PROFILE"
+	envConfig       = "AURA_CONFIG"
+)
+
+// configFile is the profile file, by default $XDG_CONFIG_HOME/aura/config.yaml:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    client_id: ...
+//	    client_secret: ...
+//	    tenant_id: ...
+//	  staging:
+//	    client_id: ...
+//	    client_secret: ...
+//	    base_url: https://api.staging.neo4j.io
+type configFile struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]profile `yaml:"profiles"`
+}
+
+// profile holds the settings of one named profile. Every field is optional.
+type profile struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	BaseURL      string `yaml:"base_url"`
+	TenantID     string `yaml:"tenant_id"`
+	Timeout      string `yaml:"timeout"`
+}
+
+// settings is the resolved configuration for one run.
+type settings struct {
+	Profile      string
+	ClientID     string
+	ClientSecret string
+	BaseURL      string
+	TenantID     string
+	Timeout      time.Duration
+	Insecure     bool
+}
+
+// defaultConfigPath returns the profile file used when neither --config nor
+// AURA_CONFIG is set.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aura", "config.yaml")
+}
+
+// loadConfigFile reads the profile file at path. A missing file is not an
+// error unless explicit is set.
+func loadConfigFile(path string, explicit bool) (*configFile, error) {
+	cfg := &configFile{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// resolveSettings merges, in increasing precedence, the selected profile, the
+// environment and the global flags.
+func resolveSettings(g *globalFlags, getenv func(string) string) (*settings, error) {
+	path, explicit := g.config, g.config != ""
+	if !explicit {
+		path = getenv(envConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	file, err := loadConfigFile(path, explicit)
+	if err != nil {
+		return nil, err
+	}
+
+	name := firstNonEmpty(g.profile, getenv(envProfile), file.DefaultProfile)
+	var p profile
+	if name != "" {
+		var ok bool
+		if p, ok = file.Profiles[name]; !ok {
+			return nil, fmt.Errorf("profile %q not found in %s", name, path)
+		}
+	}
+
+	s := &settings{
+		Profile:      name,
+		ClientID:     firstNonEmpty(getenv(envClientID), p.ClientID),
+		ClientSecret: firstNonEmpty(getenv(envClientSecret), p.ClientSecret),
+		BaseURL:      firstNonEmpty(g.baseURL, getenv(envBaseURL), p.BaseURL),
+		TenantID:     firstNonEmpty(g.tenantID, getenv(envTenantID), p.TenantID),
+		Insecure:     g.insecure,
+	}
+	if timeout := firstNonEmpty(g.timeout, getenv(envTimeout), p.Timeout); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("timeout %q must be a positive duration such as 30s", timeout)
+		}
+		s.Timeout = d
+	}
+	if s.ClientID == "" || s.ClientSecret == "" {
+		return nil, fmt.Errorf("missing credentials: set %s and %s, or client_id and client_secret in a profile", envClientID, envClientSecret)
+	}
+	return s, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	aura "github.com/LackOfMorals/aura-client"
+)
+
+// Exit codes. They are part of the CLI's interface, so scripts can branch on
+// the kind of failure; do not renumber them.
+const (
+	exitOK          = 0  // success
+	exitError       = 1  // any failure not listed below, such as a network error
+	exitUsage       = 2  // unknown command, bad flags or missing arguments
+	exitConfig      = 3  // missing credentials or an unreadable profile file
+	exitAuth        = 4  // the API rejected the credentials (401 or 403)
+	exitNotFound    = 5  // the resource does not exist (404)
+	exitInvalid     = 6  // the request was rejected as invalid, locally or with a 400
+	exitConflict    = 7  // the resource is in the wrong state for the operation (409)
+	exitRateLimited = 8  // too many requests (429)
+	exitServer      = 9  // the API failed (5xx)
+	exitTimeout     = 10 // the command timed out or was interrupted
+	exitDrift       = 11 // drift check found instances changed since the baseline
+)
+
+// usageError reports a problem with the command line itself.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// configError reports a problem resolving the configuration.
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string { return e.err.Error() }
+func (e *configError) Unwrap() error { return e.err }
+
+// invalidArgError reports a malformed argument, such as an instance ID of the
+// wrong length.
+type invalidArgError struct {
+	err error
+}
+
+func (e *invalidArgError) Error() string { return e.err.Error() }
+func (e *invalidArgError) Unwrap() error { return e.err }
+
+// driftError reports that drift check found drift. The command itself
+// succeeded, so it has an exit code of its own rather than exitError.
+type driftError struct {
+	msg string
+}
+
+func (e *driftError) Error() string { return e.msg }
+
+// exitCode maps an error returned by a command to the process exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var usage *usageError
+	var drift *driftError
+	var config *configError
+	var invalidArg *invalidArgError
+	var validation aura.ValidationErrors
+	var apiErr *aura.Error
+	switch {
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &drift):
+		return exitDrift
+	case errors.As(err, &config):
+		return exitConfig
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return exitTimeout
+	case errors.As(err, &invalidArg), errors.As(err, &validation):
+		return exitInvalid
+	case errors.As(err, &apiErr):
+		return apiExitCode(apiErr.StatusCode)
+	default:
+		return exitError
+	}
+}
+
+// apiExitCode maps an API status code to an exit code.
+func apiExitCode(status int) int {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return exitAuth
+	case status == http.StatusNotFound:
+		return exitNotFound
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return exitInvalid
+	case status == http.StatusConflict:
+		return exitConflict
+	case status == http.StatusTooManyRequests:
+		return exitRateLimited
+	case status >= 500:
+		return exitServer
+	default:
+		return exitError
+	}
+}
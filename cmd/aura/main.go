@@ -0,0 +1,286 @@
+// Command aura manages Neo4j Aura resources from the command line.
+//
+// Usage:
+//
+//	aura [global flags] <group> <command> [flags] [arguments]
+//
+// Run "aura help" for the list of commands, and "aura <group> <command> -h"
+// for the flags of one command. Credentials come from the environment
+// (AURA_CLIENT_ID, AURA_CLIENT_SECRET) or, failing that, a profile in
+// $XDG_CONFIG_HOME/aura/config.yaml; there are no credential flags, so
+// secrets stay out of the process list. The base URL, tenant and timeout may
+// also be set by flags, which take precedence over both. The exit status
+// identifies the kind of failure; see "aura help".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auraformat"
+)
+
+// globalFlags are accepted before the command group.
+type globalFlags struct {
+	profile  string
+	config   string
+	baseURL  string
+	tenantID string
+	timeout  string
+	insecure bool
+	verbose  bool
+}
+
+// cli carries what a command needs to run.
+type cli struct {
+	stdout   io.Writer
+	stderr   io.Writer
+	getenv   func(string) string
+	global   globalFlags
+	settings *settings
+	client   *aura.AuraAPIClient
+	output   auraformat.Options
+}
+
+// command is one "aura <group> <name>" command.
+type command struct {
+	group, name string
+	args        string // positional arguments, for help output
+	summary     string
+	// setup registers the command's flags. It may be nil.
+	setup func(fs *flag.FlagSet) any
+	// run executes the command with its parsed flags and positional
+	// arguments, returning the value to print.
+	run func(ctx context.Context, c *cli, flags any, args []string) (any, error)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the CLI and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	c := &cli{stdout: stdout, stderr: stderr, getenv: getenv}
+	err := c.run(ctx, args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(stderr, "aura: %v\n", err)
+		var usage *usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintln(stderr, `Run "aura help" for usage.`)
+		}
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitCode(err)
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("aura", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.global.profile, "profile", "", "profile to use from the config file (or "+envProfile+")")
+	fs.StringVar(&c.global.config, "config", "", "path of the config file (or "+envConfig+")")
+	fs.StringVar(&c.global.baseURL, "base-url", "", "Aura API base URL (or "+envBaseURL+")")
+	fs.StringVar(&c.global.tenantID, "tenant", "", "default tenant ID (or "+envTenantID+")")
+	fs.StringVar(&c.global.timeout, "timeout", "", "timeout for each API call, e.g. 30s (or "+envTimeout+")")
+	fs.BoolVar(&c.global.insecure, "insecure", false, "allow a plain http:// base URL, for local testing only")
+	fs.BoolVar(&c.global.verbose, "v", false, "log API calls to stderr")
+	fs.Usage = func() { c.usage() }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{err.Error()}
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 || rest[0] == "help" {
+		c.usage()
+		if len(rest) == 0 {
+			return &usageError{"no command given"}
+		}
+		return nil
+	}
+	if len(rest) < 2 {
+		return &usageError{fmt.Sprintf("%s: missing command", rest[0])}
+	}
+	cmd := findCommand(rest[0], rest[1])
+	if cmd == nil {
+		return &usageError{fmt.Sprintf("unknown command %q", rest[0]+" "+rest[1])}
+	}
+
+	var out outputFlags
+	flags, positional, err := parseCommandArgs(cmd, c.stderr, rest[2:], &out)
+	if err != nil {
+		return err
+	}
+	if c.output, err = out.options(); err != nil {
+		return err
+	}
+
+	if c.settings, err = resolveSettings(&c.global, c.getenv); err != nil {
+		return &configError{err}
+	}
+	if c.client, err = c.newClient(); err != nil {
+		return &configError{err}
+	}
+	result, err := cmd.run(ctx, c, flags, positional)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := auraformat.Write(c.stdout, result, c.output); err != nil {
+		return &usageError{err.Error()}
+	}
+	return nil
+}
+
+// newClient builds an API client from the resolved settings and any extra
+// options.
+func (c *cli) newClient(extra ...aura.Option) (*aura.AuraAPIClient, error) {
+	level := slog.LevelError + 1 // the CLI reports errors itself
+	if c.global.verbose {
+		level = slog.LevelDebug
+	}
+	opts := []aura.Option{
+		aura.WithCredentials(c.settings.ClientID, c.settings.ClientSecret),
+		aura.WithLogger(slog.New(slog.NewTextHandler(c.stderr, &slog.HandlerOptions{Level: level}))),
+	}
+	if c.settings.Timeout > 0 {
+		opts = append(opts, aura.WithTimeout(c.settings.Timeout))
+	}
+	if c.settings.BaseURL != "" {
+		if c.settings.Insecure {
+			opts = append(opts, aura.WithInsecureBaseURL(c.settings.BaseURL))
+		} else {
+			opts = append(opts, aura.WithBaseURL(c.settings.BaseURL))
+		}
+	}
+	return aura.NewClient(append(opts, extra...)...)
+}
+
+// parseCommandArgs parses the command's flags and the output flags, which may
+// be interleaved with its positional arguments, and checks the number of
+// positional arguments.
+func parseCommandArgs(cmd *command, stderr io.Writer, args []string, out *outputFlags) (any, []string, error) {
+	fs := flag.NewFlagSet("aura "+cmd.group+" "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: aura %s %s [flags] %s\n\n%s\n", cmd.group, cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	var flags any
+	if cmd.setup != nil {
+		flags = cmd.setup(fs)
+	}
+	out.register(fs)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, nil, err
+			}
+			return nil, nil, &usageError{err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	// A trailing "[<name>...]" accepts any number of further arguments.
+	want := strings.Fields(cmd.args)
+	variadic := len(want) > 0 && strings.HasSuffix(want[len(want)-1], "...]")
+	if variadic {
+		want = want[:len(want)-1]
+	}
+	if len(positional) < len(want) || (!variadic && len(positional) > len(want)) {
+		return nil, nil, &usageError{fmt.Sprintf("%s %s: expected %d argument(s) %s, got %d", cmd.group, cmd.name, len(want), cmd.args, len(positional))}
+	}
+	return flags, positional, nil
+}
+
+// findCommand returns the command registered as group name, or nil.
+func findCommand(group, name string) *command {
+	for i := range commands {
+		if commands[i].group == group && commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// commandLine returns the group, name and arguments of cmd as shown in usage.
+func commandLine(cmd command) string {
+	return strings.TrimSpace(cmd.group + " " + cmd.name + " " + cmd.args)
+}
+
+// usage prints the command list and exit codes.
+func (c *cli) usage() {
+	w := c.stderr
+	fmt.Fprint(w, "Usage: aura [global flags] <group> <command> [flags] [arguments]\n\nCommands:\n")
+	groups := map[string][]command{}
+	var order []string
+	for _, cmd := range commands {
+		if _, ok := groups[cmd.group]; !ok {
+			order = append(order, cmd.group)
+		}
+		groups[cmd.group] = append(groups[cmd.group], cmd)
+	}
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(commandLine(cmd)))
+	}
+	for _, g := range order {
+		for _, cmd := range groups[g] {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, commandLine(cmd), cmd.summary)
+		}
+	}
+	fmt.Fprint(w, `
+Global flags:
+  --profile, --config, --base-url, --tenant, --timeout, --insecure, -v
+
+Output flags, accepted by every command:
+  -o table|json|yaml|csv|template, --columns, --template, --no-headers,
+  --show-secrets
+
+Credentials are read from AURA_CLIENT_ID and AURA_CLIENT_SECRET, or from the
+selected profile in the config file; the environment overrides the profile.
+--base-url, --tenant and --timeout override AURA_BASE_URL, AURA_TENANT_ID and
+AURA_TIMEOUT, which override the profile.
+
+Exit codes:
+`)
+	codes := map[int]string{
+		exitOK: "success", exitError: "other error", exitUsage: "usage error", exitConfig: "configuration error",
+		exitAuth: "authentication failed (401/403)", exitNotFound: "not found (404)", exitInvalid: "invalid request (400)",
+		exitConflict: "conflicting state (409)", exitRateLimited: "rate limited (429)", exitServer: "server error (5xx)",
+		exitTimeout: "timed out or interrupted", exitDrift: "drift check found drift",
+	}
+	keys := make([]int, 0, len(codes))
+	for k := range codes {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %2d  %s\n", k, codes[k])
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auraformat"
+	"github.com/LackOfMorals/aura-client/auratest"
+)
+
+// env returns a getenv that reads from m only.
+func env(t *testing.T, m map[string]string) func(string) string {
+	t.Helper()
+	return func(key string) string { return m[key] }
+}
+
+// credentials returns the environment for the fake server's credentials.
+func credentials() map[string]string {
+	return map[string]string{envClientID: auratest.ClientID, envClientSecret: auratest.ClientSecret}
+}
+
+// runCLI runs the CLI against srv and returns its exit code and output.
+func runCLI(t *testing.T, srv *auratest.Server, vars map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	return runCLIContext(t, context.Background(), srv, vars, args...)
+}
+
+// runCLIContext is runCLI with a context, for commands that run until it is
+// cancelled.
+func runCLIContext(t *testing.T, ctx context.Context, srv *auratest.Server, vars map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if srv != nil {
+		// An empty config file keeps the developer's own profiles out of the test.
+		config := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(config, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		args = append([]string{"--insecure", "--base-url", srv.URL, "--config", config}, args...)
+	}
+	code := run(ctx, args, &stdout, &stderr, env(t, vars))
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_InstancesGet(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	inst := srv.AddInstance(aura.InstanceData{Name: "prod", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+
+	code, stdout, stderr := runCLI(t, srv, credentials(), "instances", "get", inst.ID, "-o", "json")
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	var got aura.InstanceData
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", stdout, err)
+	}
+	if got.ID != inst.ID || got.Name != "prod" || got.Status != aura.StatusRunning {
+		t.Errorf("Unexpected instance %+v", got)
+	}
+}
+
+func TestRun_InstancesCreateUsesTenantFromEnv(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	vars := credentials()
+	vars[envTenantID] = auratest.DefaultTenantID
+
+	create := []string{"instances", "create",
+		"--name", "cli", "--cloud", "gcp", "--region", "europe-west1", "--type", "enterprise-db", "--memory", "8GB"}
+
+	code, stdout, stderr := runCLI(t, srv, vars, create...)
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, auraformat.Redacted) || !strings.Contains(stderr, "--show-secrets") {
+		t.Errorf("Expected the password to be redacted with a hint, got %q and %q", stdout, stderr)
+	}
+
+	code, stdout, stderr = runCLI(t, srv, vars, append(create, "-o", "json", "--show-secrets")...)
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	var got aura.CreateInstanceData
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", stdout, err)
+	}
+	if got.Password == "" || got.Password == auraformat.Redacted || got.TenantID != auratest.DefaultTenantID {
+		t.Errorf("Unexpected create response %+v", got)
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	running := srv.AddInstance(aura.InstanceData{Name: "running", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		args []string
+		want int
+	}{
+		{"not found", credentials(), []string{"instances", "get", "0000ffff"}, exitNotFound},
+		{"conflict", credentials(), []string{"instances", "resume", running.ID}, exitConflict},
+		{"invalid id", credentials(), []string{"instances", "get", "not-an-id"}, exitInvalid},
+		{"unknown command", credentials(), []string{"instances", "explode"}, exitUsage},
+		{"missing argument", credentials(), []string{"instances", "get"}, exitUsage},
+		{"unknown flag", credentials(), []string{"instances", "list", "--bogus"}, exitUsage},
+		{"delete needs --yes", credentials(), []string{"instances", "delete", running.ID}, exitUsage},
+		{"missing required flags", credentials(), []string{"instances", "create", "--name", "x"}, exitUsage},
+		{"missing credentials", map[string]string{}, []string{"instances", "list"}, exitConfig},
+		{"bad timeout", map[string]string{envClientID: "a", envClientSecret: "b", envTimeout: "soon"}, []string{"instances", "list"}, exitConfig},
+		{"wrong credentials", map[string]string{envClientID: "a", envClientSecret: "b"}, []string{"instances", "list"}, exitAuth},
+		{"unknown format", credentials(), []string{"instances", "list", "-o", "xml"}, exitUsage},
+		{"template without text", credentials(), []string{"instances", "list", "-o", "template"}, exitUsage},
+		{"unknown column", credentials(), []string{"instances", "get", running.ID, "--columns", "colour"}, exitUsage},
+		{"command help", credentials(), []string{"instances", "get", "-h"}, exitOK},
+		{"gds create needs name and memory", credentials(), []string{"gds", "create", "--ttl", "2h"}, exitUsage},
+		{"invalid session id", credentials(), []string{"gds", "delete", "not/a-session"}, exitInvalid},
+		{"estimate needs relationships", credentials(), []string{"gds", "estimate", "--nodes", "1000"}, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCLI(t, srv, tt.vars, tt.args...)
+			if code != tt.want {
+				t.Errorf("Expected exit %d, got %d: %s", tt.want, code, stderr)
+			}
+		})
+	}
+}
+
+func TestRun_HelpAlignsSummaries(t *testing.T) {
+	code, _, stderr := runCLI(t, nil, credentials(), "help")
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	column := -1
+	for _, cmd := range commands {
+		line := "  " + commandLine(cmd) + " "
+		i := strings.Index(stderr, line)
+		if i < 0 {
+			t.Fatalf("Expected %q in the help, got:\n%s", commandLine(cmd), stderr)
+		}
+		row := stderr[i:]
+		row = row[:strings.IndexByte(row, '\n')]
+		at := strings.Index(row, cmd.summary)
+		if column == -1 {
+			column = at
+		}
+		if at != column || row[at-2:at] != "  " {
+			t.Errorf("Expected the summary of %q at column %d, got %q", commandLine(cmd), column, row)
+		}
+	}
+	if !strings.Contains(stderr, fmt.Sprintf("%2d  drift check found drift", exitDrift)) {
+		t.Errorf("Expected the drift exit code in the help, got:\n%s", stderr)
+	}
+}
+
+func TestRun_OutputFormats(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	inst := srv.AddInstance(aura.InstanceData{Name: "prod", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--columns", "id,name"}, "ID         NAME\n" + inst.ID + "   prod\n"},
+		{[]string{"-o", "csv", "--columns", "name,memory", "--no-headers"}, "prod,8GB\n"},
+		{[]string{"--template", "{{.Name}}/{{.Status}}"}, "prod/running"},
+		{[]string{"--output", "yaml"}, "id: " + inst.ID + "\nname: prod\nstatus: running\n"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, srv, credentials(), append([]string{"instances", "get", inst.ID}, tt.args...)...)
+			if code != exitOK {
+				t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+			}
+			if !strings.HasPrefix(stdout, tt.want) {
+				t.Errorf("Expected output starting %q, got %q", tt.want, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_InstancesWatchUntil(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	created, err := client.Instances.Create(context.Background(), &aura.CreateInstanceConfigData{
+		Name: "watched", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	id := created.Data.ID
+
+	code, stdout, stderr := runCLI(t, srv, credentials(), "instances", "watch", id, "--until", "running",
+		"--interval", "50ms", "--transitional-interval", "5ms", "-o", "csv", "--columns", "instance_id,type,before,after")
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	want := "instance_id,type,before,after\n" +
+		id + ",observed,,creating\n" +
+		id + ",status,creating,running\n"
+	if stdout != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, stdout)
+	}
+
+	if code, _, _ := runCLI(t, srv, credentials(), "instances", "watch", "--until", "running"); code != exitUsage {
+		t.Errorf("Expected exit %d for --until without IDs, got %d", exitUsage, code)
+	}
+
+	// Interrupted before the paused instance resumes: not a success.
+	paused := srv.AddInstance(aura.InstanceData{Name: "paused", TenantID: auratest.DefaultTenantID, Status: aura.StatusPaused})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	code, _, stderr = runCLIContext(t, ctx, srv, credentials(), "instances", "watch", paused.ID, "--until", "running", "--interval", "20ms", "--transitional-interval", "5ms")
+	if code != exitTimeout || !strings.Contains(stderr, paused.ID+" did not reach running") {
+		t.Errorf("Expected exit %d naming the waiting instance, got %d: %s", exitTimeout, code, stderr)
+	}
+}
+
+func TestRun_ManifestPlanAndApply(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	srv.AddInstance(aura.InstanceData{Name: "orders", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB"})
+	manifest := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifest, []byte(`
+defaults:
+  tenant_id: `+auratest.DefaultTenantID+`
+  cloud_provider: gcp
+  region: europe-west1
+  type: enterprise-db
+instances:
+  - name: orders
+    memory: 16GB
+  - name: search
+    memory: 4GB
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI(t, srv, credentials(), "manifest", "plan", manifest, "-o", "csv", "--columns", "action,name,changes")
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	want := "action,name,changes\n" +
+		"create,search,\"cloud_provider: gcp, region: europe-west1, type: enterprise-db, memory: 4GB\"\n" +
+		"update,orders,memory: 8GB -> 16GB\n"
+	if stdout != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, stdout)
+	}
+
+	if code, _, _ := runCLI(t, srv, credentials(), "manifest", "apply", manifest); code != exitUsage {
+		t.Errorf("Expected exit %d without --yes, got %d", exitUsage, code)
+	}
+	code, stdout, stderr = runCLI(t, srv, credentials(), "manifest", "apply", manifest, "--yes",
+		"--interval", "50ms", "--transitional-interval", "5ms", "-o", "json")
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	var report aura.ApplyReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || report.Applied != 2 {
+		t.Errorf("Expected a JSON report with two applied changes, got %q, %v", stdout, err)
+	}
+	if !strings.Contains(stderr, "password of new instance") {
+		t.Errorf("Expected a note about the redacted password, got %q", stderr)
+	}
+
+	code, _, stderr = runCLI(t, srv, credentials(), "manifest", "plan", manifest)
+	if code != exitOK || !strings.Contains(stderr, "no changes") {
+		t.Errorf("Expected no changes after applying, got exit %d: %s", code, stderr)
+	}
+}
+
+func TestRun_DriftCaptureAndCheck(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	inst := srv.AddInstance(aura.InstanceData{Name: "orders", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	if code, _, stderr := runCLI(t, srv, credentials(), "drift", "capture", baseline); code != exitOK || !strings.Contains(stderr, "saved 1 instances") {
+		t.Fatalf("Expected the baseline to be saved, got exit %d: %s", code, stderr)
+	}
+	if code, stdout, stderr := runCLI(t, srv, credentials(), "drift", "check", baseline, "-o", "csv"); code != exitOK || stdout != "instance_id,name,kind,field,expected,actual\n" {
+		t.Errorf("Expected no drift, got exit %d: %q %s", code, stdout, stderr)
+	}
+
+	inst.Memory, inst.VectorOptimized = "16GB", true
+	srv.AddInstance(inst)
+	code, stdout, stderr := runCLI(t, srv, credentials(), "drift", "check", baseline, "-o", "csv", "--no-headers")
+	if code != exitDrift || !strings.Contains(stderr, "1 of 1 instances drifted") {
+		t.Errorf("Expected exit %d reporting the drift, got %d: %s", exitDrift, code, stderr)
+	}
+	want := inst.ID + ",orders,changed,memory,8GB,16GB\n" +
+		inst.ID + ",orders,changed,vector_optimized,false,true\n"
+	if stdout != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, stdout)
+	}
+
+	if code, _, _ := runCLI(t, srv, credentials(), "drift", "check", baseline, "--fields", "colour"); code != exitInvalid {
+		t.Errorf("Expected exit %d for an unknown field, got %d", exitInvalid, code)
+	}
+}
+
+func TestRun_InventoryExport(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	inst := srv.AddInstance(aura.InstanceData{Name: "orders", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "inventory.csv")
+	if code, _, stderr := runCLI(t, srv, credentials(), "inventory", "export", path); code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "type,id,name,") || !strings.Contains(string(data), "instance,"+inst.ID+",orders,") {
+		t.Errorf("Expected CSV chosen by the file extension, got\n%s", data)
+	}
+
+	code, stdout, _ := runCLI(t, srv, credentials(), "inventory", "export", "-", "--format", "json")
+	var inv aura.Inventory
+	if code != exitOK || json.Unmarshal([]byte(stdout), &inv) != nil || len(inv.Instances) != 1 {
+		t.Errorf("Expected JSON on stdout, got exit %d: %q", code, stdout)
+	}
+
+	srv.InjectFault(auratest.FaultRule{Path: "/v1/customer-managed-keys", Fault: auratest.Status(http.StatusForbidden)})
+	code, stdout, stderr := runCLI(t, srv, credentials(), "inventory", "export", "-")
+	if code != exitError || !strings.Contains(stdout, "## Errors") || !strings.Contains(stderr, "incomplete") {
+		t.Errorf("Expected a Markdown report and exit %d for a partial failure, got %d: %s", exitError, code, stderr)
+	}
+
+	if code, _, _ := runCLI(t, srv, credentials(), "inventory", "export", filepath.Join(dir, "inventory.xlsx")); code != exitUsage {
+		t.Errorf("Expected exit %d for an unknown format, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, stdout, stderr := runCLI(t, nil, nil, "help")
+	if code != exitOK || stdout != "" {
+		t.Errorf("Expected exit 0 and no stdout, got %d and %q", code, stdout)
+	}
+	for _, want := range []string{"instances overwrite <instance-id>", "metrics health", "Exit codes:"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected usage to mention %q", want)
+		}
+	}
+
+	if code, _, _ := runCLI(t, nil, nil); code != exitUsage {
+		t.Errorf("Expected exit %d without a command, got %d", exitUsage, code)
+	}
+}
+
+func TestResolveSettings_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `default_profile: prod
+profiles:
+  prod:
+    client_id: prod-id
+    client_secret: prod-secret
+    tenant_id: prod-tenant
+    timeout: 45s
+  staging:
+    client_id: staging-id
+    client_secret: staging-secret
+    base_url: https://api.staging.example
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := resolveSettings(&globalFlags{config: path}, env(t, nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Profile != "prod" || s.ClientID != "prod-id" || s.TenantID != "prod-tenant" || s.Timeout.String() != "45s" {
+		t.Errorf("Expected the default profile, got %+v", s)
+	}
+
+	s, err = resolveSettings(&globalFlags{config: path, tenantID: "flag-tenant"},
+		env(t, map[string]string{envProfile: "staging", envClientID: "env-id"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Profile != "staging" || s.ClientID != "env-id" || s.ClientSecret != "staging-secret" ||
+		s.BaseURL != "https://api.staging.example" || s.TenantID != "flag-tenant" {
+		t.Errorf("Expected flags > env > profile, got %+v", s)
+	}
+
+	if _, err := resolveSettings(&globalFlags{config: path, profile: "missing"}, env(t, nil)); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+	if _, err := resolveSettings(&globalFlags{config: filepath.Join(t.TempDir(), "missing.yaml")}, env(t, nil)); err == nil {
+		t.Error("Expected an error for a missing explicit config file")
+	}
+}
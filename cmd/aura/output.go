@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/LackOfMorals/aura-client/auraformat"
+)
+
+// outputFlags are accepted by every command and select how its result is
+// printed.
+type outputFlags struct {
+	format      string
+	columns     string
+	template    string
+	noHeaders   bool
+	showSecrets bool
+}
+
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "o", "", "output format: table, json, yaml, csv or template (default table)")
+	fs.StringVar(&o.format, "output", "", "same as -o")
+	fs.StringVar(&o.columns, "columns", "", "comma-separated columns for table and csv output")
+	fs.StringVar(&o.template, "template", "", "Go template for template output; implies -o template")
+	fs.BoolVar(&o.noHeaders, "no-headers", false, "omit the header row of table and csv output")
+	fs.BoolVar(&o.showSecrets, "show-secrets", false, "print secrets such as a new instance's password instead of redacting them")
+}
+
+// options validates the flags and returns the auraformat options.
+func (o *outputFlags) options() (auraformat.Options, error) {
+	opts := auraformat.Options{
+		Format:      auraformat.FormatTable,
+		Columns:     splitList(o.columns),
+		Template:    o.template,
+		NoHeaders:   o.noHeaders,
+		ShowSecrets: o.showSecrets,
+	}
+	switch {
+	case o.format != "":
+		format, err := auraformat.ParseFormat(o.format)
+		if err != nil {
+			return opts, &usageError{err.Error()}
+		}
+		opts.Format = format
+	case o.template != "":
+		opts.Format = auraformat.FormatTemplate
+	}
+	if opts.Format == auraformat.FormatTemplate && opts.Template == "" {
+		return opts, &usageError{"-o template requires --template"}
+	}
+	if len(opts.Columns) > 0 && opts.Format != auraformat.FormatTable && opts.Format != auraformat.FormatCSV {
+		return opts, &usageError{fmt.Sprintf("--columns applies only to table and csv output, not %s", opts.Format)}
+	}
+	return opts, nil
+}
@@ -0,0 +1,354 @@
+package aura
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utils "github.com/LackOfMorals/aura-client/internal/utils"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// defaultBaselineConcurrency is the number of instances fetched in parallel
+// when BaselineOptions.Concurrency is not set.
+const defaultBaselineConcurrency = 4
+
+// BaselineOptions controls CaptureBaseline and DetectDrift.
+type BaselineOptions struct {
+	// TenantID limits the baseline to instances of one tenant. Empty means all.
+	TenantID string
+	// Concurrency limits the number of instances fetched in parallel. Defaults to 4.
+	Concurrency int
+	// Fields selects the fields DetectDrift compares. Defaults to
+	// DefaultDriftFields.
+	Fields []string
+}
+
+// Baseline is the recorded configuration of every instance at one point in
+// time, sorted by instance ID.
+type Baseline struct {
+	CapturedAt time.Time      `json:"captured_at"`
+	TenantID   string         `json:"tenant_id,omitempty"`
+	Instances  []InstanceData `json:"instances"`
+}
+
+// DriftKind is how an instance differs from its baseline.
+type DriftKind string
+
+// Kinds of drift.
+const (
+	// DriftChanged reports a field whose value differs from the baseline.
+	DriftChanged DriftKind = "changed"
+	// DriftAdded reports an instance that is not in the baseline.
+	DriftAdded DriftKind = "added"
+	// DriftRemoved reports a baseline instance that no longer exists.
+	DriftRemoved DriftKind = "removed"
+)
+
+// Drift is one difference from the baseline. Field, Expected and Actual are
+// set for DriftChanged only.
+type Drift struct {
+	InstanceID string    `json:"instance_id"`
+	Name       string    `json:"name"`
+	TenantID   string    `json:"tenant_id"`
+	Kind       DriftKind `json:"kind"`
+	Field      string    `json:"field,omitempty"`
+	Expected   string    `json:"expected,omitempty"`
+	Actual     string    `json:"actual,omitempty"`
+}
+
+// DriftReport lists every difference between a baseline and the current
+// instances, sorted by instance name and ID. The drifts of one instance keep
+// the order of BaselineOptions.Fields.
+type DriftReport struct {
+	BaselineAt time.Time `json:"baseline_at"`
+	CheckedAt  time.Time `json:"checked_at"`
+	// Checked is the number of instances in the baseline or the current state.
+	Checked int `json:"checked"`
+	// Drifted is the number of instances with at least one drift.
+	Drifted int     `json:"drifted"`
+	Drifts  []Drift `json:"drifts"`
+}
+
+// HasDrift reports whether any instance differs from the baseline.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+// driftField reads one comparable field of an instance.
+type driftField struct {
+	value func(InstanceData) string
+	equal func(a, b string) bool
+}
+
+// driftFields holds every field DetectDrift can compare, by JSON name.
+var driftFields = map[string]driftField{
+	"name":                   {value: func(d InstanceData) string { return d.Name }},
+	"status":                 {value: func(d InstanceData) string { return string(d.Status) }},
+	"cloud_provider":         {value: func(d InstanceData) string { return d.CloudProvider }},
+	"region":                 {value: func(d InstanceData) string { return d.Region }},
+	"type":                   {value: func(d InstanceData) string { return d.Type }},
+	"memory":                 {value: func(d InstanceData) string { return d.Memory }, equal: sameMemory},
+	"storage":                {value: func(d InstanceData) string { return derefString(d.Storage) }, equal: sameMemory},
+	"secondaries_count":      {value: func(d InstanceData) string { return strconv.Itoa(d.Secondaries) }},
+	"cdc_enrichment_mode":    {value: func(d InstanceData) string { return d.CDCEnrichment }, equal: strings.EqualFold},
+	"graph_analytics_plugin": {value: func(d InstanceData) string { return strconv.FormatBool(d.GDSPlugin) }},
+	"vector_optimized":       {value: func(d InstanceData) string { return strconv.FormatBool(d.VectorOptimized) }},
+	"connection_url":         {value: func(d InstanceData) string { return d.ConnectionURL }},
+}
+
+// DefaultDriftFields are the settings usually changed out of band in the
+// Console.
+var DefaultDriftFields = []string{
+	"memory", "secondaries_count", "cdc_enrichment_mode", "graph_analytics_plugin", "vector_optimized",
+}
+
+// DriftFields returns the names of every field DetectDrift can compare.
+func DriftFields() []string {
+	names := make([]string, 0, len(driftFields))
+	for name := range driftFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ============================================================================
+// Baselines
+// ============================================================================
+
+// CaptureBaseline records the full configuration of every instance, fetching
+// up to opts.Concurrency at once. Unlike FleetHealth it fails if any instance
+// cannot be fetched, since a partial baseline would later report drift that
+// is not there. Instances deleted while the baseline is captured are left out.
+func (c *AuraAPIClient) CaptureBaseline(ctx context.Context, opts BaselineOptions) (*Baseline, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative")
+	}
+	if concurrency == 0 {
+		concurrency = defaultBaselineConcurrency
+	}
+
+	list, err := c.Instances.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+	var ids []string
+	for _, inst := range list.Data {
+		if opts.TenantID == "" || inst.TenantID == opts.TenantID {
+			ids = append(ids, inst.ID)
+		}
+	}
+
+	instances := make([]*InstanceData, len(ids))
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			resp, err := c.Instances.Get(ctx, id)
+			var apiErr *Error
+			switch {
+			case errors.As(err, &apiErr) && apiErr.IsNotFound():
+			case err != nil:
+				errs[i] = fmt.Errorf("failed to get instance %s: %w", id, err)
+			default:
+				instances[i] = &resp.Data
+			}
+		}(i, id)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	baseline := &Baseline{CapturedAt: time.Now().UTC(), TenantID: opts.TenantID, Instances: []InstanceData{}}
+	for _, inst := range instances {
+		if inst != nil {
+			baseline.Instances = append(baseline.Instances, *inst)
+		}
+	}
+	sort.Slice(baseline.Instances, func(i, j int) bool { return baseline.Instances[i].ID < baseline.Instances[j].ID })
+
+	c.logger.InfoContext(ctx, "baseline captured", slog.Int("instances", len(baseline.Instances)))
+	return baseline, nil
+}
+
+// Save writes the baseline to path as indented JSON.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// LoadBaseline reads a baseline written by Baseline.Save.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	return &b, nil
+}
+
+// ============================================================================
+// Drift detection
+// ============================================================================
+
+// DetectDrift captures the current configuration of the baseline's instances
+// and compares it with the baseline. opts.TenantID is ignored; the baseline's
+// tenant is used.
+func (c *AuraAPIClient) DetectDrift(ctx context.Context, baseline *Baseline, opts BaselineOptions) (*DriftReport, error) {
+	if baseline == nil {
+		return nil, errors.New("baseline must not be nil")
+	}
+	if err := validateDriftFields(opts.Fields); err != nil {
+		return nil, err
+	}
+	opts.TenantID = baseline.TenantID
+	current, err := c.CaptureBaseline(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	report, err := CompareBaselines(baseline, current, opts.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.InfoContext(ctx, "drift check complete",
+		slog.Int("checked", report.Checked),
+		slog.Int("drifted", report.Drifted))
+
+	return report, nil
+}
+
+// CompareBaselines reports how actual differs from expected in fields, which
+// defaults to DefaultDriftFields. Memory and storage sizes are compared by
+// value and the CDC mode ignoring case.
+func CompareBaselines(expected, actual *Baseline, fields []string) (*DriftReport, error) {
+	if expected == nil || actual == nil {
+		return nil, errors.New("baselines must not be nil")
+	}
+	if err := validateDriftFields(fields); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		fields = DefaultDriftFields
+	}
+
+	report := &DriftReport{BaselineAt: expected.CapturedAt, CheckedAt: actual.CapturedAt, Drifts: []Drift{}}
+	current := make(map[string]InstanceData, len(actual.Instances))
+	for _, inst := range actual.Instances {
+		current[inst.ID] = inst
+	}
+	seen := make(map[string]bool, len(expected.Instances))
+	drifted := make(map[string]bool)
+	add := func(d Drift) {
+		report.Drifts = append(report.Drifts, d)
+		drifted[d.InstanceID] = true
+	}
+
+	for _, want := range expected.Instances {
+		seen[want.ID] = true
+		got, ok := current[want.ID]
+		if !ok {
+			add(Drift{InstanceID: want.ID, Name: want.Name, TenantID: want.TenantID, Kind: DriftRemoved})
+			continue
+		}
+		for _, name := range fields {
+			f := driftFields[name]
+			before, after := f.value(want), f.value(got)
+			equal := f.equal
+			if equal == nil {
+				equal = func(a, b string) bool { return a == b }
+			}
+			if !equal(before, after) {
+				add(Drift{InstanceID: got.ID, Name: got.Name, TenantID: got.TenantID, Kind: DriftChanged,
+					Field: name, Expected: before, Actual: after})
+			}
+		}
+	}
+	for _, got := range actual.Instances {
+		if !seen[got.ID] {
+			add(Drift{InstanceID: got.ID, Name: got.Name, TenantID: got.TenantID, Kind: DriftAdded})
+		}
+	}
+
+	report.Checked = len(seen)
+	for id := range current {
+		if !seen[id] {
+			report.Checked++
+		}
+	}
+	report.Drifted = len(drifted)
+	sort.SliceStable(report.Drifts, func(i, j int) bool {
+		a, b := report.Drifts[i], report.Drifts[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.InstanceID < b.InstanceID
+	})
+	return report, nil
+}
+
+// validateDriftFields checks that every field can be compared.
+func validateDriftFields(fields []string) error {
+	var errs ValidationErrors
+	for _, name := range fields {
+		if _, ok := driftFields[name]; !ok {
+			errs.add("fields", name, fmt.Errorf("must be one of %s", strings.Join(DriftFields(), ", ")))
+		}
+	}
+	return errs.err()
+}
+
+// sameMemory compares two sizes such as "8GB" by value, and as strings when
+// either does not parse.
+func sameMemory(a, b string) bool {
+	ga, errA := utils.ParseMemoryGB(a)
+	gb, errB := utils.ParseMemoryGB(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ga == gb
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
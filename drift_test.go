@@ -0,0 +1,116 @@
+package aura_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auratest"
+)
+
+func TestDetectDrift(t *testing.T) {
+	srv, seeded := seedManifestServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	ctx := context.Background()
+
+	baseline, err := client.CaptureBaseline(ctx, aura.BaselineOptions{TenantID: auratest.DefaultTenantID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(baseline.Instances) != 3 || baseline.TenantID != auratest.DefaultTenantID || baseline.CapturedAt.IsZero() {
+		t.Fatalf("Expected the default tenant's three instances, got %+v", baseline)
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.Save(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	baseline, err = aura.LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	report, err := client.DetectDrift(ctx, baseline, aura.BaselineOptions{})
+	if err != nil || report.HasDrift() {
+		t.Fatalf("Expected no drift straight after capture, got %+v, %v", report, err)
+	}
+
+	// Changes made out of band, as if in the Console.
+	orders := seeded["orders"]
+	orders.Memory, orders.Secondaries, orders.GDSPlugin = "16GB", 2, true
+	orders.Status = aura.StatusPaused
+	srv.AddInstance(orders)
+	cache := seeded["cache"]
+	cache.Memory, cache.CDCEnrichment = "8 GB", "off"
+	srv.AddInstance(cache)
+	added := srv.AddInstance(aura.InstanceData{Name: "adhoc", TenantID: auratest.DefaultTenantID, Memory: "1GB"})
+	srv.AddInstance(aura.InstanceData{Name: "elsewhere", TenantID: otherTenantID, Memory: "1GB"})
+	if _, err := client.Instances.Delete(ctx, seeded["legacy"].ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	waitGone(t, client, seeded["legacy"].ID)
+
+	report, err = client.DetectDrift(ctx, baseline, aura.BaselineOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []aura.Drift{
+		{InstanceID: added.ID, Name: "adhoc", TenantID: auratest.DefaultTenantID, Kind: aura.DriftAdded},
+		{InstanceID: seeded["legacy"].ID, Name: "legacy", TenantID: auratest.DefaultTenantID, Kind: aura.DriftRemoved},
+		{InstanceID: orders.ID, Name: "orders", TenantID: auratest.DefaultTenantID, Kind: aura.DriftChanged, Field: "memory", Expected: "8GB", Actual: "16GB"},
+		{InstanceID: orders.ID, Name: "orders", TenantID: auratest.DefaultTenantID, Kind: aura.DriftChanged, Field: "secondaries_count", Expected: "0", Actual: "2"},
+		{InstanceID: orders.ID, Name: "orders", TenantID: auratest.DefaultTenantID, Kind: aura.DriftChanged, Field: "graph_analytics_plugin", Expected: "false", Actual: "true"},
+	}
+	if len(report.Drifts) != len(want) {
+		t.Fatalf("Expected %d drifts, got %+v", len(want), report.Drifts)
+	}
+	for i := range want {
+		if report.Drifts[i] != want[i] {
+			t.Errorf("Drift %d: expected %+v, got %+v", i, want[i], report.Drifts[i])
+		}
+	}
+	if report.Checked != 4 || report.Drifted != 3 {
+		t.Errorf("Expected 3 of 4 instances drifted, got %d of %d", report.Drifted, report.Checked)
+	}
+
+	report, err = client.DetectDrift(ctx, baseline, aura.BaselineOptions{Fields: []string{"status"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.Drifts) != 3 || report.Drifts[2].Field != "status" || report.Drifts[2].Actual != "paused" {
+		t.Errorf("Expected only the status change besides added and removed, got %+v", report.Drifts)
+	}
+}
+
+func TestDetectDrift_UnknownField(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+
+	_, err := client.DetectDrift(context.Background(), &aura.Baseline{}, aura.BaselineOptions{Fields: []string{"colour"}})
+	var errs aura.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Errorf("Expected ValidationErrors, got %v", err)
+	}
+}
+
+// waitGone waits for a deleted instance to disappear.
+func waitGone(t *testing.T, client *aura.AuraAPIClient, id string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	events, err := client.Instances.Watch(ctx, id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for e := range events {
+		if e.Type == aura.WatchEventDeleted {
+			return
+		}
+	}
+	t.Fatalf("Timed out waiting for %s to be deleted", id)
+}
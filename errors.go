@@ -1,6 +1,10 @@
 package aura
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/LackOfMorals/aura-client/internal/api"
 )
 
@@ -9,3 +13,58 @@ type Error = api.Error
 
 // ErrorDetail represents individual error details.
 type ErrorDetail = api.ErrorDetail
+
+// ErrGDSSessionExceedsMaxMemory is returned by GDSSessionService.CreateSized
+// when the estimated size of a session is larger than the cap set with
+// WithSizingMaxMemory.
+var ErrGDSSessionExceedsMaxMemory = errors.New("GDS session estimate exceeds maximum memory")
+
+// ValidationError describes a single invalid field in a request. It is
+// returned inside ValidationErrors, so use errors.As to retrieve it.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors aggregates every problem found while validating a request,
+// so that callers can fix them all at once rather than one per round trip.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface, listing every field problem.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual errors so errors.Is and errors.As can inspect them.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, v := range e {
+		errs[i] = v
+	}
+	return errs
+}
+
+// add records a problem with field. A nil err is ignored.
+func (e *ValidationErrors) add(field, value string, err error) {
+	if err != nil {
+		*e = append(*e, &ValidationError{Field: field, Value: value, Message: err.Error()})
+	}
+}
+
+// err returns e as an error, or nil when no problems were recorded.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,66 @@
+// Package main prints a health report for every Aura instance and exits
+// non-zero when any instance is critical.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+)
+
+func main() {
+	clientID := os.Getenv("AURA_CLIENT_ID")
+	clientSecret := os.Getenv("AURA_CLIENT_SECRET")
+
+	if clientID == "" || clientSecret == "" {
+		log.Fatal("Missing required environment variables: AURA_CLIENT_ID, AURA_CLIENT_SECRET")
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelError}
+	handler := slog.NewTextHandler(os.Stderr, opts)
+	customLogger := slog.New(handler)
+
+	client, err := aura.NewClient(
+		aura.WithCredentials(clientID, clientSecret),
+		aura.WithTimeout(120*time.Second),
+		aura.WithLogger(customLogger),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	report, err := client.FleetHealth(ctx, aura.FleetHealthOptions{
+		TenantID:    os.Getenv("AURA_TENANT_ID"), // optional
+		Concurrency: 8,
+	})
+	if err != nil {
+		log.Fatalf("Failed to check fleet health: %v", err)
+	}
+
+	fmt.Printf("=== Fleet Health (%d instances) ===\n", report.Total)
+	fmt.Printf("critical: %d  warning: %d  healthy: %d  unknown: %d\n\n",
+		report.Critical, report.Warning, report.Healthy, report.Unknown)
+
+	for _, inst := range report.Instances {
+		fmt.Printf("%-8s  %s  %s\n", inst.Status, inst.InstanceID, inst.Name)
+		if inst.Error != "" {
+			fmt.Printf("          error: %s\n", inst.Error)
+			continue
+		}
+		for _, issue := range inst.Health.Issues {
+			fmt.Printf("          - %s\n", issue)
+		}
+	}
+
+	if report.Critical > 0 {
+		os.Exit(2)
+	}
+}
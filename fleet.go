@@ -0,0 +1,187 @@
+package aura
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// defaultFleetConcurrency is the number of instances checked in parallel when
+// FleetHealthOptions.Concurrency is not set.
+const defaultFleetConcurrency = 4
+
+// FleetHealthOptions controls a FleetHealth run.
+type FleetHealthOptions struct {
+	// TenantID limits the report to instances of one tenant. Empty means all.
+	TenantID string
+	// Concurrency limits the number of instances checked in parallel. Defaults to 4.
+	Concurrency int
+	// HealthRules assesses each instance instead of DefaultHealthRules.
+	HealthRules *HealthRuleSet
+}
+
+// FleetHealthReport summarises the health of every instance. Instances are
+// sorted critical first, then warning, healthy and unknown, and by name
+// within each status.
+type FleetHealthReport struct {
+	GeneratedAt time.Time             `json:"generated_at"`
+	Total       int                   `json:"total"`
+	Critical    int                   `json:"critical"`
+	Warning     int                   `json:"warning"`
+	Healthy     int                   `json:"healthy"`
+	Unknown     int                   `json:"unknown"`
+	Instances   []FleetInstanceHealth `json:"instances"`
+}
+
+// FleetInstanceHealth is the outcome for one instance. Status is
+// HealthStatusUnknown when Error is set.
+type FleetInstanceHealth struct {
+	InstanceID string                   `json:"instance_id"`
+	Name       string                   `json:"name"`
+	TenantID   string                   `json:"tenant_id"`
+	Status     string                   `json:"status"`
+	Health     *PrometheusHealthMetrics `json:"health,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// fleetStatusOrder ranks statuses for sorting a FleetHealthReport.
+var fleetStatusOrder = map[string]int{
+	HealthStatusCritical: 0,
+	HealthStatusWarning:  1,
+	HealthStatusHealthy:  2,
+	HealthStatusUnknown:  3,
+}
+
+// ============================================================================
+// Fleet health
+// ============================================================================
+
+// FleetHealth lists every instance, resolves its metrics URL and fetches its
+// health, checking up to opts.Concurrency instances at once. An error is
+// returned only when the instances cannot be listed; an instance whose details
+// or health cannot be fetched, or that is not running, is reported as unknown
+// with the reason in its Error field.
+func (c *AuraAPIClient) FleetHealth(ctx context.Context, opts FleetHealthOptions) (*FleetHealthReport, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative")
+	}
+	if concurrency == 0 {
+		concurrency = defaultFleetConcurrency
+	}
+	var healthOpts []HealthOption
+	if opts.HealthRules != nil {
+		if err := opts.HealthRules.Validate(); err != nil {
+			return nil, err
+		}
+		healthOpts = append(healthOpts, WithHealthRules(opts.HealthRules))
+	}
+
+	instances, err := c.Instances.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	report := &FleetHealthReport{GeneratedAt: time.Now(), Instances: []FleetInstanceHealth{}}
+	for _, inst := range instances.Data {
+		if opts.TenantID != "" && inst.TenantID != opts.TenantID {
+			continue
+		}
+		report.Instances = append(report.Instances, FleetInstanceHealth{InstanceID: inst.ID, Name: inst.Name, TenantID: inst.TenantID})
+	}
+
+	c.logger.InfoContext(ctx, "checking fleet health", slog.Int("instances", len(report.Instances)))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range report.Instances {
+		wg.Add(1)
+		go func(result *FleetInstanceHealth) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Status, result.Error = HealthStatusUnknown, ctx.Err().Error()
+				return
+			}
+			c.checkInstanceHealth(ctx, result, healthOpts)
+		}(&report.Instances[i])
+	}
+	wg.Wait()
+
+	for _, result := range report.Instances {
+		switch result.Status {
+		case HealthStatusCritical:
+			report.Critical++
+		case HealthStatusWarning:
+			report.Warning++
+		case HealthStatusHealthy:
+			report.Healthy++
+		default:
+			report.Unknown++
+		}
+	}
+	report.Total = len(report.Instances)
+	sort.SliceStable(report.Instances, func(i, j int) bool {
+		a, b := report.Instances[i], report.Instances[j]
+		if fleetStatusOrder[a.Status] != fleetStatusOrder[b.Status] {
+			return fleetStatusOrder[a.Status] < fleetStatusOrder[b.Status]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.InstanceID < b.InstanceID
+	})
+
+	c.logger.InfoContext(ctx, "fleet health check complete",
+		slog.Int("critical", report.Critical),
+		slog.Int("warning", report.Warning),
+		slog.Int("healthy", report.Healthy),
+		slog.Int("unknown", report.Unknown))
+
+	return report, nil
+}
+
+// checkInstanceHealth fills in the status of one instance in a fleet report.
+func (c *AuraAPIClient) checkInstanceHealth(ctx context.Context, result *FleetInstanceHealth, healthOpts []HealthOption) {
+	fail := func(err error) {
+		result.Status, result.Error = HealthStatusUnknown, err.Error()
+		c.logger.WarnContext(ctx, "could not determine instance health", slog.String("instanceID", result.InstanceID), slog.String("error", err.Error()))
+	}
+
+	instance, err := c.Instances.Get(ctx, result.InstanceID)
+	if err != nil {
+		fail(fmt.Errorf("failed to get instance: %w", err))
+		return
+	}
+	if instance.Data.Status != StatusRunning {
+		fail(fmt.Errorf("instance is %s", instance.Data.Status))
+		return
+	}
+	if instance.Data.MetricsURL == "" {
+		fail(errors.New("instance has no metrics URL"))
+		return
+	}
+
+	health, err := c.Prometheus.GetInstanceHealth(ctx, result.InstanceID, instance.Data.MetricsURL, healthOpts...)
+	if err != nil {
+		fail(fmt.Errorf("failed to get health: %w", err))
+		return
+	}
+	result.Health = health
+	result.Status = health.OverallStatus
+}
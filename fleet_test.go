@@ -0,0 +1,122 @@
+package aura
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/LackOfMorals/aura-client/internal/api"
+)
+
+// fleetScrape returns a scrape reporting cpu cores used out of a limit of 1.
+func fleetScrape(cpu string) []byte {
+	return []byte("neo4j_aura_cpu_usage " + cpu + "\nneo4j_aura_cpu_limit 1\n")
+}
+
+// newFleetTestMock serves five instances across two tenants: critical,
+// warning, healthy, paused, and one whose metrics endpoint fails.
+func newFleetTestMock() *mockAPIServiceRouter {
+	instances := []InstanceData{
+		{ID: "aaaaaaaa", Name: "zeta", TenantID: "t1", Status: StatusRunning, MetricsURL: "https://aaaaaaaa.metrics/prom"},
+		{ID: "bbbbbbbb", Name: "alpha", TenantID: "t1", Status: StatusRunning, MetricsURL: "https://bbbbbbbb.metrics/prom"},
+		{ID: "cccccccc", Name: "mid", TenantID: "t2", Status: StatusRunning, MetricsURL: "https://cccccccc.metrics/prom"},
+		{ID: "dddddddd", Name: "sleepy", TenantID: "t2", Status: StatusPaused},
+		{ID: "eeeeeeee", Name: "flaky", TenantID: "t1", Status: StatusRunning, MetricsURL: "https://eeeeeeee.metrics/prom"},
+	}
+	list := ListInstancesResponse{}
+	mock := newMockAPIServiceRouter()
+	for _, inst := range instances {
+		list.Data = append(list.Data, ListInstanceData{ID: inst.ID, Name: inst.Name, TenantID: inst.TenantID})
+		mock.on("GET", "instances/"+inst.ID, GetInstanceResponse{Data: inst})
+	}
+	return mock.
+		on("GET", "instances", list).
+		on("GET", "https://aaaaaaaa.metrics/prom", fleetScrape("0.97")).
+		on("GET", "https://bbbbbbbb.metrics/prom", fleetScrape("0.85")).
+		on("GET", "https://cccccccc.metrics/prom", fleetScrape("0.10")).
+		onError("GET", "https://eeeeeeee.metrics/prom", &api.Error{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"})
+}
+
+// TestFleetHealth_Report verifies counts, ordering and per-instance errors
+func TestFleetHealth_Report(t *testing.T) {
+	client := newTestClientWithAPI(newFleetTestMock())
+
+	report, err := client.FleetHealth(context.Background(), FleetHealthOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if report.Total != 5 || report.Critical != 1 || report.Warning != 1 || report.Healthy != 1 || report.Unknown != 2 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+
+	wantOrder := []string{"zeta", "alpha", "mid", "flaky", "sleepy"}
+	for i, want := range wantOrder {
+		if got := report.Instances[i].Name; got != want {
+			t.Errorf("position %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	for _, inst := range report.Instances {
+		switch inst.Name {
+		case "sleepy", "flaky":
+			if inst.Status != HealthStatusUnknown || inst.Error == "" || inst.Health != nil {
+				t.Errorf("%s: expected unknown with an error, got %+v", inst.Name, inst)
+			}
+		default:
+			if inst.Error != "" || inst.Health == nil {
+				t.Errorf("%s: expected health without error, got %+v", inst.Name, inst)
+			}
+		}
+	}
+}
+
+// TestFleetHealth_TenantFilter verifies only the requested tenant is checked
+func TestFleetHealth_TenantFilter(t *testing.T) {
+	mock := newFleetTestMock()
+	client := newTestClientWithAPI(mock)
+
+	report, err := client.FleetHealth(context.Background(), FleetHealthOptions{TenantID: "t2"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Total != 2 || report.Healthy != 1 || report.Unknown != 1 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	if n := len(mock.callsTo("GET", "instances/aaaaaaaa")); n != 0 {
+		t.Errorf("instance of another tenant should not be fetched, got %d calls", n)
+	}
+}
+
+// TestFleetHealth_HealthRules verifies custom rules are applied to every instance
+func TestFleetHealth_HealthRules(t *testing.T) {
+	client := newTestClientWithAPI(newFleetTestMock())
+	lenient := DefaultHealthRules()
+	lenient.Rules[0].Warning.Value = 90
+	lenient.Rules[0].Critical.Value = 99
+
+	report, err := client.FleetHealth(context.Background(), FleetHealthOptions{HealthRules: lenient})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Critical != 0 || report.Warning != 1 || report.Healthy != 2 {
+		t.Errorf("unexpected counts with lenient rules: %+v", report)
+	}
+
+	if _, err := client.FleetHealth(context.Background(), FleetHealthOptions{HealthRules: &HealthRuleSet{}}); err == nil {
+		t.Error("expected error for invalid rules")
+	}
+}
+
+// TestFleetHealth_ListError verifies a list failure is returned
+func TestFleetHealth_ListError(t *testing.T) {
+	mock := newMockAPIServiceRouter().onError("GET", "instances", &api.Error{StatusCode: http.StatusUnauthorized, Message: "unauthorized"})
+	client := newTestClientWithAPI(mock)
+
+	if _, err := client.FleetHealth(context.Background(), FleetHealthOptions{}); err == nil {
+		t.Error("expected error when instances cannot be listed")
+	}
+	if _, err := client.FleetHealth(context.Background(), FleetHealthOptions{Concurrency: -1}); err == nil {
+		t.Error("expected error for negative concurrency")
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/LackOfMorals/aura-client/internal/api"
@@ -140,6 +143,94 @@ type DeleteGDSSession struct {
 	ID string `json:"id"`
 }
 
+// CreateSizedGDSSessionResponse holds the outcome of CreateSized: the size
+// estimate returned by the API, the memory that was requested for the new
+// session after headroom and capping, and the session itself.
+type CreateSizedGDSSessionResponse struct {
+	Estimate GDSSessionSizeEstimationData `json:"estimate"`
+	Memory   string                       `json:"memory"`
+	Session  GetGDSSessionData            `json:"session"`
+}
+
+// GDSSessionSizingOption configures how CreateSized chooses the memory for a
+// new GDS session.
+type GDSSessionSizingOption func(*gdsSessionSizing) error
+
+// gdsSessionSizing holds the settings applied by GDSSessionSizingOption values.
+type gdsSessionSizing struct {
+	headroom    float64 // multiplier applied to the recommended size
+	maxMemoryGB int     // upper bound in GB; 0 means uncapped
+}
+
+// gdsSessionMemorySizes lists the GDS session sizes, in GB, offered by Aura.
+// Headroom-adjusted sizes are rounded up to the next entry so that the
+// requested memory is always one the API accepts.
+var gdsSessionMemorySizes = []int{1, 2, 4, 8, 16, 24, 32, 48, 64, 96, 128, 192, 256, 384, 512}
+
+// WithSizingHeadroom multiplies the recommended size by multiplier before the
+// session is created, e.g. 1.25 adds 25% headroom. The result is rounded up to
+// the next available session size. The multiplier must be at least 1.
+func WithSizingHeadroom(multiplier float64) GDSSessionSizingOption {
+	return func(s *gdsSessionSizing) error {
+		if multiplier < 1 || math.IsInf(multiplier, 0) || math.IsNaN(multiplier) {
+			return fmt.Errorf("sizing headroom must be a finite number of at least 1, got %v", multiplier)
+		}
+		s.headroom = multiplier
+		return nil
+	}
+}
+
+// WithSizingMaxMemory caps the memory CreateSized may request, e.g. "32GB".
+// If the recommended size itself exceeds the cap CreateSized refuses to create
+// the session; if only the headroom pushes it over, the largest session size
+// at or below the cap is used instead.
+func WithSizingMaxMemory(memory string) GDSSessionSizingOption {
+	return func(s *gdsSessionSizing) error {
+		gb, err := utils.ParseMemoryGB(memory)
+		if err != nil {
+			return fmt.Errorf("invalid max memory: %w", err)
+		}
+		s.maxMemoryGB = gb
+		return nil
+	}
+}
+
+// GDSSessionFilter selects GDS sessions client-side. Empty string fields and
+// zero times are ignored; all set fields must match. String comparisons are
+// case-insensitive.
+type GDSSessionFilter struct {
+	InstanceID    string
+	TenantID      string
+	UserID        string
+	Status        string
+	CloudProvider string
+	Region        string
+	// ExpiresAfter and ExpiresBefore bound ExpiresAt. Sessions without an
+	// expiry never match when either bound is set.
+	ExpiresAfter  time.Time
+	ExpiresBefore time.Time
+}
+
+// GDSSessionGroupKey selects the field GroupGDSSessions groups by.
+type GDSSessionGroupKey string
+
+// Fields supported by GroupGDSSessions.
+const (
+	GroupByInstance GDSSessionGroupKey = "instance_id"
+	GroupByTenant   GDSSessionGroupKey = "tenant_id"
+	GroupByUser     GDSSessionGroupKey = "user_id"
+)
+
+// GDSSessionGroup holds the sessions sharing one value of a GDSSessionGroupKey
+// together with their combined memory. Sessions whose Memory cannot be parsed
+// are counted in UnknownMemory rather than TotalMemoryGB.
+type GDSSessionGroup struct {
+	Key           string              `json:"key"`
+	Sessions      []GetGDSSessionData `json:"sessions"`
+	TotalMemoryGB int                 `json:"total_memory_gb"`
+	UnknownMemory int                 `json:"unknown_memory"`
+}
+
 // ============================================================================
 // Service
 // ============================================================================
@@ -149,6 +240,7 @@ type gDSSessionService struct {
 	api     api.RequestService
 	timeout time.Duration
 	logger  *slog.Logger
+	watch   watchConfig
 }
 
 // List returns all GDS sessions accessible to the authenticated user.
@@ -187,8 +279,9 @@ func (g *gDSSessionService) Get(ctx context.Context, gdsSessionID string) (*GetG
 	ctx, cancel := context.WithTimeout(ctx, g.timeout)
 	defer cancel()
 
-	if gdsSessionID == "" {
-		return nil, fmt.Errorf("GDS session ID must not be empty")
+	if err := validateGDSSessionID(gdsSessionID); err != nil {
+		g.logger.ErrorContext(ctx, "invalid GDS session ID", slog.String("error", err.Error()))
+		return nil, err
 	}
 
 	g.logger.DebugContext(ctx, "getting GDS session", slog.String("sessionID", gdsSessionID))
@@ -222,6 +315,11 @@ func (g *gDSSessionService) Create(ctx context.Context, gdsSessionConfigRequest
 		return nil, fmt.Errorf("gdsSessionConfigRequest must not be nil")
 	}
 
+	if err := gdsSessionConfigRequest.Validate(); err != nil {
+		g.logger.ErrorContext(ctx, "failed to validate GDS session configuration", slog.String("error", err.Error()))
+		return nil, err
+	}
+
 	g.logger.DebugContext(ctx, "creating GDS session")
 
 	body, err := utils.Marshal(gdsSessionConfigRequest)
@@ -292,8 +390,9 @@ func (g *gDSSessionService) Delete(ctx context.Context, gdsSessionID string) (*D
 	ctx, cancel := context.WithTimeout(ctx, g.timeout)
 	defer cancel()
 
-	if gdsSessionID == "" {
-		return nil, fmt.Errorf("GDS session ID must not be empty")
+	if err := validateGDSSessionID(gdsSessionID); err != nil {
+		g.logger.ErrorContext(ctx, "invalid GDS session ID", slog.String("error", err.Error()))
+		return nil, err
 	}
 
 	g.logger.DebugContext(ctx, "deleting a GDS session", slog.String("sessionID", gdsSessionID))
@@ -313,3 +412,264 @@ func (g *gDSSessionService) Delete(ctx context.Context, gdsSessionID string) (*D
 	g.logger.DebugContext(ctx, "GDS session deleted successfully")
 	return &result, nil
 }
+
+// CreateSized estimates the memory needed for a GDS session, applies any
+// headroom and cap from opts, and creates the session with the chosen memory.
+// The Memory field of createRequest is ignored and the caller's struct is not
+// modified. ErrGDSSessionExceedsMaxMemory is returned, and no session is
+// created, when the recommended size is larger than the configured cap.
+func (g *gDSSessionService) CreateSized(ctx context.Context, estimateRequest *GetGDSSessionSizeEstimation, createRequest *CreateGDSSessionConfigData, opts ...GDSSessionSizingOption) (*CreateSizedGDSSessionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		g.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if estimateRequest == nil {
+		return nil, fmt.Errorf("estimateRequest must not be nil")
+	}
+	if createRequest == nil {
+		return nil, fmt.Errorf("createRequest must not be nil")
+	}
+	if err := createRequest.validate(false); err != nil {
+		g.logger.ErrorContext(ctx, "failed to validate GDS session configuration", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	sizing := gdsSessionSizing{headroom: 1}
+	for _, opt := range opts {
+		if err := opt(&sizing); err != nil {
+			g.logger.ErrorContext(ctx, "invalid sizing option", slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	estimate, err := g.Estimate(ctx, estimateRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate GDS session size: %w", err)
+	}
+
+	memoryGB, err := chooseGDSSessionMemory(estimate.Data.RecommendedSize, sizing)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "unable to size GDS session", slog.String("recommendedSize", estimate.Data.RecommendedSize), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	request := *createRequest
+	request.Memory = utils.FormatMemoryGB(memoryGB)
+
+	g.logger.DebugContext(ctx, "creating sized GDS session",
+		slog.String("recommendedSize", estimate.Data.RecommendedSize),
+		slog.String("memory", request.Memory))
+
+	session, err := g.Create(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GDS session: %w", err)
+	}
+
+	return &CreateSizedGDSSessionResponse{
+		Estimate: estimate.Data,
+		Memory:   request.Memory,
+		Session:  session.Data,
+	}, nil
+}
+
+// chooseGDSSessionMemory returns the session size, in GB, to request for a
+// recommended size once sizing has been applied.
+func chooseGDSSessionMemory(recommendedSize string, sizing gdsSessionSizing) (int, error) {
+	recommendedGB, err := utils.ParseMemoryGB(recommendedSize)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected recommended size from estimate: %w", err)
+	}
+
+	if sizing.maxMemoryGB > 0 && recommendedGB > sizing.maxMemoryGB {
+		return 0, fmt.Errorf("%w: recommended %dGB, maximum %dGB", ErrGDSSessionExceedsMaxMemory, recommendedGB, sizing.maxMemoryGB)
+	}
+
+	wantGB := int(math.Ceil(float64(recommendedGB) * sizing.headroom))
+	memoryGB := wantGB
+	for _, size := range gdsSessionMemorySizes {
+		if size >= wantGB {
+			memoryGB = size
+			break
+		}
+	}
+	// Sizes above the largest known tier are sent as-is and left for the API to judge.
+
+	if sizing.maxMemoryGB > 0 && memoryGB > sizing.maxMemoryGB {
+		// Clamp to the largest offered size within the cap; the cap itself may
+		// not be a size the API accepts.
+		memoryGB = 0
+		for _, size := range gdsSessionMemorySizes {
+			if size <= sizing.maxMemoryGB {
+				memoryGB = size
+			}
+		}
+		if memoryGB < recommendedGB {
+			return 0, fmt.Errorf("%w: recommended %dGB, largest session size within the %dGB maximum is %dGB",
+				ErrGDSSessionExceedsMaxMemory, recommendedGB, sizing.maxMemoryGB, memoryGB)
+		}
+	}
+	return memoryGB, nil
+}
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// Validate checks the request against the rules the Aura API applies to new
+// GDS sessions and returns every problem found as ValidationErrors:
+//
+//   - Name and Memory (whole gigabytes, e.g. "8GB") are required.
+//   - TTL, when set, must be a positive Go duration ("2h") or ISO-8601 duration ("PT2H").
+//   - Exactly one of InstanceID (attached session) and DatabaseID (self-managed
+//     database) must be set. InstanceID must be an 8-character hex string.
+//   - A self-managed session also needs DatabaseID and TenantID as UUIDs, plus
+//     CloudProvider and Region. TenantID, when set, must always be a UUID.
+func (c *CreateGDSSessionConfigData) Validate() error {
+	return c.validate(true)
+}
+
+// validate implements Validate. CreateSized passes requireMemory=false because
+// it fills Memory in from the estimate after validating the rest of the request.
+func (c *CreateGDSSessionConfigData) validate(requireMemory bool) error {
+	var errs ValidationErrors
+
+	if c.Name == "" {
+		errs.add("name", c.Name, fmt.Errorf("name must not be empty"))
+	}
+
+	if requireMemory {
+		if c.Memory == "" {
+			errs.add("memory", c.Memory, fmt.Errorf("memory must not be empty"))
+		} else if _, err := utils.ParseMemoryGB(c.Memory); err != nil {
+			errs.add("memory", c.Memory, err)
+		}
+	}
+
+	if c.TTL != "" {
+		if _, err := utils.ParseTTL(c.TTL); err != nil {
+			errs.add("ttl", c.TTL, err)
+		}
+	}
+
+	switch {
+	case c.InstanceID != "" && c.DatabaseID != "":
+		errs.add("instance_id", c.InstanceID, fmt.Errorf("instance ID and database ID are mutually exclusive; set only one"))
+	case c.InstanceID == "" && c.DatabaseID == "":
+		errs.add("instance_id", "", fmt.Errorf("one of instance ID or database ID must be set"))
+	case c.InstanceID != "":
+		errs.add("instance_id", c.InstanceID, utils.ValidateInstanceID(c.InstanceID))
+	default:
+		errs.add("database_uuid", c.DatabaseID, utils.ValidateUUID("database ID", c.DatabaseID))
+		if c.TenantID == "" {
+			errs.add("tenant_id", "", fmt.Errorf("tenant ID is required for a self-managed database"))
+		}
+		if c.CloudProvider == "" {
+			errs.add("cloud_provider", "", fmt.Errorf("cloud provider is required for a self-managed database"))
+		}
+		if c.Region == "" {
+			errs.add("region", "", fmt.Errorf("region is required for a self-managed database"))
+		}
+	}
+
+	if c.TenantID != "" {
+		errs.add("tenant_id", c.TenantID, utils.ValidateTenantID(c.TenantID))
+	}
+
+	return errs.err()
+}
+
+// validateGDSSessionID wraps utils.ValidateGDSSessionID in ValidationErrors so
+// that ID and request validation failures share one error type.
+func validateGDSSessionID(sessionID string) error {
+	var errs ValidationErrors
+	errs.add("id", sessionID, utils.ValidateGDSSessionID(sessionID))
+	return errs.err()
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Match reports whether session satisfies every field set on f.
+func (f GDSSessionFilter) Match(session GetGDSSessionData) bool {
+	fields := []struct{ want, got string }{
+		{f.InstanceID, session.InstanceID},
+		{f.TenantID, session.TenantID},
+		{f.UserID, session.UserID},
+		{f.Status, session.Status},
+		{f.CloudProvider, session.CloudProvider},
+		{f.Region, session.Region},
+	}
+	for _, field := range fields {
+		if field.want != "" && !strings.EqualFold(field.want, field.got) {
+			return false
+		}
+	}
+	if !f.ExpiresAfter.IsZero() && (session.ExpiresAt.IsZero() || !session.ExpiresAt.After(f.ExpiresAfter)) {
+		return false
+	}
+	if !f.ExpiresBefore.IsZero() && (session.ExpiresAt.IsZero() || !session.ExpiresAt.Before(f.ExpiresBefore)) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the sessions in the list that match f, in their original order.
+func (r *GetGDSSessionListResponse) Filter(f GDSSessionFilter) []GetGDSSessionData {
+	if r == nil {
+		return nil
+	}
+	return FilterGDSSessions(r.Data, f)
+}
+
+// FilterGDSSessions returns the sessions that match f, in their original order.
+func FilterGDSSessions(sessions []GetGDSSessionData, f GDSSessionFilter) []GetGDSSessionData {
+	matched := []GetGDSSessionData{}
+	for _, session := range sessions {
+		if f.Match(session) {
+			matched = append(matched, session)
+		}
+	}
+	return matched
+}
+
+// GroupGDSSessions groups sessions by key and totals the memory of each group,
+// e.g. for chargeback reports. Groups are sorted by key; sessions with an empty
+// key value are collected in a group whose Key is "".
+func GroupGDSSessions(sessions []GetGDSSessionData, key GDSSessionGroupKey) ([]GDSSessionGroup, error) {
+	var keyOf func(GetGDSSessionData) string
+	switch key {
+	case GroupByInstance:
+		keyOf = func(s GetGDSSessionData) string { return s.InstanceID }
+	case GroupByTenant:
+		keyOf = func(s GetGDSSessionData) string { return s.TenantID }
+	case GroupByUser:
+		keyOf = func(s GetGDSSessionData) string { return s.UserID }
+	default:
+		return nil, fmt.Errorf("unsupported GDS session group key %q", key)
+	}
+
+	byKey := make(map[string]*GDSSessionGroup)
+	for _, session := range sessions {
+		k := keyOf(session)
+		group, ok := byKey[k]
+		if !ok {
+			group = &GDSSessionGroup{Key: k}
+			byKey[k] = group
+		}
+		group.Sessions = append(group.Sessions, session)
+		if gb, err := utils.ParseMemoryGB(session.Memory); err == nil {
+			group.TotalMemoryGB += gb
+		} else {
+			group.UnknownMemory++
+		}
+	}
+
+	groups := make([]GDSSessionGroup, 0, len(byKey))
+	for _, group := range byKey {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups, nil
+}
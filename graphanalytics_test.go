@@ -268,3 +268,347 @@ func TestGDSSessionService_List_ContextTimeout(t *testing.T) {
 		t.Errorf("timeout took too long: %v", elapsed)
 	}
 }
+
+// validGDSSessionRequest returns a create request for an attached session that
+// passes validation; Memory is left empty for CreateSized to fill in.
+func validGDSSessionRequest() *CreateGDSSessionConfigData {
+	return &CreateGDSSessionConfigData{Name: "sized", TTL: "2h", InstanceID: "c9f0d13a"}
+}
+
+// TestGDSSessionService_CreateSized_UsesRecommendedSize verifies the estimate drives the create request
+func TestGDSSessionService_CreateSized_UsesRecommendedSize(t *testing.T) {
+	mock := newMockAPIServiceRouter().
+		on("POST", "graph-analytics/sessions/sizing", GDSSessionSizeEstimationResponse{
+			Data: GDSSessionSizeEstimationData{EstimatedMemory: "6GB", RecommendedSize: "8GB"},
+		}).
+		on("POST", "graph-analytics/sessions", GetGDSSessionResponse{
+			Data: GetGDSSessionData{ID: "session-1", Memory: "8GB"},
+		})
+
+	service := createTestGDSSessionServiceWithTimeout(mock, 30*time.Second)
+	createReq := &CreateGDSSessionConfigData{Name: "sized", InstanceID: "c9f0d13a", Memory: "1GB"}
+	result, err := service.CreateSized(context.Background(), &GetGDSSessionSizeEstimation{NodeCount: 1000}, createReq)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Memory != "8GB" {
+		t.Errorf("expected memory '8GB', got '%s'", result.Memory)
+	}
+	if result.Estimate.EstimatedMemory != "6GB" {
+		t.Errorf("expected estimated memory '6GB', got '%s'", result.Estimate.EstimatedMemory)
+	}
+	if result.Session.ID != "session-1" {
+		t.Errorf("expected session ID 'session-1', got '%s'", result.Session.ID)
+	}
+	if createReq.Memory != "1GB" {
+		t.Errorf("caller's request was modified: memory is '%s'", createReq.Memory)
+	}
+
+	calls := mock.callsTo("POST", "graph-analytics/sessions")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 create call, got %d", len(calls))
+	}
+	var sent CreateGDSSessionConfigData
+	if err := json.Unmarshal([]byte(calls[0].body), &sent); err != nil {
+		t.Fatalf("failed to unmarshal create body: %v", err)
+	}
+	if sent.Memory != "8GB" || sent.Name != "sized" {
+		t.Errorf("unexpected create body: %+v", sent)
+	}
+}
+
+// TestGDSSessionService_CreateSized_Headroom verifies headroom rounds up to the next session size
+func TestGDSSessionService_CreateSized_Headroom(t *testing.T) {
+	tests := []struct {
+		name        string
+		recommended string
+		opts        []GDSSessionSizingOption
+		want        string
+	}{
+		{"no headroom", "16GB", nil, "16GB"},
+		{"25 percent headroom", "16GB", []GDSSessionSizingOption{WithSizingHeadroom(1.25)}, "24GB"},
+		{"headroom rounds up", "8GB", []GDSSessionSizingOption{WithSizingHeadroom(1.1)}, "16GB"},
+		{"headroom clamped to cap", "16GB", []GDSSessionSizingOption{WithSizingHeadroom(2), WithSizingMaxMemory("24GB")}, "24GB"},
+		{"recommended equal to cap", "32GB", []GDSSessionSizingOption{WithSizingMaxMemory("32GB")}, "32GB"},
+		{"cap between sizes rounds down", "16GB", []GDSSessionSizingOption{WithSizingHeadroom(1.25), WithSizingMaxMemory("20GB")}, "16GB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockAPIServiceRouter().
+				on("POST", "graph-analytics/sessions/sizing", GDSSessionSizeEstimationResponse{
+					Data: GDSSessionSizeEstimationData{RecommendedSize: tt.recommended},
+				}).
+				on("POST", "graph-analytics/sessions", GetGDSSessionResponse{Data: GetGDSSessionData{ID: "session-1"}})
+
+			service := createTestGDSSessionServiceWithTimeout(mock, 30*time.Second)
+			result, err := service.CreateSized(context.Background(), &GetGDSSessionSizeEstimation{}, validGDSSessionRequest(), tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.Memory != tt.want {
+				t.Errorf("expected memory '%s', got '%s'", tt.want, result.Memory)
+			}
+		})
+	}
+}
+
+// TestGDSSessionService_CreateSized_ExceedsCap verifies no session is created when the estimate is too large
+func TestGDSSessionService_CreateSized_ExceedsCap(t *testing.T) {
+	mock := newMockAPIServiceRouter().
+		on("POST", "graph-analytics/sessions/sizing", GDSSessionSizeEstimationResponse{
+			Data: GDSSessionSizeEstimationData{RecommendedSize: "64GB"},
+		}).
+		on("POST", "graph-analytics/sessions", GetGDSSessionResponse{})
+
+	service := createTestGDSSessionServiceWithTimeout(mock, 30*time.Second)
+	result, err := service.CreateSized(context.Background(), &GetGDSSessionSizeEstimation{}, validGDSSessionRequest(), WithSizingMaxMemory("32GB"))
+
+	if !errors.Is(err, ErrGDSSessionExceedsMaxMemory) {
+		t.Fatalf("expected ErrGDSSessionExceedsMaxMemory, got %v", err)
+	}
+	if result != nil {
+		t.Error("expected nil result on error")
+	}
+	if n := len(mock.callsTo("POST", "graph-analytics/sessions")); n != 0 {
+		t.Errorf("expected no create call, got %d", n)
+	}
+}
+
+// TestGDSSessionService_CreateSized_InvalidInput verifies argument and option validation
+func TestGDSSessionService_CreateSized_InvalidInput(t *testing.T) {
+	mock := newMockAPIServiceRouter()
+	service := createTestGDSSessionServiceWithTimeout(mock, 30*time.Second)
+	ctx := context.Background()
+
+	if _, err := service.CreateSized(ctx, nil, validGDSSessionRequest()); err == nil {
+		t.Error("expected error for nil estimate request")
+	}
+	if _, err := service.CreateSized(ctx, &GetGDSSessionSizeEstimation{}, nil); err == nil {
+		t.Error("expected error for nil create request")
+	}
+	if _, err := service.CreateSized(ctx, &GetGDSSessionSizeEstimation{}, validGDSSessionRequest(), WithSizingHeadroom(0.5)); err == nil {
+		t.Error("expected error for headroom below 1")
+	}
+	if _, err := service.CreateSized(ctx, &GetGDSSessionSizeEstimation{}, validGDSSessionRequest(), WithSizingMaxMemory("lots")); err == nil {
+		t.Error("expected error for unparsable max memory")
+	}
+	var verrs ValidationErrors
+	if _, err := service.CreateSized(ctx, &GetGDSSessionSizeEstimation{}, &CreateGDSSessionConfigData{Name: "no-target"}); !errors.As(err, &verrs) {
+		t.Errorf("expected ValidationErrors before estimating, got %v", err)
+	}
+	if len(mock.calls) != 0 {
+		t.Errorf("expected no API calls, got %d", len(mock.calls))
+	}
+}
+
+// TestGDSSessionService_CreateSized_EstimateError verifies estimate failures are propagated
+func TestGDSSessionService_CreateSized_EstimateError(t *testing.T) {
+	mock := newMockAPIServiceRouter().
+		onError("POST", "graph-analytics/sessions/sizing", &api.Error{StatusCode: http.StatusBadRequest, Message: "bad estimate"})
+
+	service := createTestGDSSessionServiceWithTimeout(mock, 30*time.Second)
+	_, err := service.CreateSized(context.Background(), &GetGDSSessionSizeEstimation{}, validGDSSessionRequest())
+
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected wrapped *api.Error, got %v", err)
+	}
+	if !apiErr.IsBadRequest() {
+		t.Errorf("expected status 400, got %d", apiErr.StatusCode)
+	}
+}
+
+// filterTestSessions returns sessions spread across instances, tenants and users.
+func filterTestSessions(now time.Time) []GetGDSSessionData {
+	return []GetGDSSessionData{
+		{ID: "s1", InstanceID: "aaaaaaaa", TenantID: "t1", UserID: "u1", Status: "Ready", CloudProvider: "gcp", Region: "europe-west2", Memory: "8GB", ExpiresAt: now.Add(time.Hour)},
+		{ID: "s2", InstanceID: "aaaaaaaa", TenantID: "t1", UserID: "u2", Status: "Ready", CloudProvider: "gcp", Region: "europe-west2", Memory: "16GB", ExpiresAt: now.Add(3 * time.Hour)},
+		{ID: "s3", InstanceID: "bbbbbbbb", TenantID: "t2", UserID: "u1", Status: "Creating", CloudProvider: "aws", Region: "us-east-1", Memory: "4GB"},
+		{ID: "s4", TenantID: "t2", UserID: "u1", Status: "Ready", CloudProvider: "aws", Region: "us-east-1", Memory: "unknown", ExpiresAt: now.Add(-time.Hour)},
+	}
+}
+
+// TestFilterGDSSessions verifies each filter field narrows the result
+func TestFilterGDSSessions(t *testing.T) {
+	now := time.Now()
+	sessions := filterTestSessions(now)
+
+	tests := []struct {
+		name   string
+		filter GDSSessionFilter
+		want   []string
+	}{
+		{"empty filter matches all", GDSSessionFilter{}, []string{"s1", "s2", "s3", "s4"}},
+		{"instance", GDSSessionFilter{InstanceID: "aaaaaaaa"}, []string{"s1", "s2"}},
+		{"tenant and user", GDSSessionFilter{TenantID: "t2", UserID: "u1"}, []string{"s3", "s4"}},
+		{"status is case-insensitive", GDSSessionFilter{Status: "ready"}, []string{"s1", "s2", "s4"}},
+		{"provider and region", GDSSessionFilter{CloudProvider: "aws", Region: "us-east-1"}, []string{"s3", "s4"}},
+		{"expires within two hours", GDSSessionFilter{ExpiresAfter: now, ExpiresBefore: now.Add(2 * time.Hour)}, []string{"s1"}},
+		{"already expired", GDSSessionFilter{ExpiresBefore: now}, []string{"s4"}},
+		{"no match", GDSSessionFilter{UserID: "nobody"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterGDSSessions(sessions, tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d sessions, got %d", len(tt.want), len(got))
+			}
+			for i, s := range got {
+				if s.ID != tt.want[i] {
+					t.Errorf("position %d: expected '%s', got '%s'", i, tt.want[i], s.ID)
+				}
+			}
+		})
+	}
+
+	list := &GetGDSSessionListResponse{Data: sessions}
+	if got := list.Filter(GDSSessionFilter{InstanceID: "bbbbbbbb"}); len(got) != 1 || got[0].ID != "s3" {
+		t.Errorf("expected list filter to return s3, got %+v", got)
+	}
+}
+
+// TestGroupGDSSessions verifies grouping and memory totals
+func TestGroupGDSSessions(t *testing.T) {
+	sessions := filterTestSessions(time.Now())
+
+	groups, err := GroupGDSSessions(sessions, GroupByInstance)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	// Sorted by key: "" (no instance), aaaaaaaa, bbbbbbbb.
+	if groups[0].Key != "" || groups[0].UnknownMemory != 1 || groups[0].TotalMemoryGB != 0 {
+		t.Errorf("unexpected ungrouped sessions: %+v", groups[0])
+	}
+	if groups[1].Key != "aaaaaaaa" || len(groups[1].Sessions) != 2 || groups[1].TotalMemoryGB != 24 {
+		t.Errorf("unexpected group for aaaaaaaa: %+v", groups[1])
+	}
+	if groups[2].Key != "bbbbbbbb" || groups[2].TotalMemoryGB != 4 {
+		t.Errorf("unexpected group for bbbbbbbb: %+v", groups[2])
+	}
+
+	byUser, err := GroupGDSSessions(sessions, GroupByUser)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(byUser) != 2 || byUser[0].Key != "u1" || byUser[0].TotalMemoryGB != 12 {
+		t.Errorf("unexpected user groups: %+v", byUser)
+	}
+
+	if _, err := GroupGDSSessions(sessions, "region"); err == nil {
+		t.Error("expected error for unsupported group key")
+	}
+}
+
+// TestCreateGDSSessionConfigData_Validate verifies create request validation rules
+func TestCreateGDSSessionConfigData_Validate(t *testing.T) {
+	const tenant = "12345678-abcd-4321-efef-000000000001"
+	const database = "87654321-abcd-4321-efef-000000000002"
+
+	tests := []struct {
+		name       string
+		req        CreateGDSSessionConfigData
+		wantFields []string
+	}{
+		{
+			name: "valid attached session",
+			req:  CreateGDSSessionConfigData{Name: "s", Memory: "8GB", TTL: "PT2H", InstanceID: "c9f0d13a"},
+		},
+		{
+			name: "valid self-managed session",
+			req: CreateGDSSessionConfigData{Name: "s", Memory: "8GB", TTL: "2h", DatabaseID: database,
+				TenantID: tenant, CloudProvider: "gcp", Region: "europe-west2"},
+		},
+		{
+			name:       "instance and database are mutually exclusive",
+			req:        CreateGDSSessionConfigData{Name: "s", Memory: "8GB", InstanceID: "c9f0d13a", DatabaseID: database},
+			wantFields: []string{"instance_id"},
+		},
+		{
+			name:       "every problem is reported",
+			req:        CreateGDSSessionConfigData{Memory: "8 gigs", TTL: "soon", InstanceID: "not-hex", TenantID: "tenant-1"},
+			wantFields: []string{"name", "memory", "ttl", "instance_id", "tenant_id"},
+		},
+		{
+			name:       "self-managed session needs tenant, provider and region",
+			req:        CreateGDSSessionConfigData{Name: "s", Memory: "8GB", DatabaseID: "db-1"},
+			wantFields: []string{"database_uuid", "tenant_id", "cloud_provider", "region"},
+		},
+		{
+			name:       "missing target and memory",
+			req:        CreateGDSSessionConfigData{Name: "s"},
+			wantFields: []string{"memory", "instance_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+			if len(verrs) != len(tt.wantFields) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tt.wantFields), len(verrs), err)
+			}
+			for i, field := range tt.wantFields {
+				if verrs[i].Field != field {
+					t.Errorf("problem %d: expected field '%s', got '%s'", i, field, verrs[i].Field)
+				}
+			}
+
+			var single *ValidationError
+			if !errors.As(err, &single) {
+				t.Error("expected errors.As to find a *ValidationError")
+			}
+		})
+	}
+}
+
+// TestGDSSessionService_Create_ValidationError verifies invalid requests never reach the API
+func TestGDSSessionService_Create_ValidationError(t *testing.T) {
+	mock := &mockAPIService{}
+	service := createTestGDSSessionService(mock)
+
+	_, err := service.Create(context.Background(), &CreateGDSSessionConfigData{Name: "s", Memory: "8GB"})
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if mock.lastMethod != "" {
+		t.Errorf("expected no API call, got %s %s", mock.lastMethod, mock.lastPath)
+	}
+}
+
+// TestGDSSessionService_GetDelete_InvalidID verifies session ID validation
+func TestGDSSessionService_GetDelete_InvalidID(t *testing.T) {
+	mock := &mockAPIService{}
+	service := createTestGDSSessionService(mock)
+	ctx := context.Background()
+
+	for _, id := range []string{"", "../instances", "abc/def", "id with space"} {
+		var verrs ValidationErrors
+		if _, err := service.Get(ctx, id); !errors.As(err, &verrs) {
+			t.Errorf("Get(%q): expected ValidationErrors, got %v", id, err)
+		}
+		if _, err := service.Delete(ctx, id); !errors.As(err, &verrs) {
+			t.Errorf("Delete(%q): expected ValidationErrors, got %v", id, err)
+		}
+	}
+	if mock.lastMethod != "" {
+		t.Errorf("expected no API call, got %s %s", mock.lastMethod, mock.lastPath)
+	}
+}
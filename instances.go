@@ -100,6 +100,10 @@ func (c CreateInstanceData) String() string {
 type UpdateInstanceData struct {
 	Name   string `json:"name,omitempty"`
 	Memory string `json:"memory,omitempty"`
+	// SecondariesCount sets the number of secondaries; nil leaves it unchanged.
+	SecondariesCount *int `json:"secondaries_count,omitempty"`
+	// CDCEnrichmentMode sets the change data capture mode: OFF, DIFF or FULL.
+	CDCEnrichmentMode string `json:"cdc_enrichment_mode,omitempty"`
 }
 
 // GetInstanceResponse wraps the response for a single instance lookup.
@@ -150,6 +154,7 @@ type instanceService struct {
 	api     api.RequestService
 	timeout time.Duration
 	logger  *slog.Logger
+	watch   watchConfig
 }
 
 // List returns all instances accessible to the authenticated user.
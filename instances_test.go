@@ -721,6 +721,10 @@ func (m *mockAPIServiceContextCheck) Get(ctx context.Context, _ string) (*api.Re
 	return m.response, m.err
 }
 
+func (m *mockAPIServiceContextCheck) GetWithHeaders(ctx context.Context, endpoint string, _ map[string]string) (*api.Response, error) {
+	return m.Get(ctx, endpoint)
+}
+
 func (m *mockAPIServiceContextCheck) Post(ctx context.Context, _ string, _ string) (*api.Response, error) {
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
@@ -33,6 +33,8 @@ type InstanceService interface {
 	OverwriteFromInstance(ctx context.Context, instanceID string, sourceInstanceID string) (*OverwriteInstanceResponse, error)
 	// Overwrite replaces instance data from another instance or snapshot
 	OverwriteFromSnapshot(ctx context.Context, instanceID string, sourceSnapshotID string) (*OverwriteInstanceResponse, error)
+	// Watch polls instances, all of them when no IDs are given, and sends their changes until ctx is done
+	Watch(ctx context.Context, instanceIDs ...string) (<-chan InstanceEvent, error)
 }
 
 // SnapshotService defines operations for managing instance snapshots
@@ -65,16 +67,25 @@ type GDSSessionService interface {
 	Get(ctx context.Context, GDSSessionID string) (*GetGDSSessionResponse, error)
 	// Delete a single GDS Session
 	Delete(ctx context.Context, GDSSessionID string) (*DeleteGDSSessionResponse, error)
+	// CreateSized estimates the size of a GDS session and creates it with the recommended memory
+	CreateSized(ctx context.Context, estimateRequest *GetGDSSessionSizeEstimation, createRequest *CreateGDSSessionConfigData, opts ...GDSSessionSizingOption) (*CreateSizedGDSSessionResponse, error)
+	// Watch polls GDS sessions, all of them when no IDs are given, and sends their changes until ctx is done
+	Watch(ctx context.Context, sessionIDs ...string) (<-chan GDSSessionEvent, error)
 }
 
 // PrometheusService defines operations for querying Prometheus metrics
 type PrometheusService interface {
-	// FetchRawMetrics fetches and parses raw Prometheus metrics from an Aura metrics endpoint
-	FetchRawMetrics(ctx context.Context, prometheusURL string) (*PrometheusMetricsResponse, error)
+	// FetchRawMetrics fetches and parses raw Prometheus metrics from an Aura metrics endpoint,
+	// negotiating text, OpenMetrics or protobuf exposition
+	FetchRawMetrics(ctx context.Context, prometheusURL string, opts ...FetchOption) (*PrometheusMetricsResponse, error)
 	// GetMetricValue retrieves a specific metric value by name and optional label filters
 	GetMetricValue(ctx context.Context, metrics *PrometheusMetricsResponse, name string, labelFilters map[string]string) (float64, error)
-	// GetInstanceHealth retrieves comprehensive health metrics for an instance
-	GetInstanceHealth(ctx context.Context, instanceID string, prometheusURL string) (*PrometheusHealthMetrics, error)
+	// Query evaluates a PromQL-style selector or aggregation against parsed metrics
+	Query(ctx context.Context, metrics *PrometheusMetricsResponse, query string) ([]MetricSample, error)
+	// GetInstanceHealth retrieves comprehensive health metrics for an instance, assessed with optional health rules
+	GetInstanceHealth(ctx context.Context, instanceID string, prometheusURL string, opts ...HealthOption) (*PrometheusHealthMetrics, error)
+	// InstanceHealthFromMetrics computes health metrics for an instance from an already fetched scrape
+	InstanceHealthFromMetrics(ctx context.Context, instanceID string, metrics *PrometheusMetricsResponse, opts ...HealthOption) (*PrometheusHealthMetrics, error)
 }
 
 // Compile-time interface compliance checks
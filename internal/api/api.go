@@ -63,7 +63,7 @@ func (e *Error) IsBadRequest() bool {
 // transport layer internally — callers do not need to know about or create an
 // httpclient.
 func NewRequestService(cfg Config, logger *slog.Logger) RequestService {
-	httpSvc := httpclient.NewHTTPService(cfg.Timeout, cfg.MaxRetry, logger)
+	httpSvc := httpclient.NewHTTPService(cfg.Timeout, cfg.MaxRetry, logger, cfg.Transport)
 
 	userAgent := cfg.UserAgent
 	if userAgent == "" {
@@ -86,33 +86,39 @@ func NewRequestService(cfg Config, logger *slog.Logger) RequestService {
 
 // Get performs an authenticated GET request.
 func (s *apiRequestService) Get(ctx context.Context, endpoint string) (*Response, error) {
-	return s.doAuthenticatedRequest(ctx, http.MethodGet, endpoint, "")
+	return s.doAuthenticatedRequest(ctx, http.MethodGet, endpoint, "", nil)
+}
+
+// GetWithHeaders performs an authenticated GET request with extra headers.
+func (s *apiRequestService) GetWithHeaders(ctx context.Context, endpoint string, headers map[string]string) (*Response, error) {
+	return s.doAuthenticatedRequest(ctx, http.MethodGet, endpoint, "", headers)
 }
 
 // Post performs an authenticated POST request.
 func (s *apiRequestService) Post(ctx context.Context, endpoint string, body string) (*Response, error) {
-	return s.doAuthenticatedRequest(ctx, http.MethodPost, endpoint, body)
+	return s.doAuthenticatedRequest(ctx, http.MethodPost, endpoint, body, nil)
 }
 
 // Put performs an authenticated PUT request.
 func (s *apiRequestService) Put(ctx context.Context, endpoint string, body string) (*Response, error) {
-	return s.doAuthenticatedRequest(ctx, http.MethodPut, endpoint, body)
+	return s.doAuthenticatedRequest(ctx, http.MethodPut, endpoint, body, nil)
 }
 
 // Patch performs an authenticated PATCH request.
 func (s *apiRequestService) Patch(ctx context.Context, endpoint string, body string) (*Response, error) {
-	return s.doAuthenticatedRequest(ctx, http.MethodPatch, endpoint, body)
+	return s.doAuthenticatedRequest(ctx, http.MethodPatch, endpoint, body, nil)
 }
 
 // Delete performs an authenticated DELETE request.
 func (s *apiRequestService) Delete(ctx context.Context, endpoint string) (*Response, error) {
-	return s.doAuthenticatedRequest(ctx, http.MethodDelete, endpoint, "")
+	return s.doAuthenticatedRequest(ctx, http.MethodDelete, endpoint, "", nil)
 }
 
 // doAuthenticatedRequest handles the common pattern of making an authenticated
 // API request. It trusts the deadline already set on ctx by the calling service
-// layer — no additional timeout is applied here.
-func (s *apiRequestService) doAuthenticatedRequest(ctx context.Context, method, endpoint, body string) (*Response, error) {
+// layer — no additional timeout is applied here. extraHeaders are sent in
+// addition to the defaults, which take precedence.
+func (s *apiRequestService) doAuthenticatedRequest(ctx context.Context, method, endpoint, body string, extraHeaders map[string]string) (*Response, error) {
 	if err := ctx.Err(); err != nil {
 		s.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
 		return nil, err
@@ -135,11 +141,13 @@ func (s *apiRequestService) doAuthenticatedRequest(ctx context.Context, method,
 		return nil, err
 	}
 
-	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"User-Agent":    s.userAgent,
-		"Authorization": tokenType + " " + token,
+	headers := make(map[string]string, len(extraHeaders)+3)
+	for k, v := range extraHeaders {
+		headers[http.CanonicalHeaderKey(k)] = v
 	}
+	headers["Content-Type"] = "application/json"
+	headers["User-Agent"] = s.userAgent
+	headers["Authorization"] = tokenType + " " + token
 
 	s.logger.DebugContext(ctx, "making authenticated API request",
 		slog.String("method", method),
@@ -192,6 +200,7 @@ func (s *apiRequestService) doAuthenticatedRequest(ctx context.Context, method,
 	return &Response{
 		StatusCode: resp.StatusCode,
 		Body:       resp.Body,
+		Header:     resp.Headers,
 	}, nil
 }
 
@@ -268,6 +268,26 @@ func TestAPIService_Headers_UserAgent(t *testing.T) {
 	}
 }
 
+func TestAPIService_GetWithHeaders(t *testing.T) {
+	mock := testutil.NewMockHTTPService()
+	mock.WithResponse(http.StatusOK, `metric 1`)
+	svc := newTestServiceWithToken(mock)
+
+	_, err := svc.GetWithHeaders(context.Background(), "https://metrics.example.com", map[string]string{
+		"accept":        "application/openmetrics-text",
+		"Authorization": "Bearer spoofed",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.LastHeaders["Accept"] != "application/openmetrics-text" {
+		t.Errorf("expected Accept header to be sent, got '%s'", mock.LastHeaders["Accept"])
+	}
+	if mock.LastHeaders["Authorization"] != "Bearer test-access-token" {
+		t.Errorf("expected extra headers not to replace Authorization, got '%s'", mock.LastHeaders["Authorization"])
+	}
+}
+
 func TestAPIService_Headers_AuthorizationFormat(t *testing.T) {
 	mock := testutil.NewMockHTTPService()
 	mock.WithResponse(http.StatusOK, `{"data":[]}`)
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"log/slog"
+	"net/http"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 type Response struct {
 	StatusCode int
 	Body       []byte
+	Header     http.Header
 }
 
 // Error represents an error response from the Aura API.
@@ -37,7 +39,8 @@ type Config struct {
 	APIVersion   string
 	Timeout      time.Duration
 	MaxRetry     int
-	UserAgent    string // e.g. "aura-go-client/v1.8.0"; defaults to "aura-go-client" if empty
+	UserAgent    string            // e.g. "aura-go-client/v1.8.0"; defaults to "aura-go-client" if empty
+	Transport    http.RoundTripper // optional; nil selects the default pooled transport
 }
 
 // apiRequestService is the concrete implementation of RequestService.
@@ -72,6 +75,9 @@ type tokenResponse struct {
 // This is the middle layer that handles authentication and common API patterns.
 type RequestService interface {
 	Get(ctx context.Context, endpoint string) (*Response, error)
+	// GetWithHeaders is Get with additional request headers, such as Accept.
+	// They cannot replace the Authorization or User-Agent headers.
+	GetWithHeaders(ctx context.Context, endpoint string, headers map[string]string) (*Response, error)
 	Post(ctx context.Context, endpoint string, body string) (*Response, error)
 	Put(ctx context.Context, endpoint string, body string) (*Response, error)
 	Patch(ctx context.Context, endpoint string, body string) (*Response, error)
@@ -35,8 +35,9 @@ func networkOnlyRetryPolicy(ctx context.Context, resp *http.Response, err error)
 // NewHTTPService creates a new HTTPService backed by a retryable HTTP client.
 // Retries are attempted only on network-level errors (no response received);
 // HTTP error responses (including 5xx) are always returned to the caller.
-// The caller-supplied logger is used for debug output.
-func NewHTTPService(timeout time.Duration, maxRetry int, logger *slog.Logger) HTTPService {
+// The caller-supplied logger is used for debug output. A nil transport selects
+// the pooled default transport below.
+func NewHTTPService(timeout time.Duration, maxRetry int, logger *slog.Logger, transport http.RoundTripper) HTTPService {
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = maxRetry
 	retryClient.RetryWaitMin = 1 * time.Second
@@ -49,15 +50,18 @@ func NewHTTPService(timeout time.Duration, maxRetry int, logger *slog.Logger) HT
 	// causes connection exhaustion under concurrent load since all requests go
 	// to the same host. These values are sized for a typical management-plane
 	// workload; tune MaxIdleConnsPerHost upward if you issue many parallel calls.
-	retryClient.HTTPClient = &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
+	if transport == nil {
+		transport = &http.Transport{
 			MaxIdleConns:          100,
 			MaxIdleConnsPerHost:   20,
 			IdleConnTimeout:       90 * time.Second,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
-		},
+		}
+	}
+	retryClient.HTTPClient = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
 	}
 
 	return &httpService{
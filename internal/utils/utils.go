@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -85,6 +87,102 @@ func ValidateInstanceID(instanceID string) error {
 	return nil
 }
 
+// memoryRegex matches a whole number of gigabytes such as "8GB" or "16gb".
+var memoryRegex = regexp.MustCompile(`(?i)^([0-9]+)\s*GB$`)
+
+// ParseMemoryGB returns the number of gigabytes in a memory size string such
+// as "8GB". The Aura API expresses instance and GDS session memory in whole
+// gigabytes, so fractional or differently-unit values are rejected.
+func ParseMemoryGB(memory string) (int, error) {
+	m := memoryRegex.FindStringSubmatch(strings.TrimSpace(memory))
+	if m == nil {
+		return 0, fmt.Errorf("memory must be a whole number of gigabytes (e.g. 8GB), got %q", memory)
+	}
+	gb, err := strconv.Atoi(m[1])
+	if err != nil || gb <= 0 {
+		return 0, fmt.Errorf("memory must be a positive number of gigabytes, got %q", memory)
+	}
+	return gb, nil
+}
+
+// FormatMemoryGB returns gb in the "NGB" form used by the Aura API.
+func FormatMemoryGB(gb int) string {
+	return strconv.Itoa(gb) + "GB"
+}
+
+// gdsSessionIDRegex matches the characters the Aura API uses in GDS session
+// IDs: letters, digits and hyphens. Anything else (slashes, spaces, query
+// characters) would change the meaning of the request path.
+var gdsSessionIDRegex = regexp.MustCompile(`^[0-9a-zA-Z][0-9a-zA-Z-]{0,63}$`)
+
+// ValidateGDSSessionID returns an error if sessionID is empty or contains
+// characters other than letters, digits and hyphens.
+func ValidateGDSSessionID(sessionID string) error {
+	if sessionID == "" {
+		return fmt.Errorf("GDS session ID must not be empty")
+	}
+	if !gdsSessionIDRegex.MatchString(sessionID) {
+		return fmt.Errorf("GDS session ID must contain only letters, digits and hyphens (at most 64 characters)")
+	}
+	return nil
+}
+
+// ValidateUUID returns an error naming field if value is not a valid UUID.
+func ValidateUUID(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	if !uuidRegex.MatchString(value) {
+		return fmt.Errorf("%s must be a valid UUID format (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx)", field)
+	}
+	return nil
+}
+
+// isoDurationRegex matches the ISO-8601 durations accepted by ParseTTL. Years
+// and months are excluded because their length is not fixed.
+var isoDurationRegex = regexp.MustCompile(
+	`^P(?:([0-9]+)W)?(?:([0-9]+)D)?(?:T(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9]+(?:\.[0-9]+)?)S)?)?$`,
+)
+
+// ParseTTL parses a session time-to-live expressed either as a Go duration
+// ("90m", "2h30m") or as an ISO-8601 duration ("PT2H", "P1DT12H"). The result
+// must be greater than zero.
+func ParseTTL(ttl string) (time.Duration, error) {
+	if ttl == "" {
+		return 0, fmt.Errorf("TTL must not be empty")
+	}
+
+	var d time.Duration
+	if strings.HasPrefix(ttl, "P") {
+		m := isoDurationRegex.FindStringSubmatch(ttl)
+		if m == nil || ttl == "P" || strings.HasSuffix(ttl, "T") {
+			return 0, fmt.Errorf("TTL %q is not a valid ISO-8601 duration (e.g. PT2H or P1DT12H)", ttl)
+		}
+		units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute}
+		for i, unit := range units {
+			if m[i+1] != "" {
+				n, _ := strconv.Atoi(m[i+1])
+				d += time.Duration(n) * unit
+			}
+		}
+		if m[5] != "" {
+			secs, _ := strconv.ParseFloat(m[5], 64)
+			d += time.Duration(secs * float64(time.Second))
+		}
+	} else {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			return 0, fmt.Errorf("TTL %q is neither a Go duration (e.g. 2h30m) nor an ISO-8601 duration (e.g. PT2H30M)", ttl)
+		}
+		d = parsed
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("TTL must be greater than zero, got %q", ttl)
+	}
+	return d, nil
+}
+
 // TruncateString returns the first n runes of s. If s contains n or fewer
 // runes it is returned unchanged. Using rune counts rather than byte offsets
 // ensures that multibyte UTF-8 characters are never split.
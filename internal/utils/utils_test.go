@@ -3,7 +3,9 @@ package utils
 import (
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestBase64Encode verifies base64 encoding for Basic Auth
@@ -369,6 +371,101 @@ func TestTruncateString(t *testing.T) {
 	}
 }
 
+// TestParseMemoryGB verifies parsing of whole-gigabyte memory strings.
+func TestParseMemoryGB(t *testing.T) {
+	tests := []struct {
+		name    string
+		memory  string
+		want    int
+		wantErr bool
+	}{
+		{"simple", "8GB", 8, false},
+		{"lower case unit", "16gb", 16, false},
+		{"space before unit", "32 GB", 32, false},
+		{"empty", "", 0, true},
+		{"missing unit", "8", 0, true},
+		{"wrong unit", "8MB", 0, true},
+		{"fractional", "1.5GB", 0, true},
+		{"zero", "0GB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMemoryGB(tt.memory)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMemoryGB(%q) error = %v, wantErr %v", tt.memory, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMemoryGB(%q) = %d, want %d", tt.memory, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateGDSSessionID verifies GDS session ID validation
+func TestValidateGDSSessionID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{"hex with hyphen", "a1b2c3d4-e5f6a7b8", false},
+		{"simple", "session1", false},
+		{"empty", "", true},
+		{"path traversal", "../instances", true},
+		{"slash", "abc/def", true},
+		{"query", "abc?x=1", true},
+		{"space", "abc def", true},
+		{"leading hyphen", "-abc", true},
+		{"too long", strings.Repeat("a", 65), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGDSSessionID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateGDSSessionID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParseTTL verifies Go and ISO-8601 duration parsing
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"go hours", "2h", 2 * time.Hour, false},
+		{"go mixed", "1h30m", 90 * time.Minute, false},
+		{"iso hours", "PT2H", 2 * time.Hour, false},
+		{"iso days and hours", "P1DT12H", 36 * time.Hour, false},
+		{"iso weeks", "P1W", 7 * 24 * time.Hour, false},
+		{"iso fractional seconds", "PT1.5S", 1500 * time.Millisecond, false},
+		{"empty", "", 0, true},
+		{"bare P", "P", 0, true},
+		{"trailing T", "P1DT", 0, true},
+		{"iso months rejected", "P1M", 0, true},
+		{"zero", "0s", 0, true},
+		{"negative", "-1h", 0, true},
+		{"garbage", "tomorrow", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTTL(tt.ttl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTTL(%q) error = %v, wantErr %v", tt.ttl, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTTL(%q) = %v, want %v", tt.ttl, got, tt.want)
+			}
+		})
+	}
+}
+
 // Helper function for string contains check
 func contains(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
@@ -0,0 +1,542 @@
+package aura
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Inventory defaults used when InventoryOptions fields are not set.
+const (
+	defaultInventoryConcurrency = 4
+	defaultSnapshotLookbackDays = 7
+)
+
+// InventoryOptions controls an Inventory run.
+type InventoryOptions struct {
+	// TenantIDs limits the inventory to these tenants. Empty means all.
+	TenantIDs []string
+	// Concurrency limits the number of API calls made in parallel. Defaults to 4.
+	Concurrency int
+	// SnapshotLookbackDays is the number of days, counting back from today,
+	// searched for each instance's latest snapshot. Defaults to 7.
+	SnapshotLookbackDays int
+}
+
+// Inventory lists every Aura resource of the selected tenants. Resources
+// refer to each other by ID: instances, CMEKs and GDS sessions carry a
+// tenant ID, and GDS sessions an instance ID. Each tenant and instance also
+// lists the IDs of the resources that belong to it. Every list is sorted by
+// name, then ID.
+type Inventory struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Tenants     []InventoryTenant   `json:"tenants"`
+	Instances   []InventoryInstance `json:"instances"`
+	CMEKs       []GetCmeksData      `json:"cmeks"`
+	GDSSessions []GetGDSSessionData `json:"gds_sessions"`
+	// Errors records each call that failed. The resources it would have
+	// returned are missing or, for an instance, hold only the listed fields.
+	Errors []InventoryError `json:"errors"`
+}
+
+// InventoryTenant is a tenant and the IDs of its resources.
+type InventoryTenant struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	InstanceIDs   []string `json:"instance_ids"`
+	CMEKIDs       []string `json:"cmek_ids"`
+	GDSSessionIDs []string `json:"gds_session_ids"`
+}
+
+// InventoryInstance is an instance with its latest snapshot and the IDs of
+// the GDS sessions attached to it.
+type InventoryInstance struct {
+	InstanceData
+	// LatestSnapshot is nil when no snapshot was taken within
+	// InventoryOptions.SnapshotLookbackDays.
+	LatestSnapshot *GetSnapshotData `json:"latest_snapshot,omitempty"`
+	GDSSessionIDs  []string         `json:"gds_session_ids"`
+}
+
+// InventoryError records one failed call. ID is the tenant, instance or
+// session concerned, and empty for a failed list of every resource.
+type InventoryError struct {
+	Resource string `json:"resource"`
+	ID       string `json:"id,omitempty"`
+	Error    string `json:"error"`
+}
+
+// Resource types in InventoryError.Resource and InventoryRow.Type.
+const (
+	InventoryTenantResource     = "tenant"
+	InventoryInstanceResource   = "instance"
+	InventorySnapshotResource   = "snapshot"
+	InventoryCMEKResource       = "cmek"
+	InventoryGDSSessionResource = "gds_session"
+)
+
+// InventoryRow is one resource of an Inventory flattened for a spreadsheet,
+// with the names of the tenant and instance it belongs to.
+type InventoryRow struct {
+	Type           string `json:"type"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	TenantID       string `json:"tenant_id"`
+	TenantName     string `json:"tenant_name"`
+	InstanceID     string `json:"instance_id"`
+	InstanceName   string `json:"instance_name"`
+	Status         string `json:"status"`
+	CloudProvider  string `json:"cloud_provider"`
+	Region         string `json:"region"`
+	InstanceType   string `json:"instance_type"`
+	Memory         string `json:"memory"`
+	LatestSnapshot string `json:"latest_snapshot"`
+}
+
+// ============================================================================
+// Building
+// ============================================================================
+
+// Inventory walks every tenant's instances, their latest snapshots, CMEKs and
+// GDS sessions, making up to opts.Concurrency calls at once. An error is
+// returned only when the options are invalid or the tenants cannot be listed;
+// any other failed call is recorded in Inventory.Errors.
+func (c *AuraAPIClient) Inventory(ctx context.Context, opts InventoryOptions) (*Inventory, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative")
+	}
+	if concurrency == 0 {
+		concurrency = defaultInventoryConcurrency
+	}
+	lookback := opts.SnapshotLookbackDays
+	if lookback < 0 {
+		return nil, fmt.Errorf("snapshot lookback days must not be negative")
+	}
+	if lookback == 0 {
+		lookback = defaultSnapshotLookbackDays
+	}
+
+	tenants, err := c.Tenants.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	b := &inventoryBuilder{
+		c:        c,
+		inv:      &Inventory{GeneratedAt: time.Now().UTC()},
+		sem:      make(chan struct{}, concurrency),
+		lookback: lookback,
+		tenants:  make(map[string]bool),
+	}
+	for _, t := range tenants.Data {
+		if len(opts.TenantIDs) == 0 || slices.Contains(opts.TenantIDs, t.ID) {
+			b.tenants[t.ID] = true
+			b.inv.Tenants = append(b.inv.Tenants, InventoryTenant{ID: t.ID, Name: t.Name})
+		}
+	}
+
+	c.logger.InfoContext(ctx, "building inventory", slog.Int("tenants", len(b.inv.Tenants)))
+
+	b.run(ctx, b.instances)
+	b.run(ctx, b.gdsSessions)
+	for _, t := range b.inv.Tenants {
+		b.run(ctx, func(ctx context.Context) { b.cmeks(ctx, t.ID) })
+	}
+	b.wg.Wait()
+
+	b.inv.link()
+
+	c.logger.InfoContext(ctx, "inventory complete",
+		slog.Int("instances", len(b.inv.Instances)),
+		slog.Int("cmeks", len(b.inv.CMEKs)),
+		slog.Int("gds_sessions", len(b.inv.GDSSessions)),
+		slog.Int("errors", len(b.inv.Errors)))
+
+	return b.inv, nil
+}
+
+// inventoryBuilder fills an Inventory from concurrent calls.
+type inventoryBuilder struct {
+	c        *AuraAPIClient
+	inv      *Inventory
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex // guards inv
+	lookback int
+	tenants  map[string]bool
+}
+
+// run starts task once a slot is free. Tasks may start further tasks. Once
+// ctx is done the remaining tasks still run, so that their failed calls are
+// recorded.
+func (b *inventoryBuilder) run(ctx context.Context, task func(ctx context.Context)) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.sem <- struct{}{}
+		defer func() { <-b.sem }()
+		task(ctx)
+	}()
+}
+
+// fail records a failed call.
+func (b *inventoryBuilder) fail(ctx context.Context, resource, id string, err error) {
+	b.c.logger.WarnContext(ctx, "inventory call failed",
+		slog.String("resource", resource),
+		slog.String("id", id),
+		slog.String("error", err.Error()))
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inv.Errors = append(b.inv.Errors, InventoryError{Resource: resource, ID: id, Error: err.Error()})
+}
+
+// instances lists the instances and starts a task per instance for its
+// details and latest snapshot.
+func (b *inventoryBuilder) instances(ctx context.Context) {
+	list, err := b.c.Instances.List(ctx)
+	if err != nil {
+		b.fail(ctx, InventoryInstanceResource, "", fmt.Errorf("failed to list instances: %w", err))
+		return
+	}
+	for _, inst := range list.Data {
+		if !b.tenants[inst.TenantID] {
+			continue
+		}
+		b.run(ctx, func(ctx context.Context) { b.instance(ctx, inst) })
+	}
+}
+
+// instance fetches one instance and its latest snapshot. When the details
+// cannot be fetched the listed fields are kept.
+func (b *inventoryBuilder) instance(ctx context.Context, listed ListInstanceData) {
+	result := InventoryInstance{InstanceData: InstanceData{
+		ID: listed.ID, Name: listed.Name, TenantID: listed.TenantID, CloudProvider: listed.CloudProvider,
+	}}
+	if resp, err := b.c.Instances.Get(ctx, listed.ID); err != nil {
+		b.fail(ctx, InventoryInstanceResource, listed.ID, fmt.Errorf("failed to get instance: %w", err))
+	} else {
+		result.InstanceData = resp.Data
+	}
+
+	snapshot, err := b.latestSnapshot(ctx, listed.ID)
+	if err != nil {
+		b.fail(ctx, InventorySnapshotResource, listed.ID, fmt.Errorf("failed to list snapshots: %w", err))
+	}
+	result.LatestSnapshot = snapshot
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inv.Instances = append(b.inv.Instances, result)
+}
+
+// latestSnapshot searches back from today, one day at a time, for the most
+// recent snapshot of an instance.
+func (b *inventoryBuilder) latestSnapshot(ctx context.Context, instanceID string) (*GetSnapshotData, error) {
+	day := b.inv.GeneratedAt
+	for range b.lookback {
+		resp, err := b.c.Snapshots.List(ctx, instanceID, &SnapshotDate{Year: day.Year(), Month: day.Month(), Day: day.Day()})
+		if err != nil {
+			return nil, err
+		}
+		var latest *GetSnapshotData
+		for i, snap := range resp.Data {
+			if latest == nil || snap.Timestamp.After(latest.Timestamp) {
+				latest = &resp.Data[i]
+			}
+		}
+		if latest != nil {
+			return latest, nil
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+	return nil, nil
+}
+
+// cmeks lists the customer-managed keys of one tenant.
+func (b *inventoryBuilder) cmeks(ctx context.Context, tenantID string) {
+	list, err := b.c.Cmek.List(ctx, tenantID)
+	if err != nil {
+		b.fail(ctx, InventoryCMEKResource, tenantID, fmt.Errorf("failed to list customer-managed keys: %w", err))
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range list.Data {
+		if key.TenantID == "" {
+			key.TenantID = tenantID
+		}
+		b.inv.CMEKs = append(b.inv.CMEKs, key)
+	}
+}
+
+// gdsSessions lists the GDS sessions of the selected tenants.
+func (b *inventoryBuilder) gdsSessions(ctx context.Context) {
+	list, err := b.c.GraphAnalytics.List(ctx)
+	if err != nil {
+		b.fail(ctx, InventoryGDSSessionResource, "", fmt.Errorf("failed to list GDS sessions: %w", err))
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, session := range list.Data {
+		if b.tenants[session.TenantID] {
+			b.inv.GDSSessions = append(b.inv.GDSSessions, session)
+		}
+	}
+}
+
+// link sorts the resources and fills in the ID lists of tenants and
+// instances.
+func (inv *Inventory) link() {
+	sort.Slice(inv.Tenants, func(i, j int) bool {
+		return byNameThenID(inv.Tenants[i].Name, inv.Tenants[i].ID, inv.Tenants[j].Name, inv.Tenants[j].ID)
+	})
+	sort.Slice(inv.Instances, func(i, j int) bool {
+		return byNameThenID(inv.Instances[i].Name, inv.Instances[i].ID, inv.Instances[j].Name, inv.Instances[j].ID)
+	})
+	sort.Slice(inv.CMEKs, func(i, j int) bool {
+		return byNameThenID(inv.CMEKs[i].Name, inv.CMEKs[i].ID, inv.CMEKs[j].Name, inv.CMEKs[j].ID)
+	})
+	sort.Slice(inv.GDSSessions, func(i, j int) bool {
+		return byNameThenID(inv.GDSSessions[i].Name, inv.GDSSessions[i].ID, inv.GDSSessions[j].Name, inv.GDSSessions[j].ID)
+	})
+	sort.SliceStable(inv.Errors, func(i, j int) bool {
+		if inv.Errors[i].Resource != inv.Errors[j].Resource {
+			return inv.Errors[i].Resource < inv.Errors[j].Resource
+		}
+		return inv.Errors[i].ID < inv.Errors[j].ID
+	})
+
+	tenants := make(map[string]*InventoryTenant, len(inv.Tenants))
+	for i := range inv.Tenants {
+		t := &inv.Tenants[i]
+		t.InstanceIDs, t.CMEKIDs, t.GDSSessionIDs = []string{}, []string{}, []string{}
+		tenants[t.ID] = t
+	}
+	instances := make(map[string]*InventoryInstance, len(inv.Instances))
+	for i := range inv.Instances {
+		inst := &inv.Instances[i]
+		inst.GDSSessionIDs = []string{}
+		instances[inst.ID] = inst
+		if t := tenants[inst.TenantID]; t != nil {
+			t.InstanceIDs = append(t.InstanceIDs, inst.ID)
+		}
+	}
+	for _, key := range inv.CMEKs {
+		if t := tenants[key.TenantID]; t != nil {
+			t.CMEKIDs = append(t.CMEKIDs, key.ID)
+		}
+	}
+	for _, session := range inv.GDSSessions {
+		if t := tenants[session.TenantID]; t != nil {
+			t.GDSSessionIDs = append(t.GDSSessionIDs, session.ID)
+		}
+		if inst := instances[session.InstanceID]; inst != nil {
+			inst.GDSSessionIDs = append(inst.GDSSessionIDs, session.ID)
+		}
+	}
+	if inv.Tenants == nil {
+		inv.Tenants = []InventoryTenant{}
+	}
+	if inv.Instances == nil {
+		inv.Instances = []InventoryInstance{}
+	}
+	if inv.CMEKs == nil {
+		inv.CMEKs = []GetCmeksData{}
+	}
+	if inv.GDSSessions == nil {
+		inv.GDSSessions = []GetGDSSessionData{}
+	}
+	if inv.Errors == nil {
+		inv.Errors = []InventoryError{}
+	}
+}
+
+func byNameThenID(nameA, idA, nameB, idB string) bool {
+	if nameA != nameB {
+		return nameA < nameB
+	}
+	return idA < idB
+}
+
+// ============================================================================
+// Export
+// ============================================================================
+
+// Rows flattens the inventory into one row per tenant, instance, CMEK and GDS
+// session, in that order.
+func (inv *Inventory) Rows() []InventoryRow {
+	tenantNames := make(map[string]string, len(inv.Tenants))
+	for _, t := range inv.Tenants {
+		tenantNames[t.ID] = t.Name
+	}
+	instanceNames := make(map[string]string, len(inv.Instances))
+	for _, inst := range inv.Instances {
+		instanceNames[inst.ID] = inst.Name
+	}
+
+	rows := []InventoryRow{}
+	for _, t := range inv.Tenants {
+		rows = append(rows, InventoryRow{Type: InventoryTenantResource, ID: t.ID, Name: t.Name, TenantID: t.ID, TenantName: t.Name})
+	}
+	for _, inst := range inv.Instances {
+		row := InventoryRow{
+			Type: InventoryInstanceResource, ID: inst.ID, Name: inst.Name,
+			TenantID: inst.TenantID, TenantName: tenantNames[inst.TenantID],
+			Status: string(inst.Status), CloudProvider: inst.CloudProvider, Region: inst.Region,
+			InstanceType: inst.Type, Memory: inst.Memory,
+		}
+		if inst.LatestSnapshot != nil {
+			row.LatestSnapshot = inst.LatestSnapshot.Timestamp.UTC().Format(time.RFC3339)
+		}
+		rows = append(rows, row)
+	}
+	for _, key := range inv.CMEKs {
+		rows = append(rows, InventoryRow{Type: InventoryCMEKResource, ID: key.ID, Name: key.Name,
+			TenantID: key.TenantID, TenantName: tenantNames[key.TenantID]})
+	}
+	for _, s := range inv.GDSSessions {
+		rows = append(rows, InventoryRow{
+			Type: InventoryGDSSessionResource, ID: s.ID, Name: s.Name,
+			TenantID: s.TenantID, TenantName: tenantNames[s.TenantID],
+			InstanceID: s.InstanceID, InstanceName: instanceNames[s.InstanceID],
+			Status: s.Status, CloudProvider: s.CloudProvider, Region: s.Region, Memory: s.Memory,
+		})
+	}
+	return rows
+}
+
+// inventoryColumns are the CSV and Markdown columns of an InventoryRow.
+var inventoryColumns = []struct {
+	name  string
+	value func(InventoryRow) string
+}{
+	{"type", func(r InventoryRow) string { return r.Type }},
+	{"id", func(r InventoryRow) string { return r.ID }},
+	{"name", func(r InventoryRow) string { return r.Name }},
+	{"tenant_id", func(r InventoryRow) string { return r.TenantID }},
+	{"tenant_name", func(r InventoryRow) string { return r.TenantName }},
+	{"instance_id", func(r InventoryRow) string { return r.InstanceID }},
+	{"instance_name", func(r InventoryRow) string { return r.InstanceName }},
+	{"status", func(r InventoryRow) string { return r.Status }},
+	{"cloud_provider", func(r InventoryRow) string { return r.CloudProvider }},
+	{"region", func(r InventoryRow) string { return r.Region }},
+	{"instance_type", func(r InventoryRow) string { return r.InstanceType }},
+	{"memory", func(r InventoryRow) string { return r.Memory }},
+	{"latest_snapshot", func(r InventoryRow) string { return r.LatestSnapshot }},
+}
+
+// WriteJSON writes the inventory as indented JSON.
+func (inv *Inventory) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inv)
+}
+
+// WriteCSV writes Rows as CSV with a header row. Errors are not included;
+// check Inventory.Errors before relying on the export being complete.
+func (inv *Inventory) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(inventoryColumns))
+	for i, col := range inventoryColumns {
+		record[i] = col.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for _, row := range inv.Rows() {
+		for i, col := range inventoryColumns {
+			record[i] = col.value(row)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes a report with a summary per tenant, a table of every
+// resource and, when any call failed, a table of errors.
+func (inv *Inventory) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Aura Inventory\n\nGenerated %s.\n\n## Tenants\n\n", inv.GeneratedAt.UTC().Format(time.RFC3339))
+	summary := [][]string{{"Tenant", "ID", "Instances", "CMEKs", "GDS sessions"}}
+	for _, t := range inv.Tenants {
+		summary = append(summary, []string{t.Name, t.ID,
+			strconv.Itoa(len(t.InstanceIDs)), strconv.Itoa(len(t.CMEKIDs)), strconv.Itoa(len(t.GDSSessionIDs))})
+	}
+	writeMarkdownTable(&sb, summary)
+
+	sb.WriteString("\n## Resources\n\n")
+	resources := [][]string{make([]string, len(inventoryColumns))}
+	for i, col := range inventoryColumns {
+		resources[0][i] = col.name
+	}
+	for _, row := range inv.Rows() {
+		cells := make([]string, len(inventoryColumns))
+		for i, col := range inventoryColumns {
+			cells[i] = col.value(row)
+		}
+		resources = append(resources, cells)
+	}
+	writeMarkdownTable(&sb, resources)
+
+	if len(inv.Errors) > 0 {
+		sb.WriteString("\n## Errors\n\nThese calls failed, so the inventory is incomplete.\n\n")
+		errs := [][]string{{"Resource", "ID", "Error"}}
+		for _, e := range inv.Errors {
+			errs = append(errs, []string{e.Resource, e.ID, e.Error})
+		}
+		writeMarkdownTable(&sb, errs)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMarkdownTable writes rows as a GitHub-flavoured Markdown table; the
+// first row is the header.
+func writeMarkdownTable(sb *strings.Builder, rows [][]string) {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ", "\r", "")
+	for i, row := range rows {
+		sb.WriteString("|")
+		for _, cell := range row {
+			sb.WriteString(" " + escape.Replace(cell) + " |")
+		}
+		sb.WriteString("\n")
+		if i == 0 {
+			sb.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+		}
+	}
+}
+
+// WriteInventory writes inv in format: "json", "csv" or "markdown" ("md").
+func WriteInventory(w io.Writer, inv *Inventory, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return inv.WriteJSON(w)
+	case "csv":
+		return inv.WriteCSV(w)
+	case "markdown", "md":
+		return inv.WriteMarkdown(w)
+	default:
+		return fmt.Errorf("inventory format must be json, csv or markdown, not %q", format)
+	}
+}
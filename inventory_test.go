@@ -0,0 +1,172 @@
+package aura_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auratest"
+)
+
+// seedInventoryServer starts a server with two tenants, an instance in each,
+// a CMEK, two days of snapshots and a GDS session attached to orders.
+func seedInventoryServer(t *testing.T) (*auratest.Server, aura.InstanceData, aura.InstanceData) {
+	t.Helper()
+	srv := auratest.NewServer()
+	srv.AddTenant(aura.TenantResponseData{ID: otherTenantID, Name: "other"})
+	orders := srv.AddInstance(aura.InstanceData{Name: "orders", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB"})
+	reports := srv.AddInstance(aura.InstanceData{Name: "reports", TenantID: otherTenantID, CloudProvider: "aws",
+		Region: "us-east-1", Type: "professional-db", Memory: "2GB"})
+
+	now := time.Now().UTC()
+	srv.AddSnapshot(aura.GetSnapshotData{InstanceID: orders.ID, SnapshotID: "old", Timestamp: now.Add(-time.Minute)})
+	srv.AddSnapshot(aura.GetSnapshotData{InstanceID: orders.ID, SnapshotID: "new", Timestamp: now})
+	srv.AddSnapshot(aura.GetSnapshotData{InstanceID: reports.ID, SnapshotID: "yesterday", Timestamp: now.AddDate(0, 0, -1)})
+	srv.AddCMEK(aura.GetCmeksData{ID: "key-1", Name: "orders-key", TenantID: auratest.DefaultTenantID})
+	srv.AddGDSSession(aura.GetGDSSessionData{ID: "gds-1", Name: "analysis", Memory: "8GB",
+		InstanceID: orders.ID, TenantID: auratest.DefaultTenantID})
+	return srv, orders, reports
+}
+
+func TestInventory(t *testing.T) {
+	srv, orders, reports := seedInventoryServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+
+	inv, err := client.Inventory(context.Background(), aura.InventoryOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(inv.Errors) != 0 {
+		t.Fatalf("Expected no errors, got %+v", inv.Errors)
+	}
+	if len(inv.Tenants) != 2 || inv.Tenants[0].Name != "auratest" {
+		t.Fatalf("Expected both tenants sorted by name, got %+v", inv.Tenants)
+	}
+	tenant := inv.Tenants[0]
+	if !slices.Equal(tenant.InstanceIDs, []string{orders.ID}) || !slices.Equal(tenant.CMEKIDs, []string{"key-1"}) ||
+		!slices.Equal(tenant.GDSSessionIDs, []string{"gds-1"}) {
+		t.Errorf("Expected the tenant to reference its resources, got %+v", tenant)
+	}
+
+	if len(inv.Instances) != 2 {
+		t.Fatalf("Expected 2 instances, got %+v", inv.Instances)
+	}
+	first, second := inv.Instances[0], inv.Instances[1]
+	if first.ID != orders.ID || first.Region != "europe-west1" || first.LatestSnapshot == nil || first.LatestSnapshot.SnapshotID != "new" ||
+		!slices.Equal(first.GDSSessionIDs, []string{"gds-1"}) {
+		t.Errorf("Expected orders with its latest snapshot and session, got %+v", first)
+	}
+	if second.ID != reports.ID || second.LatestSnapshot == nil || second.LatestSnapshot.SnapshotID != "yesterday" {
+		t.Errorf("Expected reports with yesterday's snapshot, got %+v", second)
+	}
+
+	inv, err = client.Inventory(context.Background(), aura.InventoryOptions{TenantIDs: []string{otherTenantID}, SnapshotLookbackDays: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(inv.Tenants) != 1 || len(inv.Instances) != 1 || len(inv.CMEKs) != 0 || len(inv.GDSSessions) != 0 {
+		t.Errorf("Expected only the other tenant's resources, got %+v", inv)
+	}
+	if inv.Instances[0].LatestSnapshot != nil {
+		t.Errorf("Expected no snapshot within a day, got %+v", inv.Instances[0].LatestSnapshot)
+	}
+}
+
+func TestInventory_RecordsPartialFailures(t *testing.T) {
+	srv, orders, _ := seedInventoryServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	srv.InjectFault(auratest.FaultRule{Method: http.MethodGet, Path: "/v1/instances/" + orders.ID, Fault: auratest.Status(http.StatusForbidden)})
+	srv.InjectFault(auratest.FaultRule{Path: "/v1/graph-analytics/sessions", Fault: auratest.Status(http.StatusForbidden)})
+
+	inv, err := client.Inventory(context.Background(), aura.InventoryOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(inv.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %+v", inv.Errors)
+	}
+	if e := inv.Errors[0]; e.Resource != aura.InventoryGDSSessionResource || e.ID != "" {
+		t.Errorf("Expected the session list to fail, got %+v", e)
+	}
+	if e := inv.Errors[1]; e.Resource != aura.InventoryInstanceResource || e.ID != orders.ID {
+		t.Errorf("Expected orders to fail, got %+v", e)
+	}
+	if first := inv.Instances[0]; first.ID != orders.ID || first.Name != "orders" || first.Region != "" || first.LatestSnapshot == nil {
+		t.Errorf("Expected orders with its listed fields and snapshot, got %+v", first)
+	}
+	if len(inv.GDSSessions) != 0 || len(inv.CMEKs) != 1 {
+		t.Errorf("Expected the CMEKs despite the session failure, got %+v", inv)
+	}
+
+	srv.InjectFault(auratest.FaultRule{Path: "/v1/tenants", Fault: auratest.Status(http.StatusForbidden)})
+	if _, err := client.Inventory(context.Background(), aura.InventoryOptions{}); err == nil {
+		t.Error("Expected an error when the tenants cannot be listed")
+	}
+}
+
+func TestInventory_Export(t *testing.T) {
+	srv, orders, _ := seedInventoryServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	inv, err := client.Inventory(context.Background(), aura.InventoryOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := aura.WriteInventory(&buf, inv, "csv"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v", err)
+	}
+	// Header, 2 tenants, 2 instances, 1 CMEK and 1 session.
+	if len(records) != 7 || records[0][0] != "type" {
+		t.Fatalf("Expected a header and 6 rows, got %v", records)
+	}
+	session := records[6]
+	if session[0] != "gds_session" || session[4] != "auratest" || session[5] != orders.ID || session[6] != "orders" {
+		t.Errorf("Expected the session row to name its tenant and instance, got %v", session)
+	}
+
+	buf.Reset()
+	if err := aura.WriteInventory(&buf, inv, "json"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded aura.Inventory
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Instances) != 2 || decoded.Instances[0].Memory != "8GB" {
+		t.Errorf("Expected the inventory to round-trip through JSON, got %+v, %v", decoded, err)
+	}
+
+	inv.Errors = append(inv.Errors, aura.InventoryError{Resource: "cmek", ID: otherTenantID, Error: "a | b"})
+	buf.Reset()
+	if err := aura.WriteInventory(&buf, inv, "markdown"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	md := buf.String()
+	for _, want := range []string{
+		"| auratest | " + auratest.DefaultTenantID + " | 1 | 1 | 1 |",
+		"| instance | " + orders.ID + " | orders |",
+		"## Errors",
+		`a \| b`,
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in\n%s", want, md)
+		}
+	}
+
+	if err := aura.WriteInventory(&buf, inv, "xlsx"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,194 @@
+package aura
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// GDSSessionReapReason identifies which policy selected a GDS session for removal.
+type GDSSessionReapReason string
+
+// Reasons reported by ReapGDSSessions.
+const (
+	ReapReasonAge      GDSSessionReapReason = "age"
+	ReapReasonExpired  GDSSessionReapReason = "expired"
+	ReapReasonOrphaned GDSSessionReapReason = "orphaned"
+	ReapReasonName     GDSSessionReapReason = "name"
+)
+
+// defaultReapConcurrency is the number of parallel deletes used when
+// GDSSessionReapPolicy.Concurrency is not set.
+const defaultReapConcurrency = 4
+
+// GDSSessionReapPolicy describes which GDS sessions ReapGDSSessions removes.
+// A session is selected when it matches any of the enabled policies; at least
+// one policy must be enabled.
+type GDSSessionReapPolicy struct {
+	// OlderThan selects sessions created more than this long ago. Zero disables the policy.
+	OlderThan time.Duration
+	// Expired selects sessions whose ExpiresAt is in the past.
+	Expired bool
+	// Orphaned selects sessions attached to an InstanceID that no longer
+	// appears in Instances.List. Sessions without an InstanceID are never orphaned.
+	Orphaned bool
+	// NamePattern selects sessions whose name matches the expression.
+	NamePattern *regexp.Regexp
+
+	// DryRun reports what would be removed without deleting anything.
+	DryRun bool
+	// Concurrency limits the number of parallel deletes. Defaults to 4.
+	Concurrency int
+}
+
+// GDSSessionReapReport summarises a ReapGDSSessions run.
+type GDSSessionReapReport struct {
+	DryRun   bool                   `json:"dry_run"`
+	Checked  int                    `json:"checked"`
+	Matched  int                    `json:"matched"`
+	Deleted  int                    `json:"deleted"`
+	Failed   int                    `json:"failed"`
+	Sessions []GDSSessionReapResult `json:"sessions"`
+}
+
+// GDSSessionReapResult records the outcome for one selected GDS session.
+// Error holds the delete failure message, if any.
+type GDSSessionReapResult struct {
+	Session GetGDSSessionData      `json:"session"`
+	Reasons []GDSSessionReapReason `json:"reasons"`
+	Deleted bool                   `json:"deleted"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// ============================================================================
+// Reaper
+// ============================================================================
+
+// ReapGDSSessions finds GDS sessions matching policy and deletes them, or only
+// reports them when policy.DryRun is set. Deletes run concurrently up to
+// policy.Concurrency. An error is returned only when the sessions (or, for the
+// Orphaned policy, the instances) cannot be listed; individual delete failures
+// are recorded in the report.
+func (c *AuraAPIClient) ReapGDSSessions(ctx context.Context, policy GDSSessionReapPolicy) (*GDSSessionReapReport, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if policy.OlderThan < 0 {
+		return nil, fmt.Errorf("OlderThan must not be negative")
+	}
+	if policy.OlderThan == 0 && !policy.Expired && !policy.Orphaned && policy.NamePattern == nil {
+		return nil, errors.New("at least one reap policy must be enabled")
+	}
+	concurrency := policy.Concurrency
+	if concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative")
+	}
+	if concurrency == 0 {
+		concurrency = defaultReapConcurrency
+	}
+
+	sessions, err := c.GraphAnalytics.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GDS sessions: %w", err)
+	}
+
+	var liveInstances map[string]bool
+	if policy.Orphaned {
+		instances, err := c.Instances.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
+		}
+		liveInstances = make(map[string]bool, len(instances.Data))
+		for _, inst := range instances.Data {
+			liveInstances[inst.ID] = true
+		}
+	}
+
+	now := time.Now()
+	report := &GDSSessionReapReport{
+		DryRun:   policy.DryRun,
+		Checked:  len(sessions.Data),
+		Sessions: []GDSSessionReapResult{},
+	}
+	for _, session := range sessions.Data {
+		reasons := reapReasons(session, policy, liveInstances, now)
+		if len(reasons) > 0 {
+			report.Sessions = append(report.Sessions, GDSSessionReapResult{Session: session, Reasons: reasons})
+		}
+	}
+	report.Matched = len(report.Sessions)
+
+	c.logger.InfoContext(ctx, "GDS sessions selected for reaping",
+		slog.Int("checked", report.Checked),
+		slog.Int("matched", report.Matched),
+		slog.Bool("dryRun", policy.DryRun))
+
+	if policy.DryRun {
+		return report, nil
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range report.Sessions {
+		wg.Add(1)
+		go func(result *GDSSessionReapResult) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+				return
+			}
+			if _, err := c.GraphAnalytics.Delete(ctx, result.Session.ID); err != nil {
+				c.logger.WarnContext(ctx, "failed to reap GDS session", slog.String("sessionID", result.Session.ID), slog.String("error", err.Error()))
+				result.Error = err.Error()
+				return
+			}
+			result.Deleted = true
+		}(&report.Sessions[i])
+	}
+	wg.Wait()
+
+	for _, result := range report.Sessions {
+		if result.Deleted {
+			report.Deleted++
+		} else {
+			report.Failed++
+		}
+	}
+
+	c.logger.InfoContext(ctx, "GDS session reaping complete",
+		slog.Int("deleted", report.Deleted),
+		slog.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+// reapReasons returns every policy in policy that session matches.
+func reapReasons(session GetGDSSessionData, policy GDSSessionReapPolicy, liveInstances map[string]bool, now time.Time) []GDSSessionReapReason {
+	var reasons []GDSSessionReapReason
+	if policy.OlderThan > 0 && !session.CreatedAt.IsZero() && now.Sub(session.CreatedAt) > policy.OlderThan {
+		reasons = append(reasons, ReapReasonAge)
+	}
+	if policy.Expired && !session.ExpiresAt.IsZero() && session.ExpiresAt.Before(now) {
+		reasons = append(reasons, ReapReasonExpired)
+	}
+	if policy.Orphaned && session.InstanceID != "" && !liveInstances[session.InstanceID] {
+		reasons = append(reasons, ReapReasonOrphaned)
+	}
+	if policy.NamePattern != nil && policy.NamePattern.MatchString(session.Name) {
+		reasons = append(reasons, ReapReasonName)
+	}
+	return reasons
+}
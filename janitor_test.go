@@ -0,0 +1,157 @@
+package aura
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/LackOfMorals/aura-client/internal/api"
+)
+
+// reapTestSessions returns a session list covering every reap policy.
+func reapTestSessions(now time.Time) GetGDSSessionListResponse {
+	return GetGDSSessionListResponse{Data: []GetGDSSessionData{
+		{ID: "fresh", Name: "analytics", InstanceID: "aaaaaaaa", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ID: "old", Name: "analytics", InstanceID: "aaaaaaaa", CreatedAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ID: "expired", Name: "analytics", InstanceID: "aaaaaaaa", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)},
+		{ID: "orphan", Name: "analytics", InstanceID: "bbbbbbbb", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ID: "scratch", Name: "tmp-scratch", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+	}}
+}
+
+// newReapTestMock returns a router serving reapTestSessions, one live
+// instance, and successful deletes for every session.
+func newReapTestMock(now time.Time) *mockAPIServiceRouter {
+	mock := newMockAPIServiceRouter().
+		on("GET", "graph-analytics/sessions", reapTestSessions(now)).
+		on("GET", "instances", ListInstancesResponse{Data: []ListInstanceData{{ID: "aaaaaaaa"}}})
+	for _, s := range reapTestSessions(now).Data {
+		mock.on("DELETE", "graph-analytics/sessions/"+s.ID, DeleteGDSSessionResponse{Data: DeleteGDSSession{ID: s.ID}})
+	}
+	return mock
+}
+
+// TestReapGDSSessions_AllPolicies verifies each policy selects the expected session
+func TestReapGDSSessions_AllPolicies(t *testing.T) {
+	mock := newReapTestMock(time.Now())
+	client := newTestClientWithAPI(mock)
+
+	report, err := client.ReapGDSSessions(context.Background(), GDSSessionReapPolicy{
+		OlderThan:   24 * time.Hour,
+		Expired:     true,
+		Orphaned:    true,
+		NamePattern: regexp.MustCompile(`^tmp-`),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := map[string]GDSSessionReapReason{
+		"old":     ReapReasonAge,
+		"expired": ReapReasonExpired,
+		"orphan":  ReapReasonOrphaned,
+		"scratch": ReapReasonName,
+	}
+	if report.Checked != 5 || report.Matched != 4 || report.Deleted != 4 || report.Failed != 0 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	for _, result := range report.Sessions {
+		reason, ok := want[result.Session.ID]
+		if !ok {
+			t.Errorf("session %s should not have been selected", result.Session.ID)
+			continue
+		}
+		if len(result.Reasons) != 1 || result.Reasons[0] != reason {
+			t.Errorf("session %s: expected reason %s, got %v", result.Session.ID, reason, result.Reasons)
+		}
+		if !result.Deleted {
+			t.Errorf("session %s: expected Deleted to be true", result.Session.ID)
+		}
+		if n := len(mock.callsTo("DELETE", "graph-analytics/sessions/"+result.Session.ID)); n != 1 {
+			t.Errorf("session %s: expected 1 delete call, got %d", result.Session.ID, n)
+		}
+	}
+	if n := len(mock.callsTo("DELETE", "graph-analytics/sessions/fresh")); n != 0 {
+		t.Errorf("fresh session should not be deleted, got %d calls", n)
+	}
+}
+
+// TestReapGDSSessions_DryRun verifies nothing is deleted in dry-run mode
+func TestReapGDSSessions_DryRun(t *testing.T) {
+	mock := newReapTestMock(time.Now())
+	client := newTestClientWithAPI(mock)
+
+	report, err := client.ReapGDSSessions(context.Background(), GDSSessionReapPolicy{Expired: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !report.DryRun || report.Matched != 1 || report.Deleted != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.Sessions[0].Session.ID != "expired" {
+		t.Errorf("expected 'expired' session, got '%s'", report.Sessions[0].Session.ID)
+	}
+	for _, c := range mock.calls {
+		if c.method == "DELETE" {
+			t.Errorf("unexpected delete call: %s", c.path)
+		}
+	}
+	// The Orphaned policy is off, so instances should not be listed.
+	if n := len(mock.callsTo("GET", "instances")); n != 0 {
+		t.Errorf("expected no instance list call, got %d", n)
+	}
+}
+
+// TestReapGDSSessions_DeleteFailure verifies delete failures are recorded, not returned
+func TestReapGDSSessions_DeleteFailure(t *testing.T) {
+	mock := newReapTestMock(time.Now()).
+		onError("DELETE", "graph-analytics/sessions/old", &api.Error{StatusCode: http.StatusInternalServerError, Message: "boom"})
+	client := newTestClientWithAPI(mock)
+
+	report, err := client.ReapGDSSessions(context.Background(), GDSSessionReapPolicy{OlderThan: 24 * time.Hour, Expired: true, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Deleted != 1 || report.Failed != 1 {
+		t.Errorf("expected 1 deleted and 1 failed, got %+v", report)
+	}
+	for _, result := range report.Sessions {
+		if result.Session.ID == "old" && (result.Deleted || result.Error == "") {
+			t.Errorf("expected failure recorded for 'old', got %+v", result)
+		}
+	}
+}
+
+// TestReapGDSSessions_InvalidPolicy verifies policy validation
+func TestReapGDSSessions_InvalidPolicy(t *testing.T) {
+	client := newTestClientWithAPI(newMockAPIServiceRouter())
+	ctx := context.Background()
+
+	if _, err := client.ReapGDSSessions(ctx, GDSSessionReapPolicy{DryRun: true}); err == nil {
+		t.Error("expected error when no policy is enabled")
+	}
+	if _, err := client.ReapGDSSessions(ctx, GDSSessionReapPolicy{OlderThan: -time.Hour}); err == nil {
+		t.Error("expected error for negative OlderThan")
+	}
+	if _, err := client.ReapGDSSessions(ctx, GDSSessionReapPolicy{Expired: true, Concurrency: -1}); err == nil {
+		t.Error("expected error for negative concurrency")
+	}
+}
+
+// TestReapGDSSessions_InstanceListError verifies the run aborts when orphans cannot be determined
+func TestReapGDSSessions_InstanceListError(t *testing.T) {
+	mock := newReapTestMock(time.Now()).
+		onError("GET", "instances", &api.Error{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"})
+	client := newTestClientWithAPI(mock)
+
+	if _, err := client.ReapGDSSessions(context.Background(), GDSSessionReapPolicy{Orphaned: true}); err == nil {
+		t.Fatal("expected error when instances cannot be listed")
+	}
+	for _, c := range mock.calls {
+		if c.method == "DELETE" {
+			t.Errorf("unexpected delete call: %s", c.path)
+		}
+	}
+}
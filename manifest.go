@@ -0,0 +1,586 @@
+package aura
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	utils "github.com/LackOfMorals/aura-client/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Manifest describes the instances that should exist, for PlanManifest and
+// ApplyPlan:
+//
+//	defaults:
+//	  tenant_id: 6f1b...
+//	  cloud_provider: gcp
+//	  region: europe-west1
+//	  type: enterprise-db
+//	instances:
+//	  - name: orders
+//	    memory: 16GB
+//	    secondaries_count: 1
+//	  - name: analytics
+//	    memory: 32GB
+//	    cdc_enrichment_mode: DIFF
+//	prune: true
+type Manifest struct {
+	// Defaults fills the empty fields of every instance.
+	Defaults  ManifestInstance   `json:"defaults" yaml:"defaults,omitempty"`
+	Instances []ManifestInstance `json:"instances" yaml:"instances"`
+	// Prune deletes the instances of the manifest's tenants that it does not
+	// list. Instances of other tenants are never touched.
+	Prune bool `json:"prune,omitempty" yaml:"prune,omitempty"`
+}
+
+// ManifestInstance is the desired state of one instance. An entry is matched
+// to an existing instance by ID when set, otherwise by name within its tenant,
+// so the name can only be changed when the ID is given.
+type ManifestInstance struct {
+	ID            string `json:"id,omitempty" yaml:"id,omitempty"`
+	Name          string `json:"name" yaml:"name,omitempty"`
+	TenantID      string `json:"tenant_id" yaml:"tenant_id,omitempty"`
+	CloudProvider string `json:"cloud_provider" yaml:"cloud_provider,omitempty"`
+	Region        string `json:"region" yaml:"region,omitempty"`
+	Type          string `json:"type" yaml:"type,omitempty"`
+	Memory        string `json:"memory" yaml:"memory,omitempty"`
+	// Version is only used when the instance is created.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// SecondariesCount is left unmanaged when nil.
+	SecondariesCount *int `json:"secondaries_count,omitempty" yaml:"secondaries_count,omitempty"`
+	// CDCEnrichmentMode is OFF, DIFF or FULL, and left unmanaged when empty.
+	CDCEnrichmentMode string `json:"cdc_enrichment_mode,omitempty" yaml:"cdc_enrichment_mode,omitempty"`
+}
+
+// PlanAction is what a PlanStep does to an instance.
+type PlanAction string
+
+// Actions in a Plan.
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+)
+
+// Fields reported in PlanChange.Field.
+const (
+	FieldName              = "name"
+	FieldMemory            = "memory"
+	FieldSecondariesCount  = "secondaries_count"
+	FieldCDCEnrichmentMode = "cdc_enrichment_mode"
+)
+
+// PlanChange is one field a step sets. Before is empty for creates.
+type PlanChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after"`
+}
+
+// PlanStep is one change to one instance.
+type PlanStep struct {
+	Action PlanAction `json:"action"`
+	// InstanceID is empty for creates.
+	InstanceID string       `json:"instance_id,omitempty"`
+	Name       string       `json:"name"`
+	TenantID   string       `json:"tenant_id"`
+	Changes    []PlanChange `json:"changes,omitempty"`
+	// Desired is the manifest entry; nil for deletes.
+	Desired *ManifestInstance `json:"desired,omitempty"`
+}
+
+// Plan is the set of steps that brings the instances in line with a manifest.
+type Plan struct {
+	Steps []PlanStep `json:"steps"`
+	// Unchanged lists the IDs of instances that already match the manifest.
+	Unchanged []string `json:"unchanged"`
+	// Warnings reports differences that cannot be applied in place, such as
+	// a different region. They never produce steps.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// HasChanges reports whether the plan has any steps.
+func (p *Plan) HasChanges() bool {
+	return len(p.Steps) > 0
+}
+
+// ApplyOptions controls ApplyPlan.
+type ApplyOptions struct {
+	// Concurrency limits the number of steps applied in parallel. Defaults to 4.
+	Concurrency int
+}
+
+// defaultApplyConcurrency is used when ApplyOptions.Concurrency is not set.
+const defaultApplyConcurrency = 4
+
+// ApplyReport summarises an ApplyPlan run.
+type ApplyReport struct {
+	Applied int           `json:"applied"`
+	Failed  int           `json:"failed"`
+	Results []ApplyResult `json:"results"`
+}
+
+// ApplyResult records the outcome of one step. Error holds the failure
+// message, if any.
+type ApplyResult struct {
+	Step       PlanStep `json:"step"`
+	InstanceID string   `json:"instance_id,omitempty"`
+	Applied    bool     `json:"applied"`
+	Error      string   `json:"error,omitempty"`
+	// Credentials holds the connection details of a created instance. Its
+	// password is returned only once and is never serialised.
+	Credentials *CreateInstanceData `json:"-"`
+}
+
+// ============================================================================
+// Loading and validation
+// ============================================================================
+
+// ParseManifest decodes a manifest from YAML or JSON and validates it.
+// Unknown fields are rejected so that typos do not silently go unmanaged.
+func ParseManifest(data []byte) (*Manifest, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadManifest reads a YAML or JSON manifest from path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return ParseManifest(data)
+}
+
+// Validate checks every instance, after applying the defaults, and returns
+// all problems found as ValidationErrors.
+func (m *Manifest) Validate() error {
+	var errs ValidationErrors
+	if len(m.Instances) == 0 {
+		errs.add("instances", "", errors.New("at least one instance is required"))
+	}
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, inst := range m.resolved() {
+		field := fmt.Sprintf("instances[%d]", i)
+		if inst.ID != "" {
+			errs.add(field+".id", inst.ID, utils.ValidateInstanceID(inst.ID))
+			if ids[inst.ID] {
+				errs.add(field+".id", inst.ID, errors.New("is listed more than once"))
+			}
+			ids[inst.ID] = true
+		}
+		if inst.Name == "" {
+			errs.add(field+".name", "", errors.New("is required"))
+		} else if key := inst.TenantID + "/" + inst.Name; names[key] {
+			errs.add(field+".name", inst.Name, errors.New("is listed more than once for the tenant"))
+		} else {
+			names[key] = true
+		}
+		if inst.TenantID == "" {
+			errs.add(field+".tenant_id", "", errors.New("is required"))
+		} else {
+			errs.add(field+".tenant_id", inst.TenantID, utils.ValidateTenantID(inst.TenantID))
+		}
+		switch inst.CloudProvider {
+		case "gcp", "aws", "azure":
+		default:
+			errs.add(field+".cloud_provider", inst.CloudProvider, errors.New("must be gcp, aws or azure"))
+		}
+		if inst.Region == "" {
+			errs.add(field+".region", "", errors.New("is required"))
+		}
+		if inst.Type == "" {
+			errs.add(field+".type", "", errors.New("is required"))
+		}
+		if _, err := utils.ParseMemoryGB(inst.Memory); err != nil {
+			errs.add(field+".memory", inst.Memory, err)
+		}
+		if inst.SecondariesCount != nil && *inst.SecondariesCount < 0 {
+			errs.add(field+".secondaries_count", strconv.Itoa(*inst.SecondariesCount), errors.New("must not be negative"))
+		}
+		switch strings.ToUpper(inst.CDCEnrichmentMode) {
+		case "", "OFF", "DIFF", "FULL":
+		default:
+			errs.add(field+".cdc_enrichment_mode", inst.CDCEnrichmentMode, errors.New("must be OFF, DIFF or FULL"))
+		}
+	}
+	return errs.err()
+}
+
+// resolved returns the instances with the defaults applied.
+func (m *Manifest) resolved() []ManifestInstance {
+	d := m.Defaults
+	out := make([]ManifestInstance, len(m.Instances))
+	for i, inst := range m.Instances {
+		inst.TenantID = orDefault(inst.TenantID, d.TenantID)
+		inst.CloudProvider = orDefault(inst.CloudProvider, d.CloudProvider)
+		inst.Region = orDefault(inst.Region, d.Region)
+		inst.Type = orDefault(inst.Type, d.Type)
+		inst.Memory = orDefault(inst.Memory, d.Memory)
+		inst.Version = orDefault(inst.Version, d.Version)
+		inst.CDCEnrichmentMode = strings.ToUpper(orDefault(inst.CDCEnrichmentMode, d.CDCEnrichmentMode))
+		if inst.SecondariesCount == nil {
+			inst.SecondariesCount = d.SecondariesCount
+		}
+		out[i] = inst
+	}
+	return out
+}
+
+// orDefault returns value, or fallback when value is empty.
+func orDefault(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// ============================================================================
+// Planning
+// ============================================================================
+
+// PlanManifest compares the manifest with the existing instances and returns
+// the steps that would reconcile them. It changes nothing.
+func (c *AuraAPIClient) PlanManifest(ctx context.Context, manifest *Manifest) (*Plan, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, errors.New("manifest must not be nil")
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	list, err := c.Instances.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+	byID := make(map[string]ListInstanceData, len(list.Data))
+	byName := make(map[string][]ListInstanceData)
+	for _, inst := range list.Data {
+		byID[inst.ID] = inst
+		key := inst.TenantID + "/" + inst.Name
+		byName[key] = append(byName[key], inst)
+	}
+
+	plan := &Plan{Steps: []PlanStep{}, Unchanged: []string{}}
+	matched := make(map[string]bool)
+	tenants := make(map[string]bool)
+	var creates, updates []PlanStep
+	for _, desired := range manifest.resolved() {
+		tenants[desired.TenantID] = true
+
+		var id string
+		if desired.ID != "" {
+			if _, ok := byID[desired.ID]; !ok {
+				return nil, fmt.Errorf("instance %s of manifest entry %q does not exist", desired.ID, desired.Name)
+			}
+			id = desired.ID
+		} else {
+			switch found := byName[desired.TenantID+"/"+desired.Name]; len(found) {
+			case 0:
+			case 1:
+				id = found[0].ID
+			default:
+				return nil, fmt.Errorf("%d instances in tenant %s are named %q; set id in the manifest to choose one", len(found), desired.TenantID, desired.Name)
+			}
+		}
+
+		if id == "" {
+			creates = append(creates, PlanStep{
+				Action:   PlanCreate,
+				Name:     desired.Name,
+				TenantID: desired.TenantID,
+				Changes:  createChanges(&desired),
+				Desired:  &desired,
+			})
+			continue
+		}
+		matched[id] = true
+
+		current, err := c.Instances.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance %s: %w", id, err)
+		}
+		changes, warnings := diffInstance(&desired, &current.Data)
+		plan.Warnings = append(plan.Warnings, warnings...)
+		if len(changes) == 0 {
+			plan.Unchanged = append(plan.Unchanged, id)
+			continue
+		}
+		updates = append(updates, PlanStep{
+			Action:     PlanUpdate,
+			InstanceID: id,
+			Name:       current.Data.Name,
+			TenantID:   current.Data.TenantID,
+			Changes:    changes,
+			Desired:    &desired,
+		})
+	}
+
+	var deletes []PlanStep
+	if manifest.Prune {
+		for _, inst := range list.Data {
+			if tenants[inst.TenantID] && !matched[inst.ID] {
+				deletes = append(deletes, PlanStep{Action: PlanDelete, InstanceID: inst.ID, Name: inst.Name, TenantID: inst.TenantID})
+			}
+		}
+		slices.SortFunc(deletes, func(a, b PlanStep) int { return strings.Compare(a.Name, b.Name) })
+	}
+	plan.Steps = append(append(append(plan.Steps, creates...), updates...), deletes...)
+
+	c.logger.InfoContext(ctx, "manifest planned",
+		slog.Int("create", len(creates)),
+		slog.Int("update", len(updates)),
+		slog.Int("delete", len(deletes)),
+		slog.Int("unchanged", len(plan.Unchanged)))
+
+	return plan, nil
+}
+
+// createChanges lists the fields a create step sets.
+func createChanges(d *ManifestInstance) []PlanChange {
+	changes := []PlanChange{
+		{Field: "cloud_provider", After: d.CloudProvider},
+		{Field: "region", After: d.Region},
+		{Field: "type", After: d.Type},
+		{Field: FieldMemory, After: d.Memory},
+	}
+	if d.SecondariesCount != nil {
+		changes = append(changes, PlanChange{Field: FieldSecondariesCount, After: strconv.Itoa(*d.SecondariesCount)})
+	}
+	if d.CDCEnrichmentMode != "" {
+		changes = append(changes, PlanChange{Field: FieldCDCEnrichmentMode, After: d.CDCEnrichmentMode})
+	}
+	return changes
+}
+
+// diffInstance returns the changes that make current match desired, and
+// warnings for differences that cannot be changed in place.
+func diffInstance(desired *ManifestInstance, current *InstanceData) ([]PlanChange, []string) {
+	var changes []PlanChange
+	var warnings []string
+	for _, f := range []struct{ field, want, got string }{
+		{"cloud_provider", desired.CloudProvider, current.CloudProvider},
+		{"region", desired.Region, current.Region},
+		{"type", desired.Type, current.Type},
+	} {
+		if !strings.EqualFold(f.want, f.got) {
+			warnings = append(warnings, fmt.Sprintf("instance %s (%s): %s is %s but the manifest wants %s, which cannot be changed in place",
+				current.ID, current.Name, f.field, f.got, f.want))
+		}
+	}
+
+	if desired.ID != "" && desired.Name != current.Name {
+		changes = append(changes, PlanChange{Field: FieldName, Before: current.Name, After: desired.Name})
+	}
+	wantGB, _ := utils.ParseMemoryGB(desired.Memory)
+	if gotGB, err := utils.ParseMemoryGB(current.Memory); err != nil || gotGB != wantGB {
+		changes = append(changes, PlanChange{Field: FieldMemory, Before: current.Memory, After: desired.Memory})
+	}
+	if desired.SecondariesCount != nil && *desired.SecondariesCount != current.Secondaries {
+		changes = append(changes, PlanChange{Field: FieldSecondariesCount, Before: strconv.Itoa(current.Secondaries), After: strconv.Itoa(*desired.SecondariesCount)})
+	}
+	if desired.CDCEnrichmentMode != "" && !strings.EqualFold(desired.CDCEnrichmentMode, current.CDCEnrichment) {
+		changes = append(changes, PlanChange{Field: FieldCDCEnrichmentMode, Before: current.CDCEnrichment, After: desired.CDCEnrichmentMode})
+	}
+	return changes, warnings
+}
+
+// ============================================================================
+// Applying
+// ============================================================================
+
+// ApplyPlan executes the steps of plan, up to opts.Concurrency at a time, and
+// waits for each instance to settle: created and updated instances until they
+// are running, deleted ones until they are gone. Secondaries and the CDC mode
+// of a new instance are set by an update once it is running. An error is
+// returned only when the arguments are invalid; step failures are recorded in
+// the report.
+func (c *AuraAPIClient) ApplyPlan(ctx context.Context, plan *Plan, opts ApplyOptions) (*ApplyReport, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("plan must not be nil")
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative")
+	}
+	if concurrency == 0 {
+		concurrency = defaultApplyConcurrency
+	}
+
+	report := &ApplyReport{Results: make([]ApplyResult, len(plan.Steps))}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, step := range plan.Steps {
+		report.Results[i] = ApplyResult{Step: step, InstanceID: step.InstanceID}
+		wg.Add(1)
+		go func(result *ApplyResult) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+				return
+			}
+			if err := c.applyStep(ctx, result); err != nil {
+				c.logger.WarnContext(ctx, "failed to apply plan step",
+					slog.String("action", string(result.Step.Action)),
+					slog.String("name", result.Step.Name),
+					slog.String("error", err.Error()))
+				result.Error = err.Error()
+				return
+			}
+			result.Applied = true
+		}(&report.Results[i])
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		if result.Applied {
+			report.Applied++
+		} else {
+			report.Failed++
+		}
+	}
+
+	c.logger.InfoContext(ctx, "plan applied",
+		slog.Int("applied", report.Applied),
+		slog.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+// applyStep executes one step, filling in the result's instance ID and
+// credentials as they become known.
+func (c *AuraAPIClient) applyStep(ctx context.Context, result *ApplyResult) error {
+	step := result.Step
+	switch step.Action {
+	case PlanCreate:
+		if step.Desired == nil {
+			return errors.New("create step has no desired state")
+		}
+		d := step.Desired
+		created, err := c.Instances.Create(ctx, &CreateInstanceConfigData{
+			Name: d.Name, TenantID: d.TenantID, CloudProvider: d.CloudProvider,
+			Region: d.Region, Type: d.Type, Version: d.Version, Memory: d.Memory,
+		})
+		if err != nil {
+			return err
+		}
+		result.InstanceID = created.Data.ID
+		result.Credentials = &created.Data
+		if err := c.waitForInstance(ctx, created.Data.ID, StatusRunning); err != nil {
+			return err
+		}
+
+		current, err := c.Instances.Get(ctx, created.Data.ID)
+		if err != nil {
+			return err
+		}
+		changes, _ := diffInstance(d, &current.Data)
+		return c.updateInstance(ctx, created.Data.ID, d, changes)
+
+	case PlanUpdate:
+		if step.Desired == nil {
+			return errors.New("update step has no desired state")
+		}
+		return c.updateInstance(ctx, step.InstanceID, step.Desired, step.Changes)
+
+	case PlanDelete:
+		if _, err := c.Instances.Delete(ctx, step.InstanceID); err != nil {
+			return err
+		}
+		return c.waitForInstance(ctx, step.InstanceID, "")
+
+	default:
+		return fmt.Errorf("unknown plan action %q", step.Action)
+	}
+}
+
+// updateInstance sends the changed fields of desired in one update and waits
+// for the instance to be running again. It does nothing without changes.
+func (c *AuraAPIClient) updateInstance(ctx context.Context, id string, desired *ManifestInstance, changes []PlanChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	req := &UpdateInstanceData{}
+	for _, change := range changes {
+		switch change.Field {
+		case FieldName:
+			req.Name = desired.Name
+		case FieldMemory:
+			req.Memory = desired.Memory
+		case FieldSecondariesCount:
+			req.SecondariesCount = desired.SecondariesCount
+		case FieldCDCEnrichmentMode:
+			req.CDCEnrichmentMode = desired.CDCEnrichmentMode
+		}
+	}
+	if _, err := c.Instances.Update(ctx, id, req); err != nil {
+		return err
+	}
+	return c.waitForInstance(ctx, id, StatusRunning)
+}
+
+// waitForInstance watches an instance until it has the wanted status, or
+// until it is gone when want is empty. Reaching any other settled status is
+// an error.
+func (c *AuraAPIClient) waitForInstance(ctx context.Context, id string, want InstanceStatus) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := c.Instances.Watch(ctx, id)
+	if err != nil {
+		return err
+	}
+	for e := range events {
+		switch e.Type {
+		case WatchEventDeleted:
+			if want == "" {
+				return nil
+			}
+			return fmt.Errorf("instance %s was deleted while waiting for it to be %s", id, want)
+		case WatchEventObserved, WatchEventStatus:
+			status := InstanceStatus(e.After)
+			switch {
+			case want != "" && status == want:
+				return nil
+			case want != "" && !status.IsTransitional():
+				return fmt.Errorf("instance %s is %s, expected %s", id, status, want)
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stopped watching instance %s", id)
+}
@@ -0,0 +1,211 @@
+package aura_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auratest"
+)
+
+const otherTenantID = "b2c3d4e5-0000-4000-8000-000000000002"
+
+const manifestYAML = `
+defaults:
+  tenant_id: ` + auratest.DefaultTenantID + `
+  cloud_provider: gcp
+  region: europe-west1
+  type: enterprise-db
+  memory: 8GB
+instances:
+  - name: orders
+    memory: 16GB
+  - name: cache
+  - name: analytics
+    secondaries_count: 1
+    cdc_enrichment_mode: diff
+prune: true
+`
+
+func TestParseManifest(t *testing.T) {
+	m, err := aura.ParseManifest([]byte(manifestYAML))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(m.Instances) != 3 || !m.Prune || m.Defaults.Memory != "8GB" {
+		t.Errorf("Unexpected manifest %+v", m)
+	}
+
+	if _, err := aura.ParseManifest([]byte(`{"instances": [{"name": "a"}], "prunes": true}`)); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestManifest_Validate(t *testing.T) {
+	negative := -1
+	m := &aura.Manifest{
+		Defaults: aura.ManifestInstance{TenantID: auratest.DefaultTenantID, Region: "europe-west1", Type: "enterprise-db"},
+		Instances: []aura.ManifestInstance{
+			{Name: "a", CloudProvider: "oracle", Memory: "lots"},
+			{Name: "a", CloudProvider: "gcp", Memory: "8GB", SecondariesCount: &negative, CDCEnrichmentMode: "ALL"},
+			{CloudProvider: "gcp", Memory: "8GB", ID: "nope"},
+		},
+	}
+	err := m.Validate()
+	var errs aura.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	for _, field := range []string{
+		"instances[0].cloud_provider", "instances[0].memory", "instances[1].name",
+		"instances[1].secondaries_count", "instances[1].cdc_enrichment_mode",
+		"instances[2].id", "instances[2].name",
+	} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected an error for %s, got %v", field, err)
+		}
+	}
+}
+
+// seedManifestServer starts a server with instances for manifestYAML: orders
+// needs resizing, cache matches, legacy is unlisted and other belongs to
+// another tenant.
+func seedManifestServer(t *testing.T) (*auratest.Server, map[string]aura.InstanceData) {
+	t.Helper()
+	srv := auratest.NewServer()
+	srv.AddTenant(aura.TenantResponseData{ID: otherTenantID, Name: "other"})
+	seeded := map[string]aura.InstanceData{}
+	for _, inst := range []aura.InstanceData{
+		{Name: "orders", TenantID: auratest.DefaultTenantID, Memory: "8GB"},
+		{Name: "cache", TenantID: auratest.DefaultTenantID, Memory: "8GB"},
+		{Name: "legacy", TenantID: auratest.DefaultTenantID, Memory: "2GB"},
+		{Name: "other", TenantID: otherTenantID, Memory: "2GB"},
+	} {
+		inst.CloudProvider, inst.Region, inst.Type, inst.CDCEnrichment = "gcp", "europe-west1", "enterprise-db", "OFF"
+		seeded[inst.Name] = srv.AddInstance(inst)
+	}
+	return srv, seeded
+}
+
+func TestPlanManifest(t *testing.T) {
+	srv, seeded := seedManifestServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	m, err := aura.ParseManifest([]byte(manifestYAML))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	plan, err := client.PlanManifest(context.Background(), m)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(plan.Steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %+v", plan.Steps)
+	}
+	if s := plan.Steps[0]; s.Action != aura.PlanCreate || s.Name != "analytics" || s.InstanceID != "" {
+		t.Errorf("Expected analytics to be created first, got %+v", s)
+	}
+	if s := plan.Steps[1]; s.Action != aura.PlanUpdate || s.InstanceID != seeded["orders"].ID ||
+		len(s.Changes) != 1 || s.Changes[0] != (aura.PlanChange{Field: aura.FieldMemory, Before: "8GB", After: "16GB"}) {
+		t.Errorf("Expected orders to be resized, got %+v", s)
+	}
+	if s := plan.Steps[2]; s.Action != aura.PlanDelete || s.InstanceID != seeded["legacy"].ID {
+		t.Errorf("Expected legacy to be deleted, got %+v", s)
+	}
+	if len(plan.Unchanged) != 1 || plan.Unchanged[0] != seeded["cache"].ID {
+		t.Errorf("Expected cache to be unchanged, got %v", plan.Unchanged)
+	}
+
+	m.Prune = false
+	m.Instances[1].Region = "us-east-1"
+	plan, err = client.PlanManifest(context.Background(), m)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(plan.Steps) != 2 || len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "region") {
+		t.Errorf("Expected no deletes and a region warning, got %+v", plan)
+	}
+}
+
+func TestPlanManifest_AmbiguousName(t *testing.T) {
+	srv, _ := seedManifestServer(t)
+	defer srv.Close()
+	srv.AddInstance(aura.InstanceData{Name: "cache", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+	client := newWatchClient(t, srv)
+	m, _ := aura.ParseManifest([]byte(manifestYAML))
+
+	if _, err := client.PlanManifest(context.Background(), m); err == nil || !strings.Contains(err.Error(), `"cache"`) {
+		t.Errorf("Expected an error naming the ambiguous instance, got %v", err)
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	srv, seeded := seedManifestServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	ctx := context.Background()
+	m, _ := aura.ParseManifest([]byte(manifestYAML))
+
+	plan, err := client.PlanManifest(ctx, m)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	report, err := client.ApplyPlan(ctx, plan, aura.ApplyOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Applied != 3 || report.Failed != 0 {
+		t.Fatalf("Expected every step to be applied, got %+v", report)
+	}
+	created := report.Results[0]
+	if created.Credentials == nil || created.Credentials.Password == "" || created.InstanceID != created.Credentials.ID {
+		t.Errorf("Expected the credentials of the new instance, got %+v", created)
+	}
+
+	analytics, err := client.Instances.Get(ctx, created.InstanceID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if d := analytics.Data; d.Status != aura.StatusRunning || d.Secondaries != 1 || d.CDCEnrichment != "DIFF" {
+		t.Errorf("Expected a running instance with one secondary and DIFF CDC, got %+v", d)
+	}
+	if orders, _ := client.Instances.Get(ctx, seeded["orders"].ID); orders.Data.Memory != "16GB" {
+		t.Errorf("Expected orders to be resized, got %s", orders.Data.Memory)
+	}
+	if _, err := client.Instances.Get(ctx, seeded["legacy"].ID); err == nil {
+		t.Error("Expected legacy to be deleted")
+	}
+	if _, err := client.Instances.Get(ctx, seeded["other"].ID); err != nil {
+		t.Errorf("Expected the other tenant's instance to be kept, got %v", err)
+	}
+
+	plan, err = client.PlanManifest(ctx, m)
+	if err != nil || plan.HasChanges() {
+		t.Errorf("Expected no changes after applying, got %+v, %v", plan, err)
+	}
+}
+
+func TestApplyPlan_RecordsFailures(t *testing.T) {
+	srv, seeded := seedManifestServer(t)
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+
+	plan := &aura.Plan{Steps: []aura.PlanStep{
+		{Action: aura.PlanDelete, InstanceID: "0000ffff", Name: "missing"},
+		{Action: aura.PlanDelete, InstanceID: seeded["legacy"].ID, Name: "legacy"},
+	}}
+	report, err := client.ApplyPlan(context.Background(), plan, aura.ApplyOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Applied != 1 || report.Failed != 1 || report.Results[0].Error == "" || !report.Results[1].Applied {
+		t.Errorf("Expected the missing instance to fail alone, got %+v", report)
+	}
+
+	if _, err := client.ApplyPlan(context.Background(), plan, aura.ApplyOptions{Concurrency: -1}); err == nil {
+		t.Error("Expected an error for a negative concurrency")
+	}
+}
@@ -2,7 +2,9 @@ package aura
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"net/http"
 	"os"
 	"sync"
 	"time"
@@ -17,6 +19,22 @@ func testLogger() *slog.Logger {
 	return slog.New(handler)
 }
 
+// newTestClientWithAPI creates an AuraAPIClient whose services all share the
+// supplied mock API, for tests of client methods that span several services.
+func newTestClientWithAPI(mock api.RequestService) *AuraAPIClient {
+	logger := testLogger()
+	return &AuraAPIClient{
+		api:            mock,
+		logger:         logger,
+		Tenants:        &tenantService{api: mock, timeout: 30 * time.Second, logger: logger},
+		Instances:      &instanceService{api: mock, timeout: 30 * time.Second, logger: logger},
+		Snapshots:      &snapshotService{api: mock, timeout: 30 * time.Second, logger: logger},
+		Cmek:           &cmekService{api: mock, timeout: 30 * time.Second, logger: logger},
+		GraphAnalytics: &gDSSessionService{api: mock, timeout: 30 * time.Second, logger: logger},
+		Prometheus:     &prometheusService{api: mock, timeout: 30 * time.Second, logger: logger},
+	}
+}
+
 // ============================================================================
 // Mock types
 // ============================================================================
@@ -66,6 +84,29 @@ type mockAPIServiceWithCallback struct {
 	OnDelete func(ctx context.Context, endpoint string) error
 }
 
+// mockAPIServiceRouter is a mock that returns a different response for each
+// "METHOD path" key, for tests that drive several endpoints in one call. Every
+// call is recorded in order; mu makes the mock safe for concurrent use.
+type mockAPIServiceRouter struct {
+	mu     sync.Mutex
+	routes map[string]mockRoute
+	calls  []mockCall
+}
+
+// mockRoute is the canned response for a single route.
+type mockRoute struct {
+	response *api.Response
+	err      error
+}
+
+// mockCall records a single request made to mockAPIServiceRouter.
+type mockCall struct {
+	method  string
+	path    string
+	body    string
+	headers map[string]string
+}
+
 // ============================================================================
 // mockAPIService — simple mock, does not check context
 // ============================================================================
@@ -76,6 +117,10 @@ func (m *mockAPIService) Get(_ context.Context, endpoint string) (*api.Response,
 	return m.response, m.err
 }
 
+func (m *mockAPIService) GetWithHeaders(ctx context.Context, endpoint string, _ map[string]string) (*api.Response, error) {
+	return m.Get(ctx, endpoint)
+}
+
 func (m *mockAPIService) Post(_ context.Context, endpoint string, body string) (*api.Response, error) {
 	m.lastMethod = "POST"
 	m.lastPath = endpoint
@@ -116,6 +161,10 @@ func (m *mockAPIServiceWithDelay) Get(ctx context.Context, endpoint string) (*ap
 	return m.executeWithDelay(ctx)
 }
 
+func (m *mockAPIServiceWithDelay) GetWithHeaders(ctx context.Context, endpoint string, _ map[string]string) (*api.Response, error) {
+	return m.Get(ctx, endpoint)
+}
+
 func (m *mockAPIServiceWithDelay) Post(ctx context.Context, endpoint string, body string) (*api.Response, error) {
 	m.mu.Lock()
 	m.lastMethod = "POST"
@@ -186,6 +235,10 @@ func (m *mockAPIServiceWithCallback) Get(ctx context.Context, endpoint string) (
 	return m.executeWithDelay(ctx)
 }
 
+func (m *mockAPIServiceWithCallback) GetWithHeaders(ctx context.Context, endpoint string, _ map[string]string) (*api.Response, error) {
+	return m.Get(ctx, endpoint)
+}
+
 func (m *mockAPIServiceWithCallback) Post(ctx context.Context, endpoint string, body string) (*api.Response, error) {
 	m.lastMethod = "POST"
 	m.lastPath = endpoint
@@ -250,3 +303,86 @@ func (m *mockAPIServiceWithCallback) executeWithDelay(ctx context.Context) (*api
 	}
 	return m.response, m.err
 }
+
+// ============================================================================
+// mockAPIServiceRouter — per-route responses, records every call
+// ============================================================================
+
+func newMockAPIServiceRouter() *mockAPIServiceRouter {
+	return &mockAPIServiceRouter{routes: make(map[string]mockRoute)}
+}
+
+// on registers a JSON response for method and path. body is marshalled with
+// encoding/json unless it is already a []byte.
+func (m *mockAPIServiceRouter) on(method, path string, body any) *mockAPIServiceRouter {
+	raw, ok := body.([]byte)
+	if !ok {
+		raw, _ = json.Marshal(body)
+	}
+	m.routes[method+" "+path] = mockRoute{response: &api.Response{StatusCode: 200, Body: raw}}
+	return m
+}
+
+// onContentType registers a raw response for method and path that is served
+// with a Content-Type header.
+func (m *mockAPIServiceRouter) onContentType(method, path, contentType string, body []byte) *mockAPIServiceRouter {
+	m.routes[method+" "+path] = mockRoute{response: &api.Response{StatusCode: 200, Body: body, Header: http.Header{"Content-Type": {contentType}}}}
+	return m
+}
+
+// onError registers an error for method and path.
+func (m *mockAPIServiceRouter) onError(method, path string, err error) *mockAPIServiceRouter {
+	m.routes[method+" "+path] = mockRoute{err: err}
+	return m
+}
+
+// callsTo returns the recorded calls matching method and path.
+func (m *mockAPIServiceRouter) callsTo(method, path string) []mockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []mockCall
+	for _, c := range m.calls {
+		if c.method == method && c.path == path {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (m *mockAPIServiceRouter) do(ctx context.Context, method, path, body string, headers map[string]string) (*api.Response, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, mockCall{method: method, path: path, body: body, headers: headers})
+	route, ok := m.routes[method+" "+path]
+	m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, &api.Error{StatusCode: 404, Message: "no route for " + method + " " + path}
+	}
+	return route.response, route.err
+}
+
+func (m *mockAPIServiceRouter) Get(ctx context.Context, endpoint string) (*api.Response, error) {
+	return m.do(ctx, "GET", endpoint, "", nil)
+}
+
+func (m *mockAPIServiceRouter) GetWithHeaders(ctx context.Context, endpoint string, headers map[string]string) (*api.Response, error) {
+	return m.do(ctx, "GET", endpoint, "", headers)
+}
+
+func (m *mockAPIServiceRouter) Post(ctx context.Context, endpoint string, body string) (*api.Response, error) {
+	return m.do(ctx, "POST", endpoint, body, nil)
+}
+
+func (m *mockAPIServiceRouter) Put(ctx context.Context, endpoint string, body string) (*api.Response, error) {
+	return m.do(ctx, "PUT", endpoint, body, nil)
+}
+
+func (m *mockAPIServiceRouter) Patch(ctx context.Context, endpoint string, body string) (*api.Response, error) {
+	return m.do(ctx, "PATCH", endpoint, body, nil)
+}
+
+func (m *mockAPIServiceRouter) Delete(ctx context.Context, endpoint string) (*api.Response, error) {
+	return m.do(ctx, "DELETE", endpoint, "", nil)
+}
@@ -0,0 +1,570 @@
+package aura
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// HealthNotifier defaults, used when the corresponding option is not given.
+const (
+	defaultNotifierDedupWindow = 10 * time.Minute
+	defaultNotifierRetries     = 3
+	defaultNotifierBackoff     = time.Second
+	defaultNotifierTimeout     = 10 * time.Second
+
+	// notifierMaxBackoff caps the wait between delivery attempts, including
+	// waits requested by a Retry-After header.
+	notifierMaxBackoff = time.Minute
+)
+
+// Headers set on signed webhook requests.
+const (
+	// WebhookSignatureHeader carries "sha256=" followed by the hex HMAC-SHA256
+	// of the timestamp, a full stop and the request body.
+	WebhookSignatureHeader = "X-Aura-Signature"
+	// WebhookTimestampHeader carries the Unix time the request was signed.
+	WebhookTimestampHeader = "X-Aura-Timestamp"
+)
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint, used when a
+// WebhookPagerDuty webhook has no URL.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// WebhookFormat selects the payload posted to a webhook.
+type WebhookFormat string
+
+// Supported webhook formats.
+const (
+	// WebhookGeneric posts the HealthTransition as JSON.
+	WebhookGeneric WebhookFormat = "generic"
+	// WebhookSlack posts a Slack incoming webhook message.
+	WebhookSlack WebhookFormat = "slack"
+	// WebhookPagerDuty posts a PagerDuty Events API v2 event, triggering an
+	// alert per instance on warning or critical and resolving it on recovery.
+	WebhookPagerDuty WebhookFormat = "pagerduty"
+)
+
+// Webhook is one destination for health-change notifications.
+type Webhook struct {
+	// URL receives a POST per notification. Optional for WebhookPagerDuty.
+	URL string `json:"url" yaml:"url"`
+	// Format defaults to WebhookGeneric.
+	Format WebhookFormat `json:"format,omitempty" yaml:"format,omitempty"`
+	// Secret, when set, signs each request with HMAC-SHA256. See
+	// WebhookSignatureHeader and VerifyWebhookSignature.
+	Secret string `json:"-" yaml:"secret,omitempty"`
+	// RoutingKey is the PagerDuty integration key. Required for WebhookPagerDuty.
+	RoutingKey string `json:"-" yaml:"routing_key,omitempty"`
+	// Headers are added to every request, e.g. for authentication.
+	Headers map[string]string `json:"-" yaml:"headers,omitempty"`
+}
+
+// HealthTransition is a change in the overall status of an instance.
+type HealthTransition struct {
+	Event           string    `json:"event"`
+	InstanceID      string    `json:"instance_id"`
+	InstanceName    string    `json:"instance_name,omitempty"`
+	PreviousStatus  string    `json:"previous_status"`
+	Status          string    `json:"status"`
+	Timestamp       time.Time `json:"timestamp"`
+	Issues          []string  `json:"issues"`
+	Recommendations []string  `json:"recommendations"`
+}
+
+// healthTransitionEvent is the Event of every HealthTransition.
+const healthTransitionEvent = "instance.health_changed"
+
+// NotifierOption configures a HealthNotifier.
+type NotifierOption func(*notifierOptions) error
+
+// notifierOptions holds the settings applied by NotifierOption values.
+type notifierOptions struct {
+	dedupWindow time.Duration
+	retries     int
+	backoff     time.Duration
+	httpClient  *http.Client
+	recoveries  bool
+}
+
+// HealthNotifier remembers the last OverallStatus of each instance and posts
+// to its webhooks when the status changes. The first status seen for an
+// instance is its baseline and is not notified, and HealthStatusUnknown is
+// ignored so that a failed check does not look like a change. Create one with
+// AuraAPIClient.NewHealthNotifier; it is safe for concurrent use.
+type HealthNotifier struct {
+	webhooks []Webhook
+	opts     notifierOptions
+	logger   *slog.Logger
+	now      func() time.Time
+	sleep    func(context.Context, time.Duration) error
+
+	mu       sync.Mutex
+	statuses map[string]string           // last known status by instance ID
+	sent     map[string]sentNotification // last delivered notification by instance ID
+}
+
+// sentNotification is the status and time of a delivered notification.
+type sentNotification struct {
+	status string
+	at     time.Time
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithNotifierDedupWindow suppresses a notification when the last notification
+// delivered for the same instance, within d, was of the same status, so an
+// instance that flaps through suppressed recoveries does not page repeatedly.
+// A notification that follows a delivered one of another status, such as a
+// trigger after a resolve, is always sent. Defaults to ten minutes; 0 disables
+// de-duplication.
+func WithNotifierDedupWindow(d time.Duration) NotifierOption {
+	return func(o *notifierOptions) error {
+		if d < 0 {
+			return fmt.Errorf("dedup window must not be negative")
+		}
+		o.dedupWindow = d
+		return nil
+	}
+}
+
+// WithNotifierRetries sets how many times a failed delivery is retried and the
+// initial wait, which doubles after each attempt. Network errors, 429 and 5xx
+// responses are retried. Defaults to 3 retries starting at one second.
+func WithNotifierRetries(retries int, backoff time.Duration) NotifierOption {
+	return func(o *notifierOptions) error {
+		if retries < 0 {
+			return fmt.Errorf("retries must not be negative, got %d", retries)
+		}
+		if backoff <= 0 {
+			return fmt.Errorf("retry backoff must be greater than zero")
+		}
+		o.retries, o.backoff = retries, backoff
+		return nil
+	}
+}
+
+// WithNotifierHTTPClient sets the HTTP client used to post notifications.
+// Defaults to a client with a ten second timeout.
+func WithNotifierHTTPClient(client *http.Client) NotifierOption {
+	return func(o *notifierOptions) error {
+		if client == nil {
+			return errors.New("HTTP client cannot be nil")
+		}
+		o.httpClient = client
+		return nil
+	}
+}
+
+// WithNotifierRecoveries controls whether a return to healthy is notified.
+// Defaults to true.
+func WithNotifierRecoveries(enabled bool) NotifierOption {
+	return func(o *notifierOptions) error {
+		o.recoveries = enabled
+		return nil
+	}
+}
+
+// ============================================================================
+// Notifier
+// ============================================================================
+
+// NewHealthNotifier returns a HealthNotifier posting to webhooks.
+func (c *AuraAPIClient) NewHealthNotifier(webhooks []Webhook, opts ...NotifierOption) (*HealthNotifier, error) {
+	if len(webhooks) == 0 {
+		return nil, errors.New("at least one webhook is required")
+	}
+
+	var errs ValidationErrors
+	hooks := make([]Webhook, len(webhooks))
+	for i, w := range webhooks {
+		field := fmt.Sprintf("webhooks[%d]", i)
+		if w.Format == "" {
+			w.Format = WebhookGeneric
+		}
+		switch w.Format {
+		case WebhookGeneric, WebhookSlack:
+		case WebhookPagerDuty:
+			if w.URL == "" {
+				w.URL = PagerDutyEventsURL
+			}
+			if w.RoutingKey == "" {
+				errs.add(field+".routing_key", "", errors.New("is required for pagerduty"))
+			}
+		default:
+			errs.add(field+".format", string(w.Format), errors.New("must be generic, slack or pagerduty"))
+		}
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add(field+".url", w.URL, errors.New("must be an absolute http or https URL"))
+		}
+		hooks[i] = w
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	options := notifierOptions{
+		dedupWindow: defaultNotifierDedupWindow,
+		retries:     defaultNotifierRetries,
+		backoff:     defaultNotifierBackoff,
+		httpClient:  &http.Client{Timeout: defaultNotifierTimeout},
+		recoveries:  true,
+	}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	return &HealthNotifier{
+		webhooks: hooks,
+		opts:     options,
+		logger:   c.logger.With(slog.String("component", "HealthNotifier")),
+		now:      time.Now,
+		sleep:    sleepContext,
+		statuses: make(map[string]string),
+		sent:     make(map[string]sentNotification),
+	}, nil
+}
+
+// Observe records the status of health and, if it differs from the last
+// status recorded for the instance, notifies every webhook. It returns the
+// transition, or nil when there was none, and any delivery errors joined
+// together. A transition suppressed by the dedup window or by
+// WithNotifierRecoveries(false) is still returned, with a nil error. When
+// delivery fails the change is not recorded, so the next observation of the
+// same status notifies every webhook again.
+func (n *HealthNotifier) Observe(ctx context.Context, health *PrometheusHealthMetrics) (*HealthTransition, error) {
+	if health == nil {
+		return nil, errors.New("health metrics must not be nil")
+	}
+	return n.observe(ctx, health.InstanceID, "", health)
+}
+
+// ObserveFleet calls Observe for every instance in report, adding instance
+// names to the notifications. Delivery errors are joined together.
+func (n *HealthNotifier) ObserveFleet(ctx context.Context, report *FleetHealthReport) ([]HealthTransition, error) {
+	if report == nil {
+		return nil, errors.New("fleet health report must not be nil")
+	}
+	var transitions []HealthTransition
+	var errs []error
+	for _, inst := range report.Instances {
+		if inst.Health == nil {
+			continue
+		}
+		t, err := n.observe(ctx, inst.InstanceID, inst.Name, inst.Health)
+		if t != nil {
+			transitions = append(transitions, *t)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return transitions, errors.Join(errs...)
+}
+
+// Forget drops the recorded status and dedup history of an instance, for
+// example after it has been deleted.
+func (n *HealthNotifier) Forget(instanceID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.statuses, instanceID)
+	delete(n.sent, instanceID)
+}
+
+// observe is the shared implementation of Observe and ObserveFleet.
+func (n *HealthNotifier) observe(ctx context.Context, instanceID, name string, health *PrometheusHealthMetrics) (*HealthTransition, error) {
+	status := health.OverallStatus
+	if status == "" || status == HealthStatusUnknown {
+		return nil, nil
+	}
+
+	n.mu.Lock()
+	previous, seen := n.statuses[instanceID]
+	n.statuses[instanceID] = status
+	if !seen || previous == status {
+		n.mu.Unlock()
+		return nil, nil
+	}
+
+	now := n.now()
+	transition := &HealthTransition{
+		Event:           healthTransitionEvent,
+		InstanceID:      instanceID,
+		InstanceName:    name,
+		PreviousStatus:  previous,
+		Status:          status,
+		Timestamp:       now,
+		Issues:          append([]string{}, health.Issues...),
+		Recommendations: append([]string{}, health.Recommendations...),
+	}
+
+	suppress := status == HealthStatusHealthy && !n.opts.recoveries
+	if last, ok := n.sent[instanceID]; ok && last.status == status && n.opts.dedupWindow > 0 && now.Sub(last.at) < n.opts.dedupWindow {
+		suppress = true
+	}
+	n.mu.Unlock()
+
+	if suppress {
+		n.logger.DebugContext(ctx, "health change notification suppressed",
+			slog.String("instanceID", instanceID), slog.String("from", previous), slog.String("to", status))
+		return transition, nil
+	}
+
+	n.logger.InfoContext(ctx, "instance health changed",
+		slog.String("instanceID", instanceID), slog.String("from", previous), slog.String("to", status))
+	err := n.Notify(ctx, *transition)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err != nil {
+		// Forget the change, unless a later observation has already replaced
+		// it, so that the next observation notifies it again.
+		if n.statuses[instanceID] == status {
+			n.statuses[instanceID] = previous
+		}
+		return transition, err
+	}
+	n.sent[instanceID] = sentNotification{status: status, at: now}
+	return transition, nil
+}
+
+// Notify posts t to every webhook, bypassing change detection and
+// de-duplication. Delivery errors are joined together.
+func (n *HealthNotifier) Notify(ctx context.Context, t HealthTransition) error {
+	var errs []error
+	for _, w := range n.webhooks {
+		if err := n.deliver(ctx, w, t); err != nil {
+			n.logger.ErrorContext(ctx, "failed to deliver health notification",
+				slog.String("instanceID", t.InstanceID), slog.String("format", string(w.Format)), slog.String("error", err.Error()))
+			errs = append(errs, fmt.Errorf("%s webhook: %w", w.Format, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver posts t to w, retrying transient failures.
+func (n *HealthNotifier) deliver(ctx context.Context, w Webhook, t HealthTransition) error {
+	body, err := webhookPayload(w, t)
+	if err != nil {
+		return fmt.Errorf("failed to build payload: %w", err)
+	}
+
+	backoff := n.opts.backoff
+	for attempt := 0; ; attempt++ {
+		wait, err := n.post(ctx, w, body)
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || attempt >= n.opts.retries {
+			return err
+		}
+		if wait == 0 {
+			wait = backoff
+		}
+		backoff = min(backoff*2, notifierMaxBackoff)
+		n.logger.DebugContext(ctx, "retrying health notification",
+			slog.Int("attempt", attempt+1), slog.Duration("wait", wait), slog.String("error", err.Error()))
+		if err := n.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// post sends one request. On failure the returned wait is -1 if the request
+// must not be retried, the delay requested by the server, or 0 for the
+// default backoff.
+func (n *HealthNotifier) post(ctx context.Context, w Webhook, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aura-client/"+AuraAPIClientVersion)
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	if w.Secret != "" {
+		timestamp := strconv.FormatInt(n.now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, signWebhook(w.Secret, timestamp, body))
+	}
+
+	resp, err := n.opts.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("webhook returned %s", resp.Status)
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return -1, err
+	}
+	if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs > 0 {
+		return min(time.Duration(secs)*time.Second, notifierMaxBackoff), err
+	}
+	return 0, err
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ============================================================================
+// Payloads and signing
+// ============================================================================
+
+// VerifyWebhookSignature reports whether signature, the value of the
+// WebhookSignatureHeader, matches body and the WebhookTimestampHeader value
+// for secret. Receivers should also reject stale timestamps.
+func VerifyWebhookSignature(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+// signWebhook returns the WebhookSignatureHeader value for body.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookPayload renders t in the format of w.
+func webhookPayload(w Webhook, t HealthTransition) ([]byte, error) {
+	switch w.Format {
+	case WebhookSlack:
+		return json.Marshal(slackPayload(t))
+	case WebhookPagerDuty:
+		return json.Marshal(pagerDutyPayload(w.RoutingKey, t))
+	default:
+		return json.Marshal(t)
+	}
+}
+
+// transitionSummary is a one-line description of t.
+func transitionSummary(t HealthTransition) string {
+	name := t.InstanceID
+	if t.InstanceName != "" {
+		name = fmt.Sprintf("%s (%s)", t.InstanceName, t.InstanceID)
+	}
+	return fmt.Sprintf("Aura instance %s is %s (was %s)", name, t.Status, t.PreviousStatus)
+}
+
+// slackMessage is a Slack incoming webhook message.
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color    string   `json:"color"`
+	Text     string   `json:"text,omitempty"`
+	Footer   string   `json:"footer,omitempty"`
+	Ts       int64    `json:"ts"`
+	MrkdwnIn []string `json:"mrkdwn_in,omitempty"`
+}
+
+// slackColors maps statuses to attachment colours.
+var slackColors = map[string]string{
+	HealthStatusHealthy:  "good",
+	HealthStatusWarning:  "warning",
+	HealthStatusCritical: "danger",
+}
+
+func slackPayload(t HealthTransition) slackMessage {
+	var lines []string
+	for _, issue := range t.Issues {
+		lines = append(lines, "• "+issue)
+	}
+	for _, rec := range t.Recommendations {
+		lines = append(lines, "→ "+rec)
+	}
+	return slackMessage{
+		Text: transitionSummary(t),
+		Attachments: []slackAttachment{{
+			Color:    slackColors[t.Status],
+			Text:     strings.Join(lines, "\n"),
+			Footer:   "aura-client",
+			Ts:       t.Timestamp.Unix(),
+			MrkdwnIn: []string{"text"},
+		}},
+	}
+}
+
+// pagerDutyEvent is a PagerDuty Events API v2 event.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyDetails `json:"payload,omitempty"`
+}
+
+type pagerDutyDetails struct {
+	Summary       string           `json:"summary"`
+	Source        string           `json:"source"`
+	Severity      string           `json:"severity"`
+	Timestamp     string           `json:"timestamp"`
+	Component     string           `json:"component"`
+	CustomDetails HealthTransition `json:"custom_details"`
+}
+
+func pagerDutyPayload(routingKey string, t HealthTransition) pagerDutyEvent {
+	event := pagerDutyEvent{
+		RoutingKey: routingKey,
+		DedupKey:   "aura-instance-health-" + t.InstanceID,
+	}
+	if t.Status == HealthStatusHealthy {
+		event.EventAction = "resolve"
+		return event
+	}
+	severity := "warning"
+	if t.Status == HealthStatusCritical {
+		severity = "critical"
+	}
+	event.EventAction = "trigger"
+	event.Payload = &pagerDutyDetails{
+		Summary:       transitionSummary(t),
+		Source:        t.InstanceID,
+		Severity:      severity,
+		Timestamp:     t.Timestamp.UTC().Format(time.RFC3339),
+		Component:     "neo4j-aura",
+		CustomDetails: t,
+	}
+	return event
+}
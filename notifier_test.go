@@ -0,0 +1,299 @@
+package aura
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookRecorder is an httptest handler that records requests and replies
+// with the queued status codes, then 200.
+type webhookRecorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []recordedWebhook
+}
+
+type recordedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, recordedWebhook{header: req.Header.Clone(), body: body})
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		w.WriteHeader(status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *webhookRecorder) received() []recordedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]recordedWebhook(nil), r.requests...)
+}
+
+// newTestNotifier returns a notifier whose clock is controlled by the returned
+// pointer and whose retries do not sleep.
+func newTestNotifier(t *testing.T, webhooks []Webhook, opts ...NotifierOption) (*HealthNotifier, *time.Time) {
+	t.Helper()
+	n, err := newTestClientWithAPI(newMockAPIServiceRouter()).NewHealthNotifier(webhooks, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	n.sleep = func(context.Context, time.Duration) error { return nil }
+	return n, &now
+}
+
+func healthWithStatus(id, status string) *PrometheusHealthMetrics {
+	return &PrometheusHealthMetrics{InstanceID: id, OverallStatus: status, Issues: []string{"High CPU usage: 85.0%"}, Recommendations: []string{}}
+}
+
+func TestHealthNotifier_Transitions(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	n, now := newTestNotifier(t, []Webhook{{URL: srv.URL, Secret: "s3cret"}})
+	ctx := context.Background()
+
+	steps := []struct {
+		status     string
+		transition bool
+	}{
+		{HealthStatusHealthy, false}, // baseline
+		{HealthStatusHealthy, false},
+		{HealthStatusUnknown, false}, // ignored
+		{HealthStatusWarning, true},
+		{HealthStatusCritical, true},
+		{HealthStatusHealthy, true},
+	}
+	for i, step := range steps {
+		*now = now.Add(time.Minute)
+		tr, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", step.status))
+		if err != nil {
+			t.Fatalf("step %d: expected no error, got %v", i, err)
+		}
+		if (tr != nil) != step.transition {
+			t.Fatalf("step %d: expected transition %v, got %+v", i, step.transition, tr)
+		}
+	}
+
+	reqs := rec.received()
+	if len(reqs) != 3 {
+		t.Fatalf("Expected 3 notifications, got %d", len(reqs))
+	}
+	var got HealthTransition
+	if err := json.Unmarshal(reqs[0].body, &got); err != nil {
+		t.Fatalf("Expected JSON payload, got %v", err)
+	}
+	if got.Event != "instance.health_changed" || got.PreviousStatus != HealthStatusHealthy || got.Status != HealthStatusWarning || got.Issues[0] != "High CPU usage: 85.0%" {
+		t.Errorf("Unexpected payload %+v", got)
+	}
+
+	ts := reqs[0].header.Get(WebhookTimestampHeader)
+	sig := reqs[0].header.Get(WebhookSignatureHeader)
+	if !VerifyWebhookSignature("s3cret", ts, reqs[0].body, sig) {
+		t.Errorf("Expected valid signature, got %q for timestamp %q", sig, ts)
+	}
+	if VerifyWebhookSignature("other", ts, reqs[0].body, sig) {
+		t.Error("Expected signature to depend on the secret")
+	}
+}
+
+func TestHealthNotifier_Dedup(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	n, now := newTestNotifier(t, []Webhook{{URL: srv.URL}}, WithNotifierDedupWindow(10*time.Minute), WithNotifierRecoveries(false))
+	ctx := context.Background()
+
+	observe := func(status string) {
+		*now = now.Add(time.Minute)
+		if _, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", status)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	observe(HealthStatusHealthy)
+	observe(HealthStatusWarning) // sent
+	observe(HealthStatusHealthy) // recovery not sent
+	observe(HealthStatusWarning) // suppressed: still the last status sent
+	observe(HealthStatusHealthy)
+	if n := len(rec.received()); n != 1 {
+		t.Fatalf("Expected flapping to be de-duplicated to 1 notification, got %d", n)
+	}
+
+	*now = now.Add(10 * time.Minute)
+	observe(HealthStatusWarning)
+	if n := len(rec.received()); n != 2 {
+		t.Errorf("Expected a notification after the window, got %d", n)
+	}
+}
+
+func TestHealthNotifier_DedupTriggerAfterResolve(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	n, now := newTestNotifier(t, []Webhook{{URL: srv.URL}}, WithNotifierDedupWindow(10*time.Minute))
+	ctx := context.Background()
+
+	var got []string
+	for _, status := range []string{HealthStatusHealthy, HealthStatusCritical, HealthStatusHealthy, HealthStatusCritical} {
+		*now = now.Add(time.Minute)
+		if _, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", status)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	for _, req := range rec.received() {
+		var tr HealthTransition
+		if err := json.Unmarshal(req.body, &tr); err != nil {
+			t.Fatalf("Expected JSON payload, got %v", err)
+		}
+		got = append(got, tr.Status)
+	}
+	// The resolve was delivered, so the receiver must be triggered again.
+	want := []string{HealthStatusCritical, HealthStatusHealthy, HealthStatusCritical}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected notifications %v, got %v", want, got)
+	}
+}
+
+func TestHealthNotifier_FailedDeliveryRetriedOnNextObserve(t *testing.T) {
+	rec := &webhookRecorder{statuses: []int{http.StatusBadRequest}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	n, now := newTestNotifier(t, []Webhook{{URL: srv.URL}}, WithNotifierDedupWindow(10*time.Minute))
+	ctx := context.Background()
+
+	n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusHealthy))
+	if _, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusCritical)); err == nil {
+		t.Fatal("Expected the delivery error")
+	}
+	*now = now.Add(time.Minute)
+	tr, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusCritical))
+	if err != nil || tr == nil || tr.PreviousStatus != HealthStatusHealthy {
+		t.Fatalf("Expected the failed transition to be notified again, got %+v, %v", tr, err)
+	}
+	if got := len(rec.received()); got != 2 {
+		t.Errorf("Expected the notification to be sent again, got %d attempts", got)
+	}
+	if tr, _ := n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusCritical)); tr != nil {
+		t.Errorf("Expected no transition once delivered, got %+v", tr)
+	}
+}
+
+func TestHealthNotifier_Retries(t *testing.T) {
+	rec := &webhookRecorder{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	n, _ := newTestNotifier(t, []Webhook{{URL: srv.URL}}, WithNotifierRetries(2, time.Millisecond))
+	ctx := context.Background()
+
+	n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusHealthy))
+	if _, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusCritical)); err != nil {
+		t.Fatalf("Expected delivery after retries, got %v", err)
+	}
+	if got := len(rec.received()); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+
+	// Client errors are not retried.
+	rec.statuses = []int{http.StatusBadRequest}
+	_, err := n.Observe(ctx, healthWithStatus("aaaaaaaa", HealthStatusHealthy))
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Expected 400 error, got %v", err)
+	}
+	if got := len(rec.received()); got != 4 {
+		t.Errorf("Expected no retry on 400, got %d attempts", got)
+	}
+}
+
+func TestHealthNotifier_Formats(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	n, _ := newTestNotifier(t, []Webhook{
+		{URL: srv.URL + "/slack", Format: WebhookSlack},
+		{URL: srv.URL + "/pd", Format: WebhookPagerDuty, RoutingKey: "R0UT1NG"},
+	})
+	ctx := context.Background()
+
+	report := &FleetHealthReport{Instances: []FleetInstanceHealth{
+		{InstanceID: "aaaaaaaa", Name: "prod", Health: healthWithStatus("aaaaaaaa", HealthStatusHealthy)},
+	}}
+	if _, err := n.ObserveFleet(ctx, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	report.Instances[0].Health = healthWithStatus("aaaaaaaa", HealthStatusCritical)
+	transitions, err := n.ObserveFleet(ctx, report)
+	if err != nil || len(transitions) != 1 || transitions[0].InstanceName != "prod" {
+		t.Fatalf("Expected one named transition, got %+v %v", transitions, err)
+	}
+	report.Instances[0].Health = healthWithStatus("aaaaaaaa", HealthStatusHealthy)
+	if _, err := n.ObserveFleet(ctx, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reqs := rec.received()
+	if len(reqs) != 4 {
+		t.Fatalf("Expected 4 requests, got %d", len(reqs))
+	}
+
+	var slack slackMessage
+	if err := json.Unmarshal(reqs[0].body, &slack); err != nil {
+		t.Fatalf("Expected Slack payload, got %v", err)
+	}
+	if slack.Text != "Aura instance prod (aaaaaaaa) is critical (was healthy)" || slack.Attachments[0].Color != "danger" {
+		t.Errorf("Unexpected Slack payload %+v", slack)
+	}
+
+	var trigger, resolve pagerDutyEvent
+	if err := json.Unmarshal(reqs[1].body, &trigger); err != nil {
+		t.Fatalf("Expected PagerDuty payload, got %v", err)
+	}
+	if err := json.Unmarshal(reqs[3].body, &resolve); err != nil {
+		t.Fatalf("Expected PagerDuty payload, got %v", err)
+	}
+	if trigger.EventAction != "trigger" || trigger.RoutingKey != "R0UT1NG" || trigger.Payload.Severity != "critical" {
+		t.Errorf("Unexpected trigger %+v", trigger)
+	}
+	if resolve.EventAction != "resolve" || resolve.DedupKey != trigger.DedupKey || resolve.Payload != nil {
+		t.Errorf("Unexpected resolve %+v", resolve)
+	}
+}
+
+func TestNewHealthNotifier_Invalid(t *testing.T) {
+	client := newTestClientWithAPI(newMockAPIServiceRouter())
+	tests := []struct {
+		name     string
+		webhooks []Webhook
+		opts     []NotifierOption
+	}{
+		{"no webhooks", nil, nil},
+		{"relative URL", []Webhook{{URL: "/hook"}}, nil},
+		{"unknown format", []Webhook{{URL: "https://example.com", Format: "teams"}}, nil},
+		{"pagerduty without key", []Webhook{{Format: WebhookPagerDuty}}, nil},
+		{"negative window", []Webhook{{URL: "https://example.com"}}, []NotifierOption{WithNotifierDedupWindow(-time.Second)}},
+		{"nil client", []Webhook{{URL: "https://example.com"}}, []NotifierOption{WithNotifierHTTPClient(nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.NewHealthNotifier(tt.webhooks, tt.opts...); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
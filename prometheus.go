@@ -3,15 +3,13 @@ package aura
 import (
 	"context"
 	"fmt"
-	"io"
 	"log/slog"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/LackOfMorals/aura-client/internal/api"
 	"github.com/LackOfMorals/aura-client/internal/utils"
 	dto "github.com/prometheus/client_model/go"
-	"github.com/prometheus/common/expfmt"
 )
 
 // ============================================================================
@@ -29,6 +27,20 @@ type PrometheusHealthMetrics struct {
 	OverallStatus   string            `json:"overall_status"`
 	Issues          []string          `json:"issues"`
 	Recommendations []string          `json:"recommendations"`
+	// Anomalies is set only when an AnomalyDetector is used.
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+
+	// unknown holds the healthFields names whose value could not be read
+	// from the scrape, or could not be computed yet, and so is left at 0.
+	unknown map[string]bool
+}
+
+// markUnknown records that the named health field has no value.
+func (m *PrometheusHealthMetrics) markUnknown(field string) {
+	if m.unknown == nil {
+		m.unknown = make(map[string]bool)
+	}
+	m.unknown[field] = true
 }
 
 // ResourceMetrics contains CPU and memory usage.
@@ -55,12 +67,43 @@ type StorageMetrics struct {
 	PageCacheHitRate float64 `json:"page_cache_hit_rate,omitempty"`
 }
 
+// MetricType is the Prometheus type of a metric family.
+type MetricType string
+
+// Metric types reported in PrometheusMetric.Type.
+const (
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeSummary   MetricType = "summary"
+	MetricTypeHistogram MetricType = "histogram"
+	MetricTypeUntyped   MetricType = "untyped"
+)
+
 // PrometheusMetric represents a single parsed metric from Prometheus exposition format.
+// For summaries and histograms Value holds the sample sum, as it always has;
+// the full distribution is available in Count, Sum, Quantiles and Buckets.
 type PrometheusMetric struct {
 	Name      string
 	Labels    map[string]string
 	Value     float64
 	Timestamp int64
+	Type      MetricType
+
+	// Count and Sum are the observation count and total for summaries and histograms.
+	Count float64
+	Sum   float64
+	// Quantiles holds the pre-computed quantiles of a summary.
+	Quantiles []SummaryQuantile
+	// Buckets holds the cumulative buckets of a histogram, sorted by upper
+	// bound and always ending with the +Inf bucket.
+	Buckets []HistogramBucket
+
+	// Created is when a counter, summary or histogram started counting, from an
+	// OpenMetrics _created series or a protobuf created timestamp. Zero if not exposed.
+	Created time.Time
+	// Exemplar is the exemplar of a counter, if exposed. Histogram exemplars are
+	// kept on their buckets.
+	Exemplar *Exemplar
 }
 
 // PrometheusMetricsResponse contains parsed metrics from the raw endpoint.
@@ -77,10 +120,25 @@ type prometheusService struct {
 	api     api.RequestService
 	timeout time.Duration
 	logger  *slog.Logger
+
+	// samplers holds one MetricsSampler per instance so that successive
+	// GetInstanceHealth calls can turn counters into rates. Created lazily
+	// and evicted once unused for longer than defaultSampleStaleness.
+	mu       sync.Mutex
+	samplers map[string]*instanceSampler
+}
+
+// instanceSampler is a MetricsSampler and when it was last used.
+type instanceSampler struct {
+	sampler  *MetricsSampler
+	lastUsed time.Time
 }
 
-// FetchRawMetrics fetches and parses raw Prometheus metrics from an Aura metrics endpoint.
-func (p *prometheusService) FetchRawMetrics(ctx context.Context, prometheusURL string) (*PrometheusMetricsResponse, error) {
+// FetchRawMetrics fetches and parses raw Prometheus metrics from an Aura metrics
+// endpoint. The exposition format is negotiated with an Accept header, which
+// can be set with WithAcceptFormats, and the response is parsed according to
+// its Content-Type.
+func (p *prometheusService) FetchRawMetrics(ctx context.Context, prometheusURL string, opts ...FetchOption) (*PrometheusMetricsResponse, error) {
 	if err := ctx.Err(); err != nil {
 		p.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
 		return nil, err
@@ -88,8 +146,15 @@ func (p *prometheusService) FetchRawMetrics(ctx context.Context, prometheusURL s
 	ctx, cancel := context.WithTimeout(ctx, p.timeout)
 	defer cancel()
 
+	options := defaultFetchOptions()
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return nil, err
+		}
+	}
+
 	p.logger.DebugContext(ctx, "fetching raw Prometheus metrics", slog.String("url", prometheusURL))
-	return p.doFetchRawMetrics(ctx, prometheusURL)
+	return p.doFetchRawMetrics(ctx, prometheusURL, options)
 }
 
 // doFetchRawMetrics performs the HTTP fetch and metric parse. It assumes the
@@ -97,18 +162,18 @@ func (p *prometheusService) FetchRawMetrics(ctx context.Context, prometheusURL s
 // timeout is set here. This separation ensures that composed callers such as
 // GetInstanceHealth apply the deadline exactly once rather than stacking two
 // independent timeouts that would shorten the effective budget unexpectedly.
-func (p *prometheusService) doFetchRawMetrics(ctx context.Context, prometheusURL string) (*PrometheusMetricsResponse, error) {
+func (p *prometheusService) doFetchRawMetrics(ctx context.Context, prometheusURL string, options fetchOptions) (*PrometheusMetricsResponse, error) {
 	if prometheusURL == "" {
 		return nil, fmt.Errorf("prometheus URL cannot be empty")
 	}
 
-	resp, err := p.api.Get(ctx, prometheusURL)
+	resp, err := p.api.GetWithHeaders(ctx, prometheusURL, map[string]string{"Accept": options.accept})
 	if err != nil {
 		p.logger.ErrorContext(ctx, "failed to fetch raw metrics", slog.String("error", err.Error()))
 		return nil, err
 	}
 
-	metrics, err := p.parsePrometheusMetrics(resp.Body)
+	metrics, err := p.parsePrometheusMetrics(resp.Body, resp.Header.Get("Content-Type"))
 	if err != nil {
 		p.logger.ErrorContext(ctx, "failed to parse metrics", slog.String("error", err.Error()))
 		return nil, err
@@ -118,17 +183,21 @@ func (p *prometheusService) doFetchRawMetrics(ctx context.Context, prometheusURL
 	return metrics, nil
 }
 
-// parsePrometheusMetrics parses Prometheus metrics using the official client library.
-func (p *prometheusService) parsePrometheusMetrics(data []byte) (*PrometheusMetricsResponse, error) {
-	result := &PrometheusMetricsResponse{
-		Metrics: make(map[string][]PrometheusMetric),
+// parsePrometheusMetrics parses a metrics response in the format given by its
+// Content-Type: Prometheus text, OpenMetrics text or delimited protobuf. All
+// three produce the same PrometheusMetricsResponse for the same metrics.
+func (p *prometheusService) parsePrometheusMetrics(data []byte, contentType string) (*PrometheusMetricsResponse, error) {
+	format, err := detectExposition(contentType, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus metrics: %w", err)
+	}
+	metricFamilies, err := decodeExposition(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus metrics as %s: %w", format, err)
 	}
 
-	reader := strings.NewReader(string(data))
-	var parser expfmt.TextParser
-	metricFamilies, err := parser.TextToMetricFamilies(reader)
-	if err != nil && err != io.EOF {
-		return nil, fmt.Errorf("failed to parse Prometheus metrics: %w", err)
+	result := &PrometheusMetricsResponse{
+		Metrics: make(map[string][]PrometheusMetric),
 	}
 
 	for name, mf := range metricFamilies {
@@ -146,24 +215,51 @@ func (p *prometheusService) parsePrometheusMetrics(data []byte) (*PrometheusMetr
 
 			switch mf.GetType() {
 			case dto.MetricType_COUNTER:
-				if m.Counter != nil && m.Counter.Value != nil {
-					metric.Value = *m.Counter.Value
+				metric.Type = MetricTypeCounter
+				if m.Counter != nil {
+					metric.Value = m.Counter.GetValue()
+					metric.Created = createdFromDTO(m.Counter.CreatedTimestamp)
+					metric.Exemplar = exemplarFromDTO(m.Counter.Exemplar)
 				}
 			case dto.MetricType_GAUGE:
+				metric.Type = MetricTypeGauge
 				if m.Gauge != nil && m.Gauge.Value != nil {
 					metric.Value = *m.Gauge.Value
 				}
 			case dto.MetricType_UNTYPED:
+				metric.Type = MetricTypeUntyped
 				if m.Untyped != nil && m.Untyped.Value != nil {
 					metric.Value = *m.Untyped.Value
 				}
 			case dto.MetricType_SUMMARY:
-				if m.Summary != nil && m.Summary.SampleSum != nil {
-					metric.Value = *m.Summary.SampleSum
+				metric.Type = MetricTypeSummary
+				if m.Summary != nil {
+					metric.Sum = m.Summary.GetSampleSum()
+					metric.Count = float64(m.Summary.GetSampleCount())
+					metric.Value = metric.Sum
+					metric.Created = createdFromDTO(m.Summary.CreatedTimestamp)
+					for _, q := range m.Summary.Quantile {
+						metric.Quantiles = append(metric.Quantiles, SummaryQuantile{Quantile: q.GetQuantile(), Value: q.GetValue()})
+					}
 				}
-			case dto.MetricType_HISTOGRAM:
-				if m.Histogram != nil && m.Histogram.SampleSum != nil {
-					metric.Value = *m.Histogram.SampleSum
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				metric.Type = MetricTypeHistogram
+				if m.Histogram != nil {
+					metric.Sum = m.Histogram.GetSampleSum()
+					metric.Count = float64(m.Histogram.GetSampleCount())
+					if m.Histogram.SampleCountFloat != nil {
+						metric.Count = m.Histogram.GetSampleCountFloat()
+					}
+					metric.Value = metric.Sum
+					metric.Created = createdFromDTO(m.Histogram.CreatedTimestamp)
+					for _, b := range m.Histogram.Bucket {
+						count := float64(b.GetCumulativeCount())
+						if b.CumulativeCountFloat != nil {
+							count = b.GetCumulativeCountFloat()
+						}
+						metric.Buckets = append(metric.Buckets, HistogramBucket{UpperBound: b.GetUpperBound(), CumulativeCount: count, Exemplar: exemplarFromDTO(b.Exemplar)})
+					}
+					metric.Buckets = normaliseBuckets(metric.Buckets, metric.Count)
 				}
 			}
 
@@ -178,8 +274,10 @@ func (p *prometheusService) parsePrometheusMetrics(data []byte) (*PrometheusMetr
 	return result, nil
 }
 
-// GetInstanceHealth retrieves comprehensive health metrics for an instance.
-func (p *prometheusService) GetInstanceHealth(ctx context.Context, instanceID string, prometheusURL string) (*PrometheusHealthMetrics, error) {
+// GetInstanceHealth retrieves comprehensive health metrics for an instance and
+// assesses them with DefaultHealthRules, or the rule set given with
+// WithHealthRules.
+func (p *prometheusService) GetInstanceHealth(ctx context.Context, instanceID string, prometheusURL string, opts ...HealthOption) (*PrometheusHealthMetrics, error) {
 	if err := ctx.Err(); err != nil {
 		p.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
 		return nil, err
@@ -197,13 +295,60 @@ func (p *prometheusService) GetInstanceHealth(ctx context.Context, instanceID st
 		return nil, fmt.Errorf("prometheus URL cannot be empty")
 	}
 
+	options := healthOptions{rules: DefaultHealthRules()}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return nil, err
+		}
+	}
+
 	// doFetchRawMetrics is used directly here so the context deadline set above
 	// is applied exactly once.
-	rawMetrics, err := p.doFetchRawMetrics(ctx, prometheusURL)
+	rawMetrics, err := p.doFetchRawMetrics(ctx, prometheusURL, defaultFetchOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch metrics: %w", err)
 	}
 
+	metrics := p.assessInstanceHealth(ctx, instanceID, rawMetrics, options)
+
+	p.logger.InfoContext(ctx, "instance health metrics retrieved",
+		slog.String("instanceID", instanceID),
+		slog.String("status", metrics.OverallStatus))
+
+	return metrics, nil
+}
+
+// InstanceHealthFromMetrics computes health metrics for an instance from a
+// scrape that has already been fetched, e.g. one instance's share of a tenant
+// endpoint scrape. The query rate uses the same per-instance sampler as
+// GetInstanceHealth.
+func (p *prometheusService) InstanceHealthFromMetrics(ctx context.Context, instanceID string, metrics *PrometheusMetricsResponse, opts ...HealthOption) (*PrometheusHealthMetrics, error) {
+	if err := ctx.Err(); err != nil {
+		p.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err := utils.ValidateInstanceID(instanceID); err != nil {
+		return nil, err
+	}
+	if metrics == nil {
+		return nil, fmt.Errorf("metrics response must not be nil")
+	}
+
+	options := healthOptions{rules: DefaultHealthRules()}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.assessInstanceHealth(ctx, instanceID, metrics, options), nil
+}
+
+// assessInstanceHealth derives health metrics for instanceID from rawMetrics,
+// evaluates the configured rules against them and, when a detector is set,
+// checks them for anomalies.
+func (p *prometheusService) assessInstanceHealth(ctx context.Context, instanceID string, rawMetrics *PrometheusMetricsResponse, options healthOptions) *PrometheusHealthMetrics {
 	metrics := &PrometheusHealthMetrics{
 		InstanceID:      instanceID,
 		Timestamp:       time.Now(),
@@ -214,26 +359,37 @@ func (p *prometheusService) GetInstanceHealth(ctx context.Context, instanceID st
 	if cpuUsage, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_aura_cpu_usage", nil); err == nil {
 		if cpuLimit, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_aura_cpu_limit", nil); err == nil && cpuLimit > 0 {
 			metrics.Resources.CPUUsagePercent = (cpuUsage / cpuLimit) * 100
+		} else {
+			metrics.markUnknown("cpu_usage_percent")
 		}
 	} else {
+		metrics.markUnknown("cpu_usage_percent")
 		p.logger.WarnContext(ctx, "failed to get CPU usage", slog.String("error", err.Error()))
 	}
 
 	if heapRatio, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_dbms_vm_heap_used_ratio", nil); err == nil {
 		metrics.Resources.MemoryUsagePercent = heapRatio * 100
 	} else {
+		metrics.markUnknown("memory_usage_percent")
 		p.logger.WarnContext(ctx, "failed to get memory usage", slog.String("error", err.Error()))
 	}
 
-	if successCount, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_db_query_execution_success_total", nil); err == nil {
-		metrics.Query.QueriesPerSecond = successCount
+	// neo4j_db_query_execution_success_total is a cumulative counter, so the
+	// query rate needs a previous sample. The first call for an instance leaves
+	// QueriesPerSecond at 0; later calls report the rate since the last call.
+	now := time.Now()
+	rates := p.samplerFor(instanceID, now).Observe(rawMetrics, now)
+	if qps, err := rates.Rate("neo4j_db_query_execution_success_total", nil); err == nil {
+		metrics.Query.QueriesPerSecond = qps
 	} else {
-		p.logger.WarnContext(ctx, "failed to get query count", slog.String("error", err.Error()))
+		metrics.markUnknown("queries_per_second")
+		p.logger.DebugContext(ctx, "query rate not yet available", slog.String("error", err.Error()))
 	}
 
 	if latency, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_db_query_execution_internal_latency_q50", nil); err == nil {
 		metrics.Query.AvgLatencyMS = latency
 	} else {
+		metrics.markUnknown("avg_latency_ms")
 		p.logger.WarnContext(ctx, "failed to get query latency", slog.String("error", err.Error()))
 	}
 
@@ -246,7 +402,7 @@ func (p *prometheusService) GetInstanceHealth(ctx context.Context, instanceID st
 	// Attempt to read the configured maximum from a Prometheus metric.
 	// If the metric is not available (varies by Aura plan / Neo4j version)
 	// MaxConnections stays at 0 and UsagePercent is left at 0 (unknown);
-	// the connection threshold check in assessHealth is skipped in that case.
+	// the default connection rule requires max_connections and is skipped.
 	if maxConns, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_dbms_bolt_connections_max_count", nil); err == nil && maxConns > 0 {
 		metrics.Connections.MaxConnections = int(maxConns)
 		metrics.Connections.UsagePercent = float64(metrics.Connections.ActiveConnections) / maxConns * 100
@@ -255,16 +411,46 @@ func (p *prometheusService) GetInstanceHealth(ctx context.Context, instanceID st
 	if hitRate, err := p.GetMetricValue(ctx, rawMetrics, "neo4j_dbms_page_cache_hit_ratio_per_minute", nil); err == nil {
 		metrics.Storage.PageCacheHitRate = hitRate * 100
 	} else {
+		metrics.markUnknown("page_cache_hit_rate")
 		p.logger.WarnContext(ctx, "failed to get page cache hit rate", slog.String("error", err.Error()))
 	}
 
-	metrics.OverallStatus = p.assessHealth(metrics)
-
-	p.logger.InfoContext(ctx, "instance health metrics retrieved",
-		slog.String("instanceID", instanceID),
-		slog.String("status", metrics.OverallStatus))
+	status, err := options.rules.Evaluate(metrics, rawMetrics)
+	if err != nil {
+		p.logger.WarnContext(ctx, "failed to evaluate health rules", slog.String("instanceID", instanceID), slog.String("error", err.Error()))
+	}
+	metrics.OverallStatus = status
+	if options.detector != nil {
+		if anomalies := options.detector.Observe(instanceID, metrics, rawMetrics); len(anomalies) > 0 {
+			p.logger.InfoContext(ctx, "metric anomalies detected", slog.String("instanceID", instanceID), slog.Int("count", len(anomalies)))
+		}
+	}
+	return metrics
+}
 
-	return metrics, nil
+// samplerFor returns the MetricsSampler for instanceID, creating it on first
+// use. Samplers not used for longer than defaultSampleStaleness are evicted:
+// every series they hold is stale by then, so an instance that comes back
+// starts afresh either way, and the map does not grow with every instance a
+// long-running client has ever seen.
+func (p *prometheusService) samplerFor(instanceID string, now time.Time) *MetricsSampler {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.samplers == nil {
+		p.samplers = make(map[string]*instanceSampler)
+	}
+	for id, entry := range p.samplers {
+		if now.Sub(entry.lastUsed) > defaultSampleStaleness {
+			delete(p.samplers, id)
+		}
+	}
+	entry, ok := p.samplers[instanceID]
+	if !ok {
+		entry = &instanceSampler{sampler: NewMetricsSampler(0)}
+		p.samplers[instanceID] = entry
+	}
+	entry.lastUsed = now
+	return entry.sampler
 }
 
 // GetMetricValue retrieves a specific metric value by name and optional label filters.
@@ -320,69 +506,3 @@ func (p *prometheusService) GetMetricValue(ctx context.Context, metrics *Prometh
 	}
 	return sum / float64(len(matchingMetrics)), nil
 }
-
-// assessHealth analyzes metrics and determines overall health status.
-// Severity increases monotonically: healthy → warning → critical.
-// A higher-severity condition always wins over a lower one.
-func (p *prometheusService) assessHealth(metrics *PrometheusHealthMetrics) string {
-	status := "healthy"
-
-	// elevate raises status to the requested level only if it is a higher
-	// severity than the current one. This ensures critical is never downgraded
-	// to warning even when multiple conditions are evaluated.
-	elevate := func(to string) {
-		if to == "critical" || (to == "warning" && status == "healthy") {
-			status = to
-		}
-	}
-
-	switch {
-	case metrics.Resources.CPUUsagePercent > 95:
-		metrics.Issues = append(metrics.Issues, fmt.Sprintf("Critical CPU usage: %.1f%%", metrics.Resources.CPUUsagePercent))
-		metrics.Recommendations = append(metrics.Recommendations, "Scale to a larger instance size immediately")
-		elevate("critical")
-	case metrics.Resources.CPUUsagePercent > 80:
-		metrics.Issues = append(metrics.Issues, fmt.Sprintf("High CPU usage: %.1f%%", metrics.Resources.CPUUsagePercent))
-		metrics.Recommendations = append(metrics.Recommendations, "Consider scaling to a larger instance size")
-		elevate("warning")
-	}
-
-	switch {
-	case metrics.Resources.MemoryUsagePercent > 95:
-		metrics.Issues = append(metrics.Issues, fmt.Sprintf("Critical memory usage: %.1f%%", metrics.Resources.MemoryUsagePercent))
-		metrics.Recommendations = append(metrics.Recommendations, "Scale to a larger memory instance immediately")
-		elevate("critical")
-	case metrics.Resources.MemoryUsagePercent > 85:
-		metrics.Issues = append(metrics.Issues, fmt.Sprintf("High memory usage: %.1f%%", metrics.Resources.MemoryUsagePercent))
-		metrics.Recommendations = append(metrics.Recommendations, "Consider scaling to a larger memory instance")
-		elevate("warning")
-	}
-
-	if metrics.Connections.MaxConnections > 0 {
-		switch {
-		case metrics.Connections.UsagePercent > 95:
-			metrics.Issues = append(metrics.Issues, fmt.Sprintf("Critical connection usage: %.1f%%", metrics.Connections.UsagePercent))
-			metrics.Recommendations = append(metrics.Recommendations, "Reduce active connections immediately; review connection pooling")
-			elevate("critical")
-		case metrics.Connections.UsagePercent > 80:
-			metrics.Issues = append(metrics.Issues, fmt.Sprintf("High connection usage: %.1f%%", metrics.Connections.UsagePercent))
-			metrics.Recommendations = append(metrics.Recommendations, "Review connection pooling configuration in your application")
-			elevate("warning")
-		}
-	}
-
-	if metrics.Storage.PageCacheHitRate > 0 {
-		switch {
-		case metrics.Storage.PageCacheHitRate < 20:
-			metrics.Issues = append(metrics.Issues, fmt.Sprintf("Critical page cache hit rate: %.1f%%", metrics.Storage.PageCacheHitRate))
-			metrics.Recommendations = append(metrics.Recommendations, "Increase page cache size immediately; query performance is severely degraded")
-			elevate("critical")
-		case metrics.Storage.PageCacheHitRate < 50:
-			metrics.Issues = append(metrics.Issues, fmt.Sprintf("Low page cache hit rate: %.1f%%", metrics.Storage.PageCacheHitRate))
-			metrics.Recommendations = append(metrics.Recommendations, "Consider increasing page cache size for better performance")
-			elevate("warning")
-		}
-	}
-
-	return status
-}
@@ -0,0 +1,382 @@
+package aura
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// AnomalyMethod is the statistic an AnomalyDetector uses as its baseline.
+type AnomalyMethod string
+
+// Supported anomaly detection methods.
+const (
+	// AnomalyEWMA scores values against an exponentially weighted moving
+	// average and standard deviation. It adapts quickly and needs no history.
+	AnomalyEWMA AnomalyMethod = "ewma"
+	// AnomalyMAD scores values against the median and median absolute
+	// deviation of a sliding window. It is robust to earlier outliers.
+	AnomalyMAD AnomalyMethod = "mad"
+)
+
+// AnomalyDirection restricts which deviations are reported.
+type AnomalyDirection string
+
+// Supported directions.
+const (
+	AnomalyUp   AnomalyDirection = "up"
+	AnomalyDown AnomalyDirection = "down"
+	AnomalyBoth AnomalyDirection = "both"
+)
+
+// AnomalyDetector defaults, used when the corresponding field of
+// AnomalyDetectorConfig is zero.
+const (
+	defaultAnomalyAlpha      = 0.2
+	defaultAnomalyWindow     = 30
+	defaultAnomalyThreshold  = 3.0
+	defaultAnomalyMinSamples = 10
+	defaultAnomalyStaleAfter = 24 * time.Hour
+)
+
+// anomalyMinRelativeSpread is the smallest spread, relative to the baseline,
+// used when scoring. Without it a perfectly flat series would flag any change,
+// however small, with near-certain confidence.
+const anomalyMinRelativeSpread = 0.01
+
+// AnomalyWatch names one value an AnomalyDetector tracks. Like HealthRule, it
+// is either a PrometheusHealthMetrics field (Metric) or a metric query against
+// the raw scrape (Query), in which case every returned series is tracked
+// separately.
+type AnomalyWatch struct {
+	Metric    string           `json:"metric,omitempty" yaml:"metric,omitempty"`
+	Query     string           `json:"query,omitempty" yaml:"query,omitempty"`
+	Direction AnomalyDirection `json:"direction" yaml:"direction"`
+
+	query *MetricQuery // Query, parsed by NewAnomalyDetector
+}
+
+// AnomalyDetectorConfig configures NewAnomalyDetector. Zero values select the
+// defaults.
+type AnomalyDetectorConfig struct {
+	// Method defaults to AnomalyEWMA.
+	Method AnomalyMethod
+	// Alpha is the EWMA smoothing factor in (0, 1]. Defaults to 0.2.
+	Alpha float64
+	// Window is the number of samples kept per series for AnomalyMAD. Defaults to 30.
+	Window int
+	// Threshold is the score (standard deviations, or scaled MADs) above which
+	// a value is anomalous. Defaults to 3.
+	Threshold float64
+	// MinSamples is the number of observations needed before a series is
+	// scored, so the baseline can settle. Defaults to 10.
+	MinSamples int
+	// StaleAfter is how long a series may go unobserved, for example because
+	// its instance was deleted, before its history is evicted. A series
+	// observed again after that starts a new baseline. Defaults to 24 hours.
+	StaleAfter time.Duration
+	// Watches lists the values to track. Defaults to DefaultAnomalyWatches.
+	Watches []AnomalyWatch
+	// RaiseStatus raises a healthy OverallStatus to warning when an anomaly
+	// is found.
+	RaiseStatus bool
+}
+
+// Anomaly is one value that deviated from its baseline.
+type Anomaly struct {
+	Metric    string            `json:"metric"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+	Expected  float64           `json:"expected"`
+	Score     float64           `json:"score"`
+	Direction AnomalyDirection  `json:"direction"`
+	// Confidence is 1 - 1/Score², Chebyshev's bound on how unlikely the
+	// deviation is for any distribution with the baseline's mean and spread.
+	Confidence float64 `json:"confidence"`
+}
+
+// AnomalyDetector keeps rolling statistics for the watched values of each
+// instance and flags values that deviate sharply from them, catching slow
+// degradations and sudden changes that static thresholds miss. Pass one to
+// GetInstanceHealth with WithAnomalyDetector and reuse it across calls; it is
+// safe for concurrent use.
+type AnomalyDetector struct {
+	cfg AnomalyDetectorConfig
+
+	now func() time.Time
+
+	mu     sync.Mutex
+	series map[string]*anomalySeries // keyed by instance ID and series
+}
+
+// anomalySeries is the rolling state for one tracked series.
+type anomalySeries struct {
+	n        int
+	mean     float64   // EWMA
+	variance float64   // EWMA
+	window   []float64 // MAD, oldest first
+	lastSeen time.Time
+}
+
+// DefaultAnomalyWatches tracks sudden latency jumps, page cache hit rate
+// collapses, CPU spikes and shifts in query rate.
+func DefaultAnomalyWatches() []AnomalyWatch {
+	return []AnomalyWatch{
+		{Metric: "avg_latency_ms", Direction: AnomalyUp},
+		{Metric: "page_cache_hit_rate", Direction: AnomalyDown},
+		{Metric: "cpu_usage_percent", Direction: AnomalyUp},
+		{Metric: "queries_per_second", Direction: AnomalyBoth},
+	}
+}
+
+// ============================================================================
+// Detector
+// ============================================================================
+
+// NewAnomalyDetector returns an AnomalyDetector with no history.
+func NewAnomalyDetector(cfg AnomalyDetectorConfig) (*AnomalyDetector, error) {
+	var errs ValidationErrors
+	if cfg.Method == "" {
+		cfg.Method = AnomalyEWMA
+	}
+	if cfg.Method != AnomalyEWMA && cfg.Method != AnomalyMAD {
+		errs.add("method", string(cfg.Method), errors.New("must be ewma or mad"))
+	}
+	if cfg.Alpha == 0 {
+		cfg.Alpha = defaultAnomalyAlpha
+	}
+	if cfg.Alpha < 0 || cfg.Alpha > 1 || math.IsNaN(cfg.Alpha) {
+		errs.add("alpha", fmt.Sprint(cfg.Alpha), errors.New("must be greater than 0 and at most 1"))
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultAnomalyWindow
+	}
+	if cfg.Window < 3 {
+		errs.add("window", fmt.Sprint(cfg.Window), errors.New("must be at least 3"))
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = defaultAnomalyThreshold
+	}
+	if cfg.Threshold < 1 || math.IsNaN(cfg.Threshold) {
+		errs.add("threshold", fmt.Sprint(cfg.Threshold), errors.New("must be at least 1"))
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = defaultAnomalyMinSamples
+	}
+	if cfg.MinSamples < 2 {
+		errs.add("min_samples", fmt.Sprint(cfg.MinSamples), errors.New("must be at least 2"))
+	}
+	if cfg.Method == AnomalyMAD && cfg.MinSamples > cfg.Window {
+		errs.add("min_samples", fmt.Sprint(cfg.MinSamples), errors.New("must not exceed window"))
+	}
+	if cfg.StaleAfter == 0 {
+		cfg.StaleAfter = defaultAnomalyStaleAfter
+	}
+	if cfg.StaleAfter < 0 {
+		errs.add("stale_after", cfg.StaleAfter.String(), errors.New("must not be negative"))
+	}
+	if cfg.Watches == nil {
+		cfg.Watches = DefaultAnomalyWatches()
+	}
+	// Copy the watches so their parsed queries are not written back into
+	// the caller's slice.
+	cfg.Watches = append([]AnomalyWatch(nil), cfg.Watches...)
+	for i := range cfg.Watches {
+		w := &cfg.Watches[i]
+		field := fmt.Sprintf("watches[%d]", i)
+		switch {
+		case (w.Metric == "") == (w.Query == ""):
+			errs.add(field, "", errors.New("exactly one of metric or query is required"))
+		case w.Metric != "":
+			if _, ok := healthFields[w.Metric]; !ok {
+				errs.add(field+".metric", w.Metric, fmt.Errorf("must be one of %s", strings.Join(healthFieldNames(), ", ")))
+			}
+		default:
+			q, err := ParseMetricQuery(w.Query)
+			errs.add(field+".query", w.Query, err)
+			w.query = q
+		}
+		switch w.Direction {
+		case AnomalyUp, AnomalyDown, AnomalyBoth:
+		default:
+			errs.add(field+".direction", string(w.Direction), errors.New("must be up, down or both"))
+		}
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &AnomalyDetector{cfg: cfg, now: time.Now, series: make(map[string]*anomalySeries)}, nil
+}
+
+// WithAnomalyDetector makes GetInstanceHealth check the health metrics with
+// d after the health rules have been evaluated. Anomalies are added to
+// Issues, with their confidence, and to Anomalies.
+func WithAnomalyDetector(d *AnomalyDetector) HealthOption {
+	return func(o *healthOptions) error {
+		if d == nil {
+			return errors.New("anomaly detector must not be nil")
+		}
+		o.detector = d
+		return nil
+	}
+}
+
+// Observe scores the watched values of health (and raw, for query watches)
+// against the instance's history, then adds them to the history. Anomalies
+// are appended to health.Issues and health.Anomalies and returned, sorted by
+// descending score. Fields that GetInstanceHealth could not read, such as the
+// query rate before a second scrape, are skipped rather than scored as 0.
+func (d *AnomalyDetector) Observe(instanceID string, health *PrometheusHealthMetrics, raw *PrometheusMetricsResponse) []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Evict series not observed within StaleAfter, so that a detector
+	// watching a changing fleet does not keep the history of every instance
+	// it has ever seen.
+	now := d.now()
+	for key, s := range d.series {
+		if now.Sub(s.lastSeen) > d.cfg.StaleAfter {
+			delete(d.series, key)
+		}
+	}
+
+	var anomalies []Anomaly
+	for _, w := range d.cfg.Watches {
+		// A field that is missing from the scrape, or a rate that has no
+		// previous sample yet, reads 0; it is unknown, not a collapse.
+		if w.Metric != "" && health.unknown[w.Metric] {
+			continue
+		}
+		// Watches were validated and parsed by NewAnomalyDetector, so the
+		// only failure left is a nil raw, which yields no samples anyway.
+		samples, _ := healthValues(w.Metric, w.Query, w.query, health, raw)
+		for _, sample := range samples {
+			if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+				continue
+			}
+			key := instanceID + "/" + seriesKey(sample.Name, sample.Labels)
+			s, ok := d.series[key]
+			if !ok {
+				s = &anomalySeries{}
+				d.series[key] = s
+			}
+			if a, ok := d.score(s, w, sample); ok {
+				anomalies = append(anomalies, a)
+			}
+			d.update(s, sample.Value)
+			s.lastSeen = now
+		}
+	}
+
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].Score > anomalies[j].Score })
+	for _, a := range anomalies {
+		health.Issues = append(health.Issues, a.issue())
+		health.Anomalies = append(health.Anomalies, a)
+	}
+	if len(anomalies) > 0 && d.cfg.RaiseStatus && health.OverallStatus == HealthStatusHealthy {
+		health.OverallStatus = HealthStatusWarning
+	}
+	return anomalies
+}
+
+// Reset forgets the history of every instance.
+func (d *AnomalyDetector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.series = make(map[string]*anomalySeries)
+}
+
+// score compares sample against the series baseline.
+func (d *AnomalyDetector) score(s *anomalySeries, w AnomalyWatch, sample MetricSample) (Anomaly, bool) {
+	if s.n < d.cfg.MinSamples {
+		return Anomaly{}, false
+	}
+
+	var expected, spread float64
+	switch d.cfg.Method {
+	case AnomalyMAD:
+		expected = median(s.window)
+		deviations := make([]float64, len(s.window))
+		for i, v := range s.window {
+			deviations[i] = math.Abs(v - expected)
+		}
+		// 1.4826 scales the MAD to a standard deviation for normal data.
+		spread = 1.4826 * median(deviations)
+	default:
+		expected = s.mean
+		spread = math.Sqrt(s.variance)
+	}
+	spread = math.Max(spread, math.Max(anomalyMinRelativeSpread*math.Abs(expected), 1e-9))
+
+	deviation := sample.Value - expected
+	direction := AnomalyUp
+	if deviation < 0 {
+		direction = AnomalyDown
+	}
+	if w.Direction != AnomalyBoth && w.Direction != direction {
+		return Anomaly{}, false
+	}
+	score := math.Abs(deviation) / spread
+	if score < d.cfg.Threshold {
+		return Anomaly{}, false
+	}
+	return Anomaly{
+		Metric:     sample.Name,
+		Labels:     sample.Labels,
+		Value:      sample.Value,
+		Expected:   expected,
+		Score:      score,
+		Direction:  direction,
+		Confidence: 1 - 1/(score*score),
+	}, true
+}
+
+// update adds value to the series history.
+func (d *AnomalyDetector) update(s *anomalySeries, value float64) {
+	s.n++
+	switch d.cfg.Method {
+	case AnomalyMAD:
+		s.window = append(s.window, value)
+		if len(s.window) > d.cfg.Window {
+			s.window = s.window[1:]
+		}
+	default:
+		if s.n == 1 {
+			s.mean = value
+			return
+		}
+		diff := value - s.mean
+		incr := d.cfg.Alpha * diff
+		s.mean += incr
+		s.variance = (1 - d.cfg.Alpha) * (s.variance + diff*incr)
+	}
+}
+
+// issue renders the anomaly as a PrometheusHealthMetrics issue.
+func (a Anomaly) issue() string {
+	name := a.Metric
+	if len(a.Labels) > 0 {
+		name = seriesKey(a.Metric, a.Labels)
+	}
+	return fmt.Sprintf("Anomalous %s: %.1f, expected about %.1f (%.1fσ %s, confidence %.0f%%)",
+		name, a.Value, a.Expected, a.Score, a.Direction, a.Confidence*100)
+}
+
+// median returns the median of values without modifying them.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
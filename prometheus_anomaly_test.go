@@ -0,0 +1,282 @@
+package aura
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// observeLatency feeds one latency value for instance "a" to d.
+func observeLatency(d *AnomalyDetector, latency float64) (*PrometheusHealthMetrics, []Anomaly) {
+	health := &PrometheusHealthMetrics{OverallStatus: HealthStatusHealthy, Issues: []string{}}
+	health.Query.AvgLatencyMS = latency
+	return health, d.Observe("a", health, nil)
+}
+
+func TestAnomalyDetector_LatencyJump(t *testing.T) {
+	for _, method := range []AnomalyMethod{AnomalyEWMA, AnomalyMAD} {
+		t.Run(string(method), func(t *testing.T) {
+			d, err := NewAnomalyDetector(AnomalyDetectorConfig{
+				Method:  method,
+				Watches: []AnomalyWatch{{Metric: "avg_latency_ms", Direction: AnomalyUp}},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			for i := 0; i < 20; i++ {
+				if _, found := observeLatency(d, 50+float64(i%3)); len(found) != 0 {
+					t.Fatalf("Expected no anomaly at sample %d, got %+v", i, found)
+				}
+			}
+
+			health, found := observeLatency(d, 250)
+			if len(found) != 1 {
+				t.Fatalf("Expected one anomaly, got %+v", found)
+			}
+			a := found[0]
+			if a.Metric != "avg_latency_ms" || a.Value != 250 || a.Direction != AnomalyUp {
+				t.Errorf("Unexpected anomaly %+v", a)
+			}
+			if a.Expected < 49 || a.Expected > 53 {
+				t.Errorf("Expected baseline near 51, got %f", a.Expected)
+			}
+			if a.Confidence < 0.95 || a.Confidence >= 1 {
+				t.Errorf("Expected high confidence, got %f", a.Confidence)
+			}
+			if len(health.Issues) != 1 || !strings.HasPrefix(health.Issues[0], "Anomalous avg_latency_ms: 250.0") {
+				t.Errorf("Expected anomaly issue, got %v", health.Issues)
+			}
+			if len(health.Anomalies) != 1 || health.OverallStatus != HealthStatusHealthy {
+				t.Errorf("Expected anomaly recorded without status change, got %s %+v", health.OverallStatus, health.Anomalies)
+			}
+		})
+	}
+}
+
+func TestAnomalyDetector_MinSamples(t *testing.T) {
+	d, err := NewAnomalyDetector(AnomalyDetectorConfig{
+		MinSamples: 5,
+		Watches:    []AnomalyWatch{{Metric: "avg_latency_ms", Direction: AnomalyBoth}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		observeLatency(d, 50)
+	}
+	if _, found := observeLatency(d, 5000); len(found) != 0 {
+		t.Errorf("Expected no scoring before MinSamples, got %+v", found)
+	}
+
+	d.Reset()
+	for i := 0; i < 5; i++ {
+		observeLatency(d, 50)
+	}
+	if _, found := observeLatency(d, 5000); len(found) != 1 {
+		t.Errorf("Expected an anomaly once MinSamples is reached, got %+v", found)
+	}
+}
+
+func TestAnomalyDetector_Direction(t *testing.T) {
+	d, err := NewAnomalyDetector(AnomalyDetectorConfig{RaiseStatus: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	observe := func(hitRate float64) (*PrometheusHealthMetrics, []Anomaly) {
+		health := &PrometheusHealthMetrics{OverallStatus: HealthStatusHealthy}
+		health.Storage.PageCacheHitRate = hitRate
+		health.Query.AvgLatencyMS = 20
+		return health, d.Observe("a", health, nil)
+	}
+	for i := 0; i < 15; i++ {
+		observe(99)
+	}
+
+	// A rising hit rate is good news and is not reported.
+	if _, found := observe(100); len(found) != 0 {
+		t.Errorf("Expected upward change to be ignored, got %+v", found)
+	}
+	health, found := observe(60)
+	if len(found) != 1 || found[0].Metric != "page_cache_hit_rate" || found[0].Direction != AnomalyDown {
+		t.Fatalf("Expected page cache collapse, got %+v", found)
+	}
+	if health.OverallStatus != HealthStatusWarning {
+		t.Errorf("Expected RaiseStatus to raise status to warning, got %s", health.OverallStatus)
+	}
+
+	// History is kept per instance.
+	other := &PrometheusHealthMetrics{OverallStatus: HealthStatusHealthy}
+	other.Storage.PageCacheHitRate = 60
+	if found := d.Observe("b", other, nil); len(found) != 0 {
+		t.Errorf("Expected no history for another instance, got %+v", found)
+	}
+}
+
+func TestAnomalyDetector_QueryWatch(t *testing.T) {
+	d, err := NewAnomalyDetector(AnomalyDetectorConfig{
+		MinSamples: 3,
+		Watches:    []AnomalyWatch{{Query: `neo4j_aura_cpu_usage{availability_zone="a"}`, Direction: AnomalyUp}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	raw := func(v float64) *PrometheusMetricsResponse {
+		return &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{"neo4j_aura_cpu_usage": {
+			{Name: "neo4j_aura_cpu_usage", Labels: map[string]string{"availability_zone": "a"}, Value: v},
+			{Name: "neo4j_aura_cpu_usage", Labels: map[string]string{"availability_zone": "b"}, Value: 9},
+		}}}
+	}
+	for i := 0; i < 3; i++ {
+		d.Observe("a", &PrometheusHealthMetrics{}, raw(0.5))
+	}
+	health := &PrometheusHealthMetrics{}
+	found := d.Observe("a", health, raw(0.9))
+	if len(found) != 1 || found[0].Labels["availability_zone"] != "a" {
+		t.Fatalf("Expected one labelled anomaly, got %+v", found)
+	}
+	if !strings.Contains(health.Issues[0], `availability_zone="a"`) {
+		t.Errorf("Expected labels in issue, got %q", health.Issues[0])
+	}
+}
+
+func TestNewAnomalyDetector_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   AnomalyDetectorConfig
+		field string
+	}{
+		{"method", AnomalyDetectorConfig{Method: "zscore"}, "method"},
+		{"alpha", AnomalyDetectorConfig{Alpha: 1.5}, "alpha"},
+		{"threshold", AnomalyDetectorConfig{Threshold: 0.5}, "threshold"},
+		{"min samples over window", AnomalyDetectorConfig{Method: AnomalyMAD, Window: 5, MinSamples: 6}, "min_samples"},
+		{"unknown metric", AnomalyDetectorConfig{Watches: []AnomalyWatch{{Metric: "nope", Direction: AnomalyUp}}}, "watches[0].metric"},
+		{"bad query", AnomalyDetectorConfig{Watches: []AnomalyWatch{{Query: "sum(", Direction: AnomalyUp}}}, "watches[0].query"},
+		{"both", AnomalyDetectorConfig{Watches: []AnomalyWatch{{Metric: "cpu_usage_percent", Query: "x", Direction: AnomalyUp}}}, "watches[0]"},
+		{"direction", AnomalyDetectorConfig{Watches: []AnomalyWatch{{Metric: "cpu_usage_percent"}}}, "watches[0].direction"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAnomalyDetector(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Expected error for %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestPrometheusService_GetInstanceHealth_WithAnomalyDetector(t *testing.T) {
+	const url = "https://c9f0d13a.metrics.neo4j.io/prometheus"
+	mock := newMockAPIServiceRouter()
+	svc := &prometheusService{api: mock, timeout: 30 * time.Second, logger: testLogger()}
+	ctx := context.Background()
+
+	d, err := NewAnomalyDetector(AnomalyDetectorConfig{MinSamples: 5})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	scrape := func(cpu float64) {
+		mock.on("GET", url, []byte(fmt.Sprintf("neo4j_aura_cpu_usage %g\nneo4j_aura_cpu_limit 1\n", cpu)))
+	}
+
+	for i := 0; i < 5; i++ {
+		scrape(0.2)
+		health, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithAnomalyDetector(d))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(health.Anomalies) != 0 {
+			t.Fatalf("Expected no anomalies while learning, got %+v", health.Anomalies)
+		}
+	}
+
+	// 60% is below the static warning threshold but far from the baseline.
+	scrape(0.6)
+	health, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithAnomalyDetector(d))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(health.Anomalies) != 1 || health.Anomalies[0].Metric != "cpu_usage_percent" {
+		t.Errorf("Expected CPU anomaly, got %+v", health.Anomalies)
+	}
+	if health.OverallStatus != HealthStatusHealthy || len(health.Issues) != 1 {
+		t.Errorf("Expected healthy status with one issue, got %s %v", health.OverallStatus, health.Issues)
+	}
+
+	if _, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithAnomalyDetector(nil)); err == nil {
+		t.Error("Expected error for nil detector")
+	}
+}
+
+func TestPrometheusService_GetInstanceHealth_AnomalyUnknownValues(t *testing.T) {
+	const url = "https://c9f0d13a.metrics.neo4j.io/prometheus"
+	mock := newMockAPIServiceRouter()
+	svc := &prometheusService{api: mock, timeout: 30 * time.Second, logger: testLogger()}
+	ctx := context.Background()
+
+	d, err := NewAnomalyDetector(AnomalyDetectorConfig{MinSamples: 5})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mock.on("GET", url, []byte("neo4j_aura_cpu_usage 0.2\nneo4j_aura_cpu_limit 1\nneo4j_dbms_page_cache_hit_ratio_per_minute 0.99\n"))
+	for i := 0; i < 5; i++ {
+		if _, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithAnomalyDetector(d)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// The page cache metric disappearing is not a hit rate collapse to 0.
+	mock.on("GET", url, []byte("neo4j_aura_cpu_usage 0.2\nneo4j_aura_cpu_limit 1\n"))
+	health, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithAnomalyDetector(d))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(health.Anomalies) != 0 {
+		t.Errorf("Expected no anomalies for a missing metric, got %+v", health.Anomalies)
+	}
+
+	// Without a query counter there is never a rate, so it is never tracked.
+	for key := range d.series {
+		if strings.Contains(key, "queries_per_second") || strings.Contains(key, "avg_latency_ms") {
+			t.Errorf("Expected unknown values not to be tracked, got series %s", key)
+		}
+	}
+}
+
+func TestAnomalyDetector_EvictsStaleSeries(t *testing.T) {
+	d, err := NewAnomalyDetector(AnomalyDetectorConfig{
+		StaleAfter: time.Hour,
+		Watches:    []AnomalyWatch{{Metric: "avg_latency_ms", Direction: AnomalyUp}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	observe := func(instanceID string) {
+		health := &PrometheusHealthMetrics{Issues: []string{}}
+		health.Query.AvgLatencyMS = 50
+		d.Observe(instanceID, health, nil)
+	}
+	observe("a1b2c3d4")
+	observe("e5f6a7b8")
+	now = now.Add(time.Hour)
+	observe("a1b2c3d4")
+	now = now.Add(time.Minute)
+	observe("a1b2c3d4")
+
+	if len(d.series) != 1 || d.series["a1b2c3d4/avg_latency_ms{}"] == nil {
+		t.Errorf("Expected only the observed instance to be kept, got %v", d.series)
+	}
+	if got := d.series["a1b2c3d4/avg_latency_ms{}"].n; got != 3 {
+		t.Errorf("Expected the kept series to retain its history, got %d samples", got)
+	}
+
+	if _, err := NewAnomalyDetector(AnomalyDetectorConfig{StaleAfter: -time.Second}); err == nil {
+		t.Error("Expected an error for a negative StaleAfter")
+	}
+}
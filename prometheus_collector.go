@@ -0,0 +1,482 @@
+package aura
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Collector defaults, used when the corresponding option is not given.
+const (
+	defaultCollectorInterval  = time.Minute
+	defaultCollectorJitter    = 0.1
+	defaultCollectorRetention = 60
+)
+
+// CollectorTarget is one metrics endpoint scraped by a Collector. Name
+// identifies the target in query results, typically the instance ID.
+type CollectorTarget struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// CollectorTargetStatus reports the outcome of the most recent scrape of a
+// target. LastError is empty when that scrape succeeded.
+type CollectorTargetStatus struct {
+	Target         CollectorTarget `json:"target"`
+	LastScrape     time.Time       `json:"last_scrape"`
+	LastDuration   time.Duration   `json:"last_duration"`
+	LastError      string          `json:"last_error,omitempty"`
+	Scrapes        int             `json:"scrapes"`
+	Failures       int             `json:"failures"`
+	SeriesRetained int             `json:"series_retained"`
+}
+
+// CollectorSample is one value of a series at a point in time.
+type CollectorSample struct {
+	At    time.Time `json:"at"`
+	Value float64   `json:"value"`
+}
+
+// SeriesValue is the result of a Collector query for one series.
+type SeriesValue struct {
+	Target string            `json:"target"`
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+	// At is the time of the newest sample the value was computed from.
+	At time.Time `json:"at"`
+}
+
+// CollectorOption configures a Collector.
+type CollectorOption func(*collectorOptions) error
+
+// collectorOptions holds the settings applied by CollectorOption values.
+type collectorOptions struct {
+	interval  time.Duration
+	jitter    float64
+	timeout   time.Duration
+	retention int
+}
+
+// Collector scrapes the metrics endpoints of one or more targets on an
+// interval and keeps the most recent samples of every series in a bounded
+// ring buffer, so that windowed queries such as Rate or AvgOverTime can be
+// answered without re-scraping. Create one with AuraAPIClient.NewCollector
+// and start it with Run. Query methods are safe to call while Run is active.
+type Collector struct {
+	prometheus PrometheusService
+	logger     *slog.Logger
+	targets    []CollectorTarget
+	opts       collectorOptions
+	now        func() time.Time
+
+	mu      sync.RWMutex
+	series  map[string]*collectorSeries // keyed by target and seriesKey
+	status  map[string]*CollectorTargetStatus
+	running bool
+}
+
+// collectorSeries is the ring buffer of samples for one series of one target.
+type collectorSeries struct {
+	target  string
+	name    string
+	labels  map[string]string
+	samples []CollectorSample
+	start   int // index of the oldest sample
+	count   int
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithCollectorInterval sets how often each target is scraped. Defaults to one minute.
+func WithCollectorInterval(d time.Duration) CollectorOption {
+	return func(o *collectorOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("collector interval must be greater than zero")
+		}
+		o.interval = d
+		return nil
+	}
+}
+
+// WithCollectorJitter spreads scrapes by up to fraction of the interval in
+// either direction, so that many targets are not scraped in lock step. The
+// first scrape of each target is delayed by a random fraction of the jitter.
+// Defaults to 0.1; 0 disables jitter.
+func WithCollectorJitter(fraction float64) CollectorOption {
+	return func(o *collectorOptions) error {
+		if fraction < 0 || fraction >= 1 || math.IsNaN(fraction) {
+			return fmt.Errorf("collector jitter must be at least 0 and less than 1, got %v", fraction)
+		}
+		o.jitter = fraction
+		return nil
+	}
+}
+
+// WithCollectorTimeout limits how long a single scrape of a target may take.
+// Defaults to the scrape interval.
+func WithCollectorTimeout(d time.Duration) CollectorOption {
+	return func(o *collectorOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("collector timeout must be greater than zero")
+		}
+		o.timeout = d
+		return nil
+	}
+}
+
+// WithCollectorRetention sets how many samples are kept per series. Older
+// samples are overwritten. Defaults to 60, one hour at the default interval.
+func WithCollectorRetention(samples int) CollectorOption {
+	return func(o *collectorOptions) error {
+		if samples < 2 {
+			return fmt.Errorf("collector retention must be at least 2 samples, got %d", samples)
+		}
+		o.retention = samples
+		return nil
+	}
+}
+
+// ============================================================================
+// Collector
+// ============================================================================
+
+// NewCollector returns a Collector that scrapes targets with the client's
+// Prometheus service. Target names must be unique and URLs non-empty.
+func (c *AuraAPIClient) NewCollector(targets []CollectorTarget, opts ...CollectorOption) (*Collector, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("at least one collector target is required")
+	}
+	names := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if t.Name == "" || t.URL == "" {
+			return nil, fmt.Errorf("collector target must have a name and URL: %+v", t)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate collector target %q", t.Name)
+		}
+		names[t.Name] = true
+	}
+
+	options := collectorOptions{interval: defaultCollectorInterval, jitter: defaultCollectorJitter, retention: defaultCollectorRetention}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return nil, err
+		}
+	}
+	if options.timeout == 0 {
+		options.timeout = options.interval
+	}
+
+	collector := &Collector{
+		prometheus: c.Prometheus,
+		logger:     c.logger.With(slog.String("component", "Collector")),
+		targets:    append([]CollectorTarget(nil), targets...),
+		opts:       options,
+		now:        time.Now,
+		series:     make(map[string]*collectorSeries),
+		status:     make(map[string]*CollectorTargetStatus, len(targets)),
+	}
+	for _, t := range targets {
+		collector.status[t.Name] = &CollectorTargetStatus{Target: t}
+	}
+	return collector, nil
+}
+
+// Run scrapes every target on the configured interval until ctx is cancelled,
+// then waits for in-flight scrapes to finish and returns ctx.Err(). Scrape
+// failures are logged and recorded in Status; they do not stop the collector.
+// Run may only be active once at a time.
+func (c *Collector) Run(ctx context.Context) error {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return errors.New("collector is already running")
+	}
+	c.running = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running = false
+		c.mu.Unlock()
+	}()
+
+	c.logger.InfoContext(ctx, "collector started",
+		slog.Int("targets", len(c.targets)),
+		slog.Duration("interval", c.opts.interval))
+
+	var wg sync.WaitGroup
+	for _, target := range c.targets {
+		wg.Add(1)
+		go func(target CollectorTarget) {
+			defer wg.Done()
+			c.loop(ctx, target)
+		}(target)
+	}
+	wg.Wait()
+
+	c.logger.InfoContext(ctx, "collector stopped")
+	return ctx.Err()
+}
+
+// loop scrapes one target until ctx is cancelled.
+func (c *Collector) loop(ctx context.Context, target CollectorTarget) {
+	delay := time.Duration(rand.Float64() * c.opts.jitter * float64(c.opts.interval))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		c.scrape(ctx, target)
+		timer.Reset(c.nextDelay())
+	}
+}
+
+// nextDelay returns the interval adjusted by a random jitter.
+func (c *Collector) nextDelay() time.Duration {
+	if c.opts.jitter == 0 {
+		return c.opts.interval
+	}
+	offset := (rand.Float64()*2 - 1) * c.opts.jitter * float64(c.opts.interval)
+	return c.opts.interval + time.Duration(offset)
+}
+
+// scrape fetches one target and stores its samples.
+func (c *Collector) scrape(ctx context.Context, target CollectorTarget) {
+	scrapeCtx, cancel := context.WithTimeout(ctx, c.opts.timeout)
+	defer cancel()
+
+	started := c.now()
+	metrics, err := c.prometheus.FetchRawMetrics(scrapeCtx, target.URL)
+	duration := c.now().Sub(started)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status[target.Name]
+	status.LastScrape = started
+	status.LastDuration = duration
+	status.Scrapes++
+	if err != nil {
+		status.LastError = err.Error()
+		status.Failures++
+		if ctx.Err() == nil {
+			c.logger.WarnContext(ctx, "scrape failed", slog.String("target", target.Name), slog.String("error", err.Error()))
+		}
+		return
+	}
+	status.LastError = ""
+
+	for name, series := range metrics.Metrics {
+		for _, m := range series {
+			at := started
+			if m.Timestamp > 0 {
+				at = time.UnixMilli(m.Timestamp)
+			}
+			key := target.Name + "/" + seriesKey(name, m.Labels)
+			s, ok := c.series[key]
+			if !ok {
+				s = &collectorSeries{target: target.Name, name: name, labels: m.Labels, samples: make([]CollectorSample, c.opts.retention)}
+				c.series[key] = s
+			}
+			s.push(CollectorSample{At: at, Value: m.Value})
+		}
+	}
+
+	// Forget series of this target that have not been seen for a full
+	// retention period, so label churn cannot grow memory without bound.
+	cutoff := started.Add(-time.Duration(c.opts.retention) * c.opts.interval)
+	retained := 0
+	for key, s := range c.series {
+		if s.target != target.Name {
+			continue
+		}
+		if s.newest().At.Before(cutoff) {
+			delete(c.series, key)
+			continue
+		}
+		retained++
+	}
+	status.SeriesRetained = retained
+
+	c.logger.DebugContext(ctx, "scrape complete",
+		slog.String("target", target.Name),
+		slog.Duration("duration", duration),
+		slog.Int("series", retained))
+}
+
+// Status returns the scrape status of every target, in the order they were given.
+func (c *Collector) Status() []CollectorTargetStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]CollectorTargetStatus, 0, len(c.targets))
+	for _, t := range c.targets {
+		out = append(out, *c.status[t.Name])
+	}
+	return out
+}
+
+// ============================================================================
+// Queries
+// ============================================================================
+
+// Last returns the newest sample of every matching series.
+func (c *Collector) Last(target, name string, labelFilters map[string]string) []SeriesValue {
+	return c.query(target, name, labelFilters, 0, func(samples []CollectorSample) (float64, bool) {
+		return samples[len(samples)-1].Value, true
+	})
+}
+
+// AvgOverTime returns, for every matching series, the average of its samples
+// within window of now.
+func (c *Collector) AvgOverTime(target, name string, labelFilters map[string]string, window time.Duration) ([]SeriesValue, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be greater than zero")
+	}
+	return c.query(target, name, labelFilters, window, func(samples []CollectorSample) (float64, bool) {
+		var sum float64
+		for _, s := range samples {
+			sum += s.Value
+		}
+		return sum / float64(len(samples)), true
+	}), nil
+}
+
+// MaxOverTime returns, for every matching series, the largest of its samples
+// within window of now.
+func (c *Collector) MaxOverTime(target, name string, labelFilters map[string]string, window time.Duration) ([]SeriesValue, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be greater than zero")
+	}
+	return c.query(target, name, labelFilters, window, func(samples []CollectorSample) (float64, bool) {
+		max := math.Inf(-1)
+		for _, s := range samples {
+			max = math.Max(max, s.Value)
+		}
+		return max, true
+	}), nil
+}
+
+// Rate returns, for every matching counter series, its per-second rate of
+// increase over the samples within window of now. Counter resets are handled
+// as in MetricsSampler. Series with fewer than two samples in the window are
+// omitted.
+func (c *Collector) Rate(target, name string, labelFilters map[string]string, window time.Duration) ([]SeriesValue, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be greater than zero")
+	}
+	return c.query(target, name, labelFilters, window, func(samples []CollectorSample) (float64, bool) {
+		if len(samples) < 2 {
+			return 0, false
+		}
+		elapsed := samples[len(samples)-1].At.Sub(samples[0].At).Seconds()
+		if elapsed <= 0 {
+			return 0, false
+		}
+		var increase float64
+		for i := 1; i < len(samples); i++ {
+			delta := samples[i].Value - samples[i-1].Value
+			if delta < 0 {
+				delta = samples[i].Value
+			}
+			increase += delta
+		}
+		return increase / elapsed, true
+	}), nil
+}
+
+// query applies fn to the samples of every matching series within window of
+// now (all retained samples when window is 0) and returns the results sorted
+// by target, name and labels.
+func (c *Collector) query(target, name string, labelFilters map[string]string, window time.Duration, fn func([]CollectorSample) (float64, bool)) []SeriesValue {
+	var since time.Time
+	if window > 0 {
+		since = c.now().Add(-window)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := []SeriesValue{}
+	for _, s := range c.series {
+		if !s.matches(target, name, labelFilters) {
+			continue
+		}
+		samples := s.since(since)
+		if len(samples) == 0 {
+			continue
+		}
+		value, ok := fn(samples)
+		if !ok {
+			continue
+		}
+		out = append(out, SeriesValue{Target: s.target, Name: s.name, Labels: s.labels, Value: value, At: samples[len(samples)-1].At})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		return seriesKey(out[i].Name, out[i].Labels) < seriesKey(out[j].Name, out[j].Labels)
+	})
+	return out
+}
+
+// ============================================================================
+// Ring buffer
+// ============================================================================
+
+// push appends a sample, overwriting the oldest once the buffer is full.
+// Samples not newer than the newest stored sample (a cached scrape) are ignored.
+func (s *collectorSeries) push(sample CollectorSample) {
+	if s.count > 0 && !sample.At.After(s.newest().At) {
+		return
+	}
+	size := len(s.samples)
+	if s.count < size {
+		s.samples[(s.start+s.count)%size] = sample
+		s.count++
+		return
+	}
+	s.samples[s.start] = sample
+	s.start = (s.start + 1) % size
+}
+
+// newest returns the most recent sample. The series must not be empty.
+func (s *collectorSeries) newest() CollectorSample {
+	return s.samples[(s.start+s.count-1)%len(s.samples)]
+}
+
+// since returns the samples at or after t, oldest first.
+func (s *collectorSeries) since(t time.Time) []CollectorSample {
+	out := make([]CollectorSample, 0, s.count)
+	for i := 0; i < s.count; i++ {
+		sample := s.samples[(s.start+i)%len(s.samples)]
+		if !sample.At.Before(t) {
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+// matches reports whether the series belongs to target (any when empty), is
+// called name and has every label in filters.
+func (s *collectorSeries) matches(target, name string, filters map[string]string) bool {
+	return (target == "" || s.target == target) && s.name == name && labelsMatch(s.labels, filters)
+}
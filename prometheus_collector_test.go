@@ -0,0 +1,205 @@
+package aura
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LackOfMorals/aura-client/internal/api"
+)
+
+const (
+	collectorURLA = "https://a1b2c3d4.metrics.neo4j.io/prometheus"
+	collectorURLB = "https://e5f6a7b8.metrics.neo4j.io/prometheus"
+)
+
+func collectorScrape(queries, cpu float64) []byte {
+	return []byte(fmt.Sprintf("neo4j_db_query_execution_success_total{database=\"neo4j\"} %v\nneo4j_aura_cpu_usage %v\n", queries, cpu))
+}
+
+// newTestCollector returns a Collector over router whose clock is controlled by the returned pointer.
+func newTestCollector(t *testing.T, router *mockAPIServiceRouter, opts ...CollectorOption) (*Collector, *time.Time) {
+	t.Helper()
+	client := newTestClientWithAPI(router)
+	c, err := client.NewCollector([]CollectorTarget{
+		{Name: "a1b2c3d4", URL: collectorURLA},
+		{Name: "e5f6a7b8", URL: collectorURLB},
+	}, opts...)
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestNewCollector_Validation(t *testing.T) {
+	client := newTestClientWithAPI(newMockAPIServiceRouter())
+	target := []CollectorTarget{{Name: "a", URL: collectorURLA}}
+
+	tests := []struct {
+		name    string
+		targets []CollectorTarget
+		opts    []CollectorOption
+	}{
+		{"no targets", nil, nil},
+		{"missing URL", []CollectorTarget{{Name: "a"}}, nil},
+		{"duplicate name", []CollectorTarget{{Name: "a", URL: collectorURLA}, {Name: "a", URL: collectorURLB}}, nil},
+		{"zero interval", target, []CollectorOption{WithCollectorInterval(0)}},
+		{"jitter too large", target, []CollectorOption{WithCollectorJitter(1)}},
+		{"negative timeout", target, []CollectorOption{WithCollectorTimeout(-time.Second)}},
+		{"retention too small", target, []CollectorOption{WithCollectorRetention(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.NewCollector(tt.targets, tt.opts...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCollector_WindowedQueries(t *testing.T) {
+	router := newMockAPIServiceRouter()
+	c, now := newTestCollector(t, router, WithCollectorInterval(time.Minute))
+	ctx := context.Background()
+	targetA := CollectorTarget{Name: "a1b2c3d4", URL: collectorURLA}
+
+	// Six scrapes a minute apart; the counter resets between the 4th and 5th.
+	queries := []float64{0, 600, 1200, 1800, 300, 900}
+	cpu := []float64{0.2, 0.4, 0.9, 0.3, 0.5, 0.6}
+	for i := range queries {
+		router.on("GET", collectorURLA, collectorScrape(queries[i], cpu[i]))
+		c.scrape(ctx, targetA)
+		if i < len(queries)-1 {
+			*now = now.Add(time.Minute)
+		}
+	}
+
+	last := c.Last("", "neo4j_aura_cpu_usage", nil)
+	if len(last) != 1 || last[0].Value != 0.6 || last[0].Target != "a1b2c3d4" {
+		t.Fatalf("unexpected Last: %+v", last)
+	}
+
+	// A 3m window holds the last four samples: 0.9, 0.3, 0.5, 0.6.
+	avg, err := c.AvgOverTime("a1b2c3d4", "neo4j_aura_cpu_usage", nil, 3*time.Minute)
+	if err != nil || len(avg) != 1 || avg[0].Value != (0.9+0.3+0.5+0.6)/4 {
+		t.Errorf("unexpected AvgOverTime: %+v (err %v)", avg, err)
+	}
+	max, err := c.MaxOverTime("", "neo4j_aura_cpu_usage", nil, 5*time.Minute)
+	if err != nil || len(max) != 1 || max[0].Value != 0.9 {
+		t.Errorf("unexpected MaxOverTime: %+v (err %v)", max, err)
+	}
+
+	// Over 5m: increases 600+600+600, then reset to 300, then 600 = 2700 over 300s.
+	rate, err := c.Rate("", "neo4j_db_query_execution_success_total", map[string]string{"database": "neo4j"}, 5*time.Minute)
+	if err != nil || len(rate) != 1 || rate[0].Value != 9 {
+		t.Errorf("unexpected Rate: %+v (err %v)", rate, err)
+	}
+
+	if _, err := c.Rate("", "neo4j_db_query_execution_success_total", nil, 0); err == nil {
+		t.Error("expected error for zero window")
+	}
+	if got := c.Last("e5f6a7b8", "neo4j_aura_cpu_usage", nil); len(got) != 0 {
+		t.Errorf("expected nothing for an unscraped target, got %+v", got)
+	}
+}
+
+func TestCollector_RingBufferBounded(t *testing.T) {
+	router := newMockAPIServiceRouter()
+	c, now := newTestCollector(t, router, WithCollectorRetention(3))
+	target := CollectorTarget{Name: "a1b2c3d4", URL: collectorURLA}
+
+	for i := 0; i < 5; i++ {
+		router.on("GET", collectorURLA, collectorScrape(0, float64(i)))
+		c.scrape(context.Background(), target)
+		*now = now.Add(time.Minute)
+	}
+	// Only samples 2, 3 and 4 remain.
+	avg, _ := c.AvgOverTime("", "neo4j_aura_cpu_usage", nil, time.Hour)
+	if len(avg) != 1 || avg[0].Value != 3 {
+		t.Errorf("expected average of the last 3 samples, got %+v", avg)
+	}
+}
+
+func TestCollector_ScrapeFailureRecorded(t *testing.T) {
+	router := newMockAPIServiceRouter().onError("GET", collectorURLB, &api.Error{StatusCode: 503, Message: "unavailable"})
+	c, _ := newTestCollector(t, router)
+
+	c.scrape(context.Background(), CollectorTarget{Name: "e5f6a7b8", URL: collectorURLB})
+	status := c.Status()
+	if len(status) != 2 {
+		t.Fatalf("expected status for both targets, got %d", len(status))
+	}
+	if status[1].Failures != 1 || status[1].LastError == "" {
+		t.Errorf("expected failure to be recorded, got %+v", status[1])
+	}
+	if status[0].Scrapes != 0 {
+		t.Errorf("expected target a to be untouched, got %+v", status[0])
+	}
+}
+
+func TestCollector_PerTargetTimeout(t *testing.T) {
+	mock := &mockAPIServiceWithDelay{response: &api.Response{StatusCode: 200, Body: collectorScrape(1, 1)}, delay: time.Second}
+	client := newTestClientWithAPI(mock)
+	c, err := client.NewCollector([]CollectorTarget{{Name: "a1b2c3d4", URL: collectorURLA}}, WithCollectorTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	c.scrape(context.Background(), c.targets[0])
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("scrape was not bounded by the target timeout: %v", elapsed)
+	}
+	if status := c.Status()[0]; status.LastError != context.DeadlineExceeded.Error() || status.Failures != 1 {
+		t.Errorf("expected a timeout failure, got %+v", status)
+	}
+}
+
+func TestCollector_RunStopsOnCancel(t *testing.T) {
+	router := newMockAPIServiceRouter().
+		on("GET", collectorURLA, collectorScrape(1, 0.5)).
+		on("GET", collectorURLB, collectorScrape(2, 0.7))
+	client := newTestClientWithAPI(router)
+	c, err := client.NewCollector([]CollectorTarget{
+		{Name: "a1b2c3d4", URL: collectorURLA},
+		{Name: "e5f6a7b8", URL: collectorURLB},
+	}, WithCollectorInterval(10*time.Millisecond), WithCollectorJitter(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	deadline := time.After(2 * time.Second)
+	for len(router.callsTo("GET", collectorURLA)) < 3 || len(router.callsTo("GET", collectorURLB)) < 3 {
+		select {
+		case <-deadline:
+			t.Fatal("collector did not scrape both targets repeatedly")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	if err := c.Run(ctx); err == nil {
+		t.Error("expected error when Run is called twice")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	if got := c.Last("", "neo4j_aura_cpu_usage", nil); len(got) != 2 {
+		t.Errorf("expected a value per target, got %+v", got)
+	}
+}
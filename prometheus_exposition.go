@@ -0,0 +1,612 @@
+package aura
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// ExpositionFormat is a metrics exposition format FetchRawMetrics can request
+// and parse.
+type ExpositionFormat string
+
+// Supported exposition formats.
+const (
+	// ExpositionText is the Prometheus text format, version 0.0.4.
+	ExpositionText ExpositionFormat = "text"
+	// ExpositionOpenMetrics is OpenMetrics text, version 1.0.0. Exemplars and
+	// _created series are kept.
+	ExpositionOpenMetrics ExpositionFormat = "openmetrics"
+	// ExpositionProtobuf is length-delimited io.prometheus.client.MetricFamily
+	// protobuf messages.
+	ExpositionProtobuf ExpositionFormat = "protobuf"
+)
+
+// expositionMediaTypes are the Accept values for each format.
+var expositionMediaTypes = map[ExpositionFormat]string{
+	ExpositionText:        "text/plain;version=0.0.4",
+	ExpositionOpenMetrics: "application/openmetrics-text;version=1.0.0",
+	ExpositionProtobuf:    "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited",
+}
+
+// defaultExpositionFormats is the Accept preference when no FetchOption is
+// given. Text comes first because it is what Aura serves today; the others
+// are accepted in case the endpoint negotiates differently.
+var defaultExpositionFormats = []ExpositionFormat{ExpositionText, ExpositionOpenMetrics, ExpositionProtobuf}
+
+// Exemplar is an example observation attached to a counter or histogram
+// bucket, typically carrying a trace ID. Timestamp is zero if not exposed.
+type Exemplar struct {
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value"`
+	Timestamp time.Time         `json:"timestamp,omitempty"`
+}
+
+// FetchOption configures FetchRawMetrics.
+type FetchOption func(*fetchOptions) error
+
+// fetchOptions holds the settings applied by FetchOption values.
+type fetchOptions struct {
+	accept string
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithAcceptFormats sets the Accept header of the request to formats, most
+// preferred first. By default text is preferred, then OpenMetrics, then
+// protobuf. Whatever the endpoint returns is parsed according to its
+// Content-Type.
+func WithAcceptFormats(formats ...ExpositionFormat) FetchOption {
+	return func(o *fetchOptions) error {
+		accept, err := acceptHeader(formats)
+		if err != nil {
+			return err
+		}
+		o.accept = accept
+		return nil
+	}
+}
+
+// defaultFetchOptions returns the options used when none are given.
+func defaultFetchOptions() fetchOptions {
+	accept, _ := acceptHeader(defaultExpositionFormats)
+	return fetchOptions{accept: accept}
+}
+
+// acceptHeader builds an Accept header listing formats with descending quality.
+func acceptHeader(formats []ExpositionFormat) (string, error) {
+	if len(formats) == 0 {
+		return "", errors.New("at least one exposition format is required")
+	}
+	parts := make([]string, 0, len(formats)+1)
+	for i, f := range formats {
+		mediaType, ok := expositionMediaTypes[f]
+		if !ok {
+			return "", fmt.Errorf("unsupported exposition format %q", f)
+		}
+		q := 1 - 0.1*float64(i)
+		parts = append(parts, fmt.Sprintf("%s;q=%s", mediaType, strconv.FormatFloat(max(q, 0.2), 'f', -1, 64)))
+	}
+	parts = append(parts, "*/*;q=0.1")
+	return strings.Join(parts, ","), nil
+}
+
+// ============================================================================
+// Parsing
+// ============================================================================
+
+// detectExposition returns the format of a response from its Content-Type.
+// Without a usable Content-Type, a body ending in "# EOF" is taken to be
+// OpenMetrics and anything else Prometheus text.
+func detectExposition(contentType string, data []byte) (ExpositionFormat, error) {
+	if mediaType, params, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/openmetrics-text":
+			return ExpositionOpenMetrics, nil
+		case "application/vnd.google.protobuf":
+			if params["proto"] != "io.prometheus.client.MetricFamily" || params["encoding"] != "delimited" {
+				return "", fmt.Errorf("unsupported protobuf exposition %q", contentType)
+			}
+			return ExpositionProtobuf, nil
+		case "text/plain":
+			return ExpositionText, nil
+		}
+	}
+	if bytes.HasSuffix(bytes.TrimRight(data, "\r\n"), []byte("# EOF")) {
+		return ExpositionOpenMetrics, nil
+	}
+	return ExpositionText, nil
+}
+
+// decodeExposition parses data in format into metric families.
+func decodeExposition(format ExpositionFormat, data []byte) (map[string]*dto.MetricFamily, error) {
+	switch format {
+	case ExpositionOpenMetrics:
+		return parseOpenMetrics(data)
+	case ExpositionProtobuf:
+		families := make(map[string]*dto.MetricFamily)
+		dec := expfmt.NewDecoder(bytes.NewReader(data), expfmt.NewFormat(expfmt.TypeProtoDelim))
+		for {
+			mf := &dto.MetricFamily{}
+			if err := dec.Decode(mf); err == io.EOF {
+				return families, nil
+			} else if err != nil {
+				return nil, err
+			}
+			if existing, ok := families[mf.GetName()]; ok {
+				existing.Metric = append(existing.Metric, mf.Metric...)
+				continue
+			}
+			families[mf.GetName()] = mf
+		}
+	default:
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return families, nil
+	}
+}
+
+// exemplarFromDTO converts a protobuf exemplar, which may be nil.
+func exemplarFromDTO(e *dto.Exemplar) *Exemplar {
+	if e == nil {
+		return nil
+	}
+	out := &Exemplar{Labels: make(map[string]string, len(e.Label)), Value: e.GetValue()}
+	for _, l := range e.Label {
+		out.Labels[l.GetName()] = l.GetValue()
+	}
+	if e.Timestamp != nil {
+		out.Timestamp = e.Timestamp.AsTime()
+	}
+	return out
+}
+
+// createdFromDTO converts a protobuf created timestamp, which may be nil.
+func createdFromDTO(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// ============================================================================
+// OpenMetrics
+// ============================================================================
+
+// openMetricsSuffixes lists the sample name suffixes allowed for each
+// OpenMetrics metric type. The empty suffix is the bare family name.
+var openMetricsSuffixes = map[string][]string{
+	"counter":        {"_total", "_created"},
+	"gauge":          {""},
+	"unknown":        {""},
+	"stateset":       {""},
+	"info":           {"_info"},
+	"summary":        {"", "_sum", "_count", "_created"},
+	"histogram":      {"_bucket", "_sum", "_count", "_created"},
+	"gaugehistogram": {"_bucket", "_gsum", "_gcount"},
+}
+
+// openMetricsTypes maps OpenMetrics metric types to protobuf types.
+var openMetricsTypes = map[string]dto.MetricType{
+	"counter":        dto.MetricType_COUNTER,
+	"gauge":          dto.MetricType_GAUGE,
+	"unknown":        dto.MetricType_UNTYPED,
+	"stateset":       dto.MetricType_GAUGE,
+	"info":           dto.MetricType_GAUGE,
+	"summary":        dto.MetricType_SUMMARY,
+	"histogram":      dto.MetricType_HISTOGRAM,
+	"gaugehistogram": dto.MetricType_GAUGE_HISTOGRAM,
+}
+
+// openMetricsFamily is a metric family being assembled by parseOpenMetrics.
+type openMetricsFamily struct {
+	name   string
+	typ    string
+	help   string
+	series map[string]*dto.Metric // keyed by labels without le and quantile
+	order  []string
+}
+
+// openMetricsSample is one parsed sample line.
+type openMetricsSample struct {
+	name      string
+	labels    []*dto.LabelPair
+	value     float64
+	timestamp *int64 // milliseconds
+	exemplar  *dto.Exemplar
+}
+
+// parseOpenMetrics parses OpenMetrics text into metric families named as the
+// Prometheus text format would name them, so that both formats produce the
+// same PrometheusMetricsResponse: counters keep their _total suffix and info
+// metrics their _info suffix. _created series become created timestamps.
+func parseOpenMetrics(data []byte) (map[string]*dto.MetricFamily, error) {
+	families := make(map[string]*dto.MetricFamily)
+	var current *openMetricsFamily
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		name := current.name
+		switch current.typ {
+		case "counter":
+			name += "_total"
+		case "info":
+			name += "_info"
+		}
+		mf, ok := families[name]
+		if !ok {
+			mf = &dto.MetricFamily{Name: proto.String(name), Type: openMetricsTypes[current.typ].Enum()}
+			if current.help != "" {
+				mf.Help = proto.String(current.help)
+			}
+			families[name] = mf
+		}
+		for _, key := range current.order {
+			mf.Metric = append(mf.Metric, current.series[key])
+		}
+		current = nil
+	}
+	start := func(name, typ string) {
+		flush()
+		current = &openMetricsFamily{name: name, typ: typ, series: make(map[string]*dto.Metric)}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo, sawEOF := 0, false
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if sawEOF {
+			if line != "" {
+				return nil, fmt.Errorf("line %d: content after # EOF", lineNo)
+			}
+			continue
+		}
+		if line == "# EOF" {
+			sawEOF = true
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(line, " ", 4)
+			if len(fields) < 3 {
+				continue // a comment
+			}
+			keyword, name := fields[1], fields[2]
+			text := ""
+			if len(fields) == 4 {
+				text = fields[3]
+			}
+			if current == nil || current.name != name {
+				start(name, "unknown")
+			}
+			switch keyword {
+			case "TYPE":
+				if _, ok := openMetricsSuffixes[text]; !ok {
+					return nil, fmt.Errorf("line %d: unknown metric type %q", lineNo, text)
+				}
+				current.typ = text
+			case "HELP":
+				current.help = unescapeOpenMetrics(text)
+			}
+			continue
+		}
+
+		sample, err := parseOpenMetricsSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		suffix, ok := openMetricsSuffix(current, sample.name)
+		if !ok {
+			start(sample.name, "unknown")
+			suffix = ""
+		}
+		if err := current.add(suffix, sample); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return families, nil
+}
+
+// openMetricsSuffix returns the suffix of name within family f, if name
+// belongs to it.
+func openMetricsSuffix(f *openMetricsFamily, name string) (string, bool) {
+	if f == nil || !strings.HasPrefix(name, f.name) {
+		return "", false
+	}
+	suffix := name[len(f.name):]
+	for _, allowed := range openMetricsSuffixes[f.typ] {
+		if suffix == allowed {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
+// add merges one sample into the family.
+func (f *openMetricsFamily) add(suffix string, s openMetricsSample) error {
+	var special *dto.LabelPair // le or quantile
+	labels := make([]*dto.LabelPair, 0, len(s.labels))
+	for _, l := range s.labels {
+		if (f.typ == "histogram" || f.typ == "gaugehistogram") && suffix == "_bucket" && l.GetName() == "le" ||
+			f.typ == "summary" && suffix == "" && l.GetName() == "quantile" {
+			special = l
+			continue
+		}
+		labels = append(labels, l)
+	}
+	key := seriesKey("", labelPairsToMap(labels))
+	m, ok := f.series[key]
+	if !ok {
+		m = &dto.Metric{Label: labels}
+		switch f.typ {
+		case "counter":
+			m.Counter = &dto.Counter{}
+		case "summary":
+			m.Summary = &dto.Summary{}
+		case "histogram", "gaugehistogram":
+			m.Histogram = &dto.Histogram{}
+		case "unknown":
+			m.Untyped = &dto.Untyped{}
+		default:
+			m.Gauge = &dto.Gauge{}
+		}
+		f.series[key] = m
+		f.order = append(f.order, key)
+	}
+	if suffix != "_created" && s.timestamp != nil {
+		m.TimestampMs = s.timestamp
+	}
+
+	switch suffix {
+	case "_created":
+		created := timestamppb.New(secondsToTime(s.value))
+		switch {
+		case m.Counter != nil:
+			m.Counter.CreatedTimestamp = created
+		case m.Summary != nil:
+			m.Summary.CreatedTimestamp = created
+		case m.Histogram != nil:
+			m.Histogram.CreatedTimestamp = created
+		}
+	case "_total":
+		m.Counter.Value = proto.Float64(s.value)
+		m.Counter.Exemplar = s.exemplar
+	case "_sum", "_gsum":
+		if m.Summary != nil {
+			m.Summary.SampleSum = proto.Float64(s.value)
+		} else {
+			m.Histogram.SampleSum = proto.Float64(s.value)
+		}
+	case "_count", "_gcount":
+		if m.Summary != nil {
+			m.Summary.SampleCount = proto.Uint64(uint64(s.value))
+		} else {
+			setHistogramCount(m.Histogram, s.value)
+		}
+	case "_bucket":
+		if special == nil {
+			return fmt.Errorf("histogram bucket %s has no le label", s.name)
+		}
+		bound, err := parseOpenMetricsFloat(special.GetValue())
+		if err != nil {
+			return fmt.Errorf("invalid le %q: %w", special.GetValue(), err)
+		}
+		bucket := &dto.Bucket{UpperBound: proto.Float64(bound), Exemplar: s.exemplar}
+		if s.value == math.Trunc(s.value) && s.value >= 0 && s.value < 1<<53 {
+			bucket.CumulativeCount = proto.Uint64(uint64(s.value))
+		} else {
+			bucket.CumulativeCountFloat = proto.Float64(s.value)
+		}
+		m.Histogram.Bucket = append(m.Histogram.Bucket, bucket)
+	default:
+		switch {
+		case m.Summary != nil:
+			if special == nil {
+				return fmt.Errorf("summary sample %s has no quantile label", s.name)
+			}
+			q, err := parseOpenMetricsFloat(special.GetValue())
+			if err != nil {
+				return fmt.Errorf("invalid quantile %q: %w", special.GetValue(), err)
+			}
+			m.Summary.Quantile = append(m.Summary.Quantile, &dto.Quantile{Quantile: proto.Float64(q), Value: proto.Float64(s.value)})
+		case m.Untyped != nil:
+			m.Untyped.Value = proto.Float64(s.value)
+		default:
+			m.Gauge.Value = proto.Float64(s.value)
+		}
+	}
+	return nil
+}
+
+// setHistogramCount sets the observation count of h, which is a float for
+// gauge histograms with non-integer counts.
+func setHistogramCount(h *dto.Histogram, count float64) {
+	if count == math.Trunc(count) && count >= 0 && count < 1<<53 {
+		h.SampleCount = proto.Uint64(uint64(count))
+		return
+	}
+	h.SampleCountFloat = proto.Float64(count)
+}
+
+// parseOpenMetricsSample parses a sample line:
+//
+//	name{label="value",...} value [timestamp] [# {label="value",...} value [timestamp]]
+func parseOpenMetricsSample(line string) (openMetricsSample, error) {
+	var s openMetricsSample
+	rest := line
+
+	end := strings.IndexAny(rest, "{ ")
+	if end <= 0 {
+		return s, fmt.Errorf("invalid sample %q", line)
+	}
+	s.name, rest = rest[:end], rest[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, after, err := parseOpenMetricsLabels(rest)
+		if err != nil {
+			return s, err
+		}
+		s.labels, rest = labels, after
+	}
+
+	var exemplar string
+	if i := strings.Index(rest, " # "); i >= 0 {
+		rest, exemplar = rest[:i], rest[i+3:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return s, fmt.Errorf("invalid sample %q", line)
+	}
+	value, err := parseOpenMetricsFloat(fields[0])
+	if err != nil {
+		return s, fmt.Errorf("invalid value %q: %w", fields[0], err)
+	}
+	s.value = value
+	if len(fields) == 2 {
+		ts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid timestamp %q: %w", fields[1], err)
+		}
+		ms := int64(math.Round(ts * 1000))
+		s.timestamp = &ms
+	}
+
+	if exemplar != "" {
+		e, err := parseOpenMetricsExemplar(exemplar)
+		if err != nil {
+			return s, err
+		}
+		s.exemplar = e
+	}
+	return s, nil
+}
+
+// parseOpenMetricsExemplar parses the part of a sample after " # ".
+func parseOpenMetricsExemplar(text string) (*dto.Exemplar, error) {
+	if !strings.HasPrefix(text, "{") {
+		return nil, fmt.Errorf("invalid exemplar %q", text)
+	}
+	labels, rest, err := parseOpenMetricsLabels(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exemplar: %w", err)
+	}
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid exemplar %q", text)
+	}
+	value, err := parseOpenMetricsFloat(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid exemplar value %q: %w", fields[0], err)
+	}
+	e := &dto.Exemplar{Label: labels, Value: proto.Float64(value)}
+	if len(fields) == 2 {
+		ts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exemplar timestamp %q: %w", fields[1], err)
+		}
+		e.Timestamp = timestamppb.New(secondsToTime(ts))
+	}
+	return e, nil
+}
+
+// parseOpenMetricsLabels parses a {...} label set at the start of text and
+// returns the labels and the remaining text.
+func parseOpenMetricsLabels(text string) ([]*dto.LabelPair, string, error) {
+	var labels []*dto.LabelPair
+	i := 1 // skip {
+	for {
+		if i < len(text) && text[i] == '}' {
+			return labels, text[i+1:], nil
+		}
+		eq := strings.IndexByte(text[i:], '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid label set %q", text)
+		}
+		name := text[i : i+eq]
+		i += eq + 1
+		if i >= len(text) || text[i] != '"' {
+			return nil, "", fmt.Errorf("label %s: value must be quoted", name)
+		}
+		i++
+		var value strings.Builder
+		for ; i < len(text) && text[i] != '"'; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+				switch text[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(text[i])
+				}
+				continue
+			}
+			value.WriteByte(text[i])
+		}
+		if i >= len(text) {
+			return nil, "", fmt.Errorf("label %s: unterminated value", name)
+		}
+		i++ // closing quote
+		labels = append(labels, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value.String())})
+		if i < len(text) && text[i] == ',' {
+			i++
+		}
+	}
+}
+
+// parseOpenMetricsFloat parses a value, including +Inf, -Inf and NaN.
+func parseOpenMetricsFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+// unescapeOpenMetrics reverses the escaping of HELP text.
+func unescapeOpenMetrics(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\"`, `"`).Replace(s)
+}
+
+// secondsToTime converts a Unix time in fractional seconds.
+func secondsToTime(seconds float64) time.Time {
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// labelPairsToMap converts protobuf labels to a map.
+func labelPairsToMap(labels []*dto.LabelPair) map[string]string {
+	out := make(map[string]string, len(labels))
+	for _, l := range labels {
+		out[l.GetName()] = l.GetValue()
+	}
+	return out
+}
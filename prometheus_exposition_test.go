@@ -0,0 +1,269 @@
+package aura
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const protobufContentType = "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited"
+
+// expositionFamilies returns a counter, gauge, histogram and summary with
+// created timestamps and exemplars, as an instrumented process would expose.
+func expositionFamilies() []*dto.MetricFamily {
+	created := timestamppb.New(time.Unix(1760000000, 0))
+	label := func(name, value string) *dto.LabelPair {
+		return &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)}
+	}
+	exemplar := &dto.Exemplar{Label: []*dto.LabelPair{label("trace_id", "abc123")}, Value: proto.Float64(0.25), Timestamp: timestamppb.New(time.Unix(1760000100, 0))}
+	return []*dto.MetricFamily{
+		{
+			Name: proto.String("neo4j_db_query_execution_success_total"), Help: proto.String("Successful queries."), Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label:   []*dto.LabelPair{label("database", "neo4j")},
+				Counter: &dto.Counter{Value: proto.Float64(42), CreatedTimestamp: created, Exemplar: exemplar},
+			}},
+		},
+		{
+			Name: proto.String("neo4j_aura_cpu_usage"), Help: proto.String(`CPU "usage" in cores.`), Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{Label: []*dto.LabelPair{label("availability_zone", "a")}, Gauge: &dto.Gauge{Value: proto.Float64(0.5)}},
+				{Label: []*dto.LabelPair{label("availability_zone", `b"\`)}, Gauge: &dto.Gauge{Value: proto.Float64(0.7)}, TimestampMs: proto.Int64(1760000200000)},
+			},
+		},
+		{
+			Name: proto.String("neo4j_db_query_execution_latency_millis"), Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount: proto.Uint64(10), SampleSum: proto.Float64(120), CreatedTimestamp: created,
+					Bucket: []*dto.Bucket{
+						{UpperBound: proto.Float64(10), CumulativeCount: proto.Uint64(6), Exemplar: exemplar},
+						{UpperBound: proto.Float64(50), CumulativeCount: proto.Uint64(9)},
+						{UpperBound: proto.Float64(math.Inf(1)), CumulativeCount: proto.Uint64(10)},
+					},
+				},
+			}},
+		},
+		{
+			Name: proto.String("neo4j_gc_pause_seconds"), Type: dto.MetricType_SUMMARY.Enum(),
+			Metric: []*dto.Metric{{
+				Summary: &dto.Summary{
+					SampleCount: proto.Uint64(4), SampleSum: proto.Float64(0.2), CreatedTimestamp: created,
+					Quantile: []*dto.Quantile{{Quantile: proto.Float64(0.5), Value: proto.Float64(0.04)}, {Quantile: proto.Float64(0.99), Value: proto.Float64(0.09)}},
+				},
+			}},
+		},
+	}
+}
+
+// encodeExposition writes families in format.
+func encodeExposition(t *testing.T, format ExpositionFormat, families []*dto.MetricFamily) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, mf := range families {
+		var err error
+		switch format {
+		case ExpositionOpenMetrics:
+			_, err = expfmt.MetricFamilyToOpenMetrics(&buf, mf, expfmt.WithCreatedLines())
+		case ExpositionProtobuf:
+			err = expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeProtoDelim)).Encode(mf)
+		default:
+			_, err = expfmt.MetricFamilyToText(&buf, mf)
+		}
+		if err != nil {
+			t.Fatalf("failed to encode %s: %v", format, err)
+		}
+	}
+	if format == ExpositionOpenMetrics {
+		if _, err := expfmt.FinalizeOpenMetrics(&buf); err != nil {
+			t.Fatalf("failed to finalise OpenMetrics: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// withoutExtras returns a copy of r without created timestamps and exemplars,
+// which the Prometheus text format cannot carry.
+func withoutExtras(r *PrometheusMetricsResponse) *PrometheusMetricsResponse {
+	out := &PrometheusMetricsResponse{Metrics: make(map[string][]PrometheusMetric)}
+	for name, metrics := range r.Metrics {
+		for _, m := range metrics {
+			m.Created, m.Exemplar = time.Time{}, nil
+			m.Buckets = append([]HistogramBucket(nil), m.Buckets...)
+			for i := range m.Buckets {
+				m.Buckets[i].Exemplar = nil
+			}
+			out.Metrics[name] = append(out.Metrics[name], m)
+		}
+	}
+	return out
+}
+
+// TestParsePrometheusMetrics_FormatsAgree verifies the three formats produce
+// the same response for the same metrics
+func TestParsePrometheusMetrics_FormatsAgree(t *testing.T) {
+	svc := newTestPrometheusService()
+	families := expositionFamilies()
+
+	parsed := map[ExpositionFormat]*PrometheusMetricsResponse{}
+	for format, contentType := range map[ExpositionFormat]string{
+		ExpositionText:        "text/plain; version=0.0.4; charset=utf-8",
+		ExpositionOpenMetrics: "application/openmetrics-text; version=1.0.0; charset=utf-8",
+		ExpositionProtobuf:    protobufContentType,
+	} {
+		result, err := svc.parsePrometheusMetrics(encodeExposition(t, format, families), contentType)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", format, err)
+		}
+		parsed[format] = result
+	}
+
+	if !reflect.DeepEqual(parsed[ExpositionOpenMetrics], parsed[ExpositionProtobuf]) {
+		t.Errorf("OpenMetrics and protobuf differ:\n%+v\n%+v", parsed[ExpositionOpenMetrics], parsed[ExpositionProtobuf])
+	}
+	if !reflect.DeepEqual(withoutExtras(parsed[ExpositionOpenMetrics]), parsed[ExpositionText]) {
+		t.Errorf("OpenMetrics and text differ:\n%+v\n%+v", parsed[ExpositionOpenMetrics], parsed[ExpositionText])
+	}
+
+	counter := parsed[ExpositionOpenMetrics].Metrics["neo4j_db_query_execution_success_total"]
+	if len(counter) != 1 || counter[0].Value != 42 || !counter[0].Created.Equal(time.Unix(1760000000, 0)) {
+		t.Fatalf("unexpected counter %+v", counter)
+	}
+	if e := counter[0].Exemplar; e == nil || e.Labels["trace_id"] != "abc123" || e.Value != 0.25 || !e.Timestamp.Equal(time.Unix(1760000100, 0)) {
+		t.Errorf("unexpected counter exemplar %+v", counter[0].Exemplar)
+	}
+	hist := parsed[ExpositionOpenMetrics].Metrics["neo4j_db_query_execution_latency_millis"][0]
+	if hist.Buckets[0].Exemplar == nil || hist.Buckets[1].Exemplar != nil || hist.Count != 10 {
+		t.Errorf("unexpected histogram %+v", hist)
+	}
+	if _, ok := parsed[ExpositionOpenMetrics].Metrics["neo4j_db_query_execution_success_created"]; ok {
+		t.Error("expected _created to be folded into the counter")
+	}
+}
+
+// TestParsePrometheusMetrics_OpenMetrics covers OpenMetrics features the
+// encoder round trip does not
+func TestParsePrometheusMetrics_OpenMetrics(t *testing.T) {
+	const input = `# TYPE neo4j_build info
+# HELP neo4j_build Build information.
+neo4j_build_info{version="5.26.0",edition="enterprise"} 1
+# TYPE neo4j_state stateset
+neo4j_state{neo4j_state="online"} 1
+neo4j_state{neo4j_state="offline"} 0
+# TYPE neo4j_page_cache_hits counter
+# UNIT neo4j_page_cache_hits hits
+neo4j_page_cache_hits_total{db="a # b"} 17 1760000000.5 # {trace_id="x"} 1
+neo4j_page_cache_hits_created{db="a # b"} 1759990000
+# TYPE neo4j_queue gaugehistogram
+neo4j_queue_bucket{le="1"} 2
+neo4j_queue_bucket{le="+Inf"} 3
+neo4j_queue_gcount 3
+neo4j_queue_gsum 2.5
+untyped_without_metadata{msg="line\nbreak"} NaN
+# EOF
+`
+	svc := newTestPrometheusService()
+	result, err := svc.parsePrometheusMetrics([]byte(input), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	info := result.Metrics["neo4j_build_info"]
+	if len(info) != 1 || info[0].Type != MetricTypeGauge || info[0].Labels["version"] != "5.26.0" {
+		t.Errorf("unexpected info metric %+v", info)
+	}
+	if state := result.Metrics["neo4j_state"]; len(state) != 2 || state[1].Labels["neo4j_state"] != "offline" {
+		t.Errorf("unexpected stateset %+v", state)
+	}
+
+	hits := result.Metrics["neo4j_page_cache_hits_total"]
+	if len(hits) != 1 || hits[0].Labels["db"] != "a # b" || hits[0].Value != 17 || hits[0].Timestamp != 1760000000500 {
+		t.Fatalf("unexpected counter %+v", hits)
+	}
+	if hits[0].Exemplar == nil || hits[0].Exemplar.Labels["trace_id"] != "x" || !hits[0].Exemplar.Timestamp.IsZero() {
+		t.Errorf("unexpected exemplar %+v", hits[0].Exemplar)
+	}
+	if !hits[0].Created.Equal(time.Unix(1759990000, 0)) {
+		t.Errorf("expected created time, got %v", hits[0].Created)
+	}
+
+	queue := result.Metrics["neo4j_queue"]
+	if len(queue) != 1 || queue[0].Type != MetricTypeHistogram || queue[0].Count != 3 || queue[0].Sum != 2.5 || len(queue[0].Buckets) != 2 {
+		t.Errorf("unexpected gauge histogram %+v", queue)
+	}
+
+	untyped := result.Metrics["untyped_without_metadata"]
+	if len(untyped) != 1 || untyped[0].Type != MetricTypeUntyped || !math.IsNaN(untyped[0].Value) || untyped[0].Labels["msg"] != "line\nbreak" {
+		t.Errorf("unexpected untyped metric %+v", untyped)
+	}
+}
+
+func TestParsePrometheusMetrics_Errors(t *testing.T) {
+	svc := newTestPrometheusService()
+	tests := []struct {
+		name        string
+		input       string
+		contentType string
+	}{
+		{"content after EOF", "a 1\n# EOF\nb 2\n", "application/openmetrics-text"},
+		{"bad value", "a one\n# EOF\n", "application/openmetrics-text"},
+		{"unknown type", "# TYPE a widget\n# EOF\n", "application/openmetrics-text"},
+		{"bucket without le", "# TYPE h histogram\nh_bucket 1\n# EOF\n", "application/openmetrics-text"},
+		{"unterminated label", "a{b=\"c} 1\n# EOF\n", "application/openmetrics-text"},
+		{"text protobuf", "a 1\n", "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text"},
+		{"truncated protobuf", "\x10\x0a", protobufContentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.parsePrometheusMetrics([]byte(tt.input), tt.contentType); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestPrometheusService_FetchRawMetrics_Negotiation verifies the Accept header
+// and that the response Content-Type selects the parser
+func TestPrometheusService_FetchRawMetrics_Negotiation(t *testing.T) {
+	const url = "https://c9f0d13a.metrics.neo4j.io/prometheus"
+	mock := newMockAPIServiceRouter().
+		onContentType("GET", url, protobufContentType, encodeExposition(t, ExpositionProtobuf, expositionFamilies()))
+	svc := &prometheusService{api: mock, timeout: 30 * time.Second, logger: testLogger()}
+	ctx := context.Background()
+
+	result, err := svc.FetchRawMetrics(ctx, url)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.Metrics["neo4j_aura_cpu_usage"]) != 2 {
+		t.Errorf("expected protobuf to be parsed, got %+v", result.Metrics)
+	}
+	accept := mock.callsTo("GET", url)[0].headers["Accept"]
+	if !strings.HasPrefix(accept, "text/plain;version=0.0.4;q=1,application/openmetrics-text") || !strings.HasSuffix(accept, "*/*;q=0.1") {
+		t.Errorf("unexpected default Accept %q", accept)
+	}
+
+	if _, err := svc.FetchRawMetrics(ctx, url, WithAcceptFormats(ExpositionProtobuf, ExpositionOpenMetrics)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=1,application/openmetrics-text;version=1.0.0;q=0.9,*/*;q=0.1"
+	if accept := mock.callsTo("GET", url)[1].headers["Accept"]; accept != want {
+		t.Errorf("expected Accept %q, got %q", want, accept)
+	}
+
+	if _, err := svc.FetchRawMetrics(ctx, url, WithAcceptFormats()); err == nil {
+		t.Error("expected error for no formats")
+	}
+	if _, err := svc.FetchRawMetrics(ctx, url, WithAcceptFormats("json")); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
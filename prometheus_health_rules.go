@@ -0,0 +1,377 @@
+package aura
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// Overall health statuses reported in PrometheusHealthMetrics.OverallStatus.
+// HealthStatusUnknown is only used in fleet reports, for instances whose
+// health could not be determined.
+const (
+	HealthStatusHealthy  = "healthy"
+	HealthStatusWarning  = "warning"
+	HealthStatusCritical = "critical"
+	HealthStatusUnknown  = "unknown"
+)
+
+// HealthComparator is how a HealthRule compares a value against its thresholds.
+type HealthComparator string
+
+// Supported comparators. A threshold is breached when `value comparator threshold` holds.
+const (
+	CompareGreater        HealthComparator = ">"
+	CompareGreaterOrEqual HealthComparator = ">="
+	CompareLess           HealthComparator = "<"
+	CompareLessOrEqual    HealthComparator = "<="
+)
+
+// HealthRuleSet is a named list of rules used by GetInstanceHealth to assess an
+// instance. Rule sets can be built in code, loaded from YAML or JSON with
+// ParseHealthRules or LoadHealthRules, or derived from DefaultHealthRules.
+type HealthRuleSet struct {
+	Name  string       `json:"name,omitempty" yaml:"name,omitempty"`
+	Rules []HealthRule `json:"rules" yaml:"rules"`
+}
+
+// HealthRule checks one value against warning and critical thresholds. The
+// value is either a field of PrometheusHealthMetrics, named by Metric, or the
+// result of a metric query (see MetricQuery) against the raw scrape, given by
+// Query. A query that returns several series is checked once per series.
+//
+// Issue and recommendation text may contain the placeholders {value}, the
+// checked value to one decimal place, and {labels}, the labels of the series
+// for query rules.
+type HealthRule struct {
+	Name   string `json:"name" yaml:"name"`
+	Metric string `json:"metric,omitempty" yaml:"metric,omitempty"`
+	Query  string `json:"query,omitempty" yaml:"query,omitempty"`
+	// Requires names a PrometheusHealthMetrics field that must be non-zero for
+	// the rule to apply, e.g. max_connections when the limit may be unknown.
+	Requires   string           `json:"requires,omitempty" yaml:"requires,omitempty"`
+	Comparator HealthComparator `json:"comparator" yaml:"comparator"`
+	Warning    *HealthThreshold `json:"warning,omitempty" yaml:"warning,omitempty"`
+	Critical   *HealthThreshold `json:"critical,omitempty" yaml:"critical,omitempty"`
+
+	query *MetricQuery // Query, parsed by Validate
+}
+
+// HealthThreshold is one severity level of a HealthRule.
+type HealthThreshold struct {
+	Value          float64 `json:"value" yaml:"value"`
+	Issue          string  `json:"issue,omitempty" yaml:"issue,omitempty"`
+	Recommendation string  `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+}
+
+// HealthOption configures a GetInstanceHealth call.
+type HealthOption func(*healthOptions) error
+
+// healthOptions holds the settings applied by HealthOption values.
+type healthOptions struct {
+	rules    *HealthRuleSet
+	detector *AnomalyDetector
+}
+
+// healthFields maps the names usable in HealthRule.Metric and
+// HealthRule.Requires to the PrometheusHealthMetrics value they read.
+var healthFields = map[string]func(*PrometheusHealthMetrics) float64{
+	"cpu_usage_percent":        func(m *PrometheusHealthMetrics) float64 { return m.Resources.CPUUsagePercent },
+	"memory_usage_percent":     func(m *PrometheusHealthMetrics) float64 { return m.Resources.MemoryUsagePercent },
+	"queries_per_second":       func(m *PrometheusHealthMetrics) float64 { return m.Query.QueriesPerSecond },
+	"avg_latency_ms":           func(m *PrometheusHealthMetrics) float64 { return m.Query.AvgLatencyMS },
+	"active_connections":       func(m *PrometheusHealthMetrics) float64 { return float64(m.Connections.ActiveConnections) },
+	"max_connections":          func(m *PrometheusHealthMetrics) float64 { return float64(m.Connections.MaxConnections) },
+	"connection_usage_percent": func(m *PrometheusHealthMetrics) float64 { return m.Connections.UsagePercent },
+	"page_cache_hit_rate":      func(m *PrometheusHealthMetrics) float64 { return m.Storage.PageCacheHitRate },
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithHealthRules makes GetInstanceHealth assess the instance with rules
+// instead of DefaultHealthRules. The rule set is validated, and its queries
+// parsed, once when WithHealthRules is called; the option keeps its own copy,
+// so it may be reused across calls and goroutines and rules is never
+// modified.
+func WithHealthRules(rules *HealthRuleSet) HealthOption {
+	var compiled *HealthRuleSet
+	err := errors.New("health rules must not be nil")
+	if rules != nil {
+		compiled, err = rules.compile()
+	}
+	return func(o *healthOptions) error {
+		if err != nil {
+			return err
+		}
+		o.rules = compiled
+		return nil
+	}
+}
+
+// ============================================================================
+// Rule sets
+// ============================================================================
+
+// DefaultHealthRules returns the rule set GetInstanceHealth uses when no
+// WithHealthRules option is given: CPU above 80% / 95%, heap above 85% / 95%,
+// connection usage above 80% / 95% and page cache hit rate below 50% / 20%.
+// Each call returns a new copy that may be modified freely.
+func DefaultHealthRules() *HealthRuleSet {
+	return &HealthRuleSet{
+		Name: "default",
+		Rules: []HealthRule{
+			{
+				Name:       "cpu",
+				Metric:     "cpu_usage_percent",
+				Comparator: CompareGreater,
+				Warning:    &HealthThreshold{Value: 80, Issue: "High CPU usage: {value}%", Recommendation: "Consider scaling to a larger instance size"},
+				Critical:   &HealthThreshold{Value: 95, Issue: "Critical CPU usage: {value}%", Recommendation: "Scale to a larger instance size immediately"},
+			},
+			{
+				Name:       "memory",
+				Metric:     "memory_usage_percent",
+				Comparator: CompareGreater,
+				Warning:    &HealthThreshold{Value: 85, Issue: "High memory usage: {value}%", Recommendation: "Consider scaling to a larger memory instance"},
+				Critical:   &HealthThreshold{Value: 95, Issue: "Critical memory usage: {value}%", Recommendation: "Scale to a larger memory instance immediately"},
+			},
+			{
+				Name:       "connections",
+				Metric:     "connection_usage_percent",
+				Requires:   "max_connections",
+				Comparator: CompareGreater,
+				Warning:    &HealthThreshold{Value: 80, Issue: "High connection usage: {value}%", Recommendation: "Review connection pooling configuration in your application"},
+				Critical:   &HealthThreshold{Value: 95, Issue: "Critical connection usage: {value}%", Recommendation: "Reduce active connections immediately; review connection pooling"},
+			},
+			{
+				Name:       "page_cache",
+				Metric:     "page_cache_hit_rate",
+				Requires:   "page_cache_hit_rate",
+				Comparator: CompareLess,
+				Warning:    &HealthThreshold{Value: 50, Issue: "Low page cache hit rate: {value}%", Recommendation: "Consider increasing page cache size for better performance"},
+				Critical:   &HealthThreshold{Value: 20, Issue: "Critical page cache hit rate: {value}%", Recommendation: "Increase page cache size immediately; query performance is severely degraded"},
+			},
+		},
+	}
+}
+
+// ParseHealthRules decodes a rule set from YAML or JSON and validates it.
+// Unknown fields are rejected so that typos do not silently disable a rule.
+func ParseHealthRules(data []byte) (*HealthRuleSet, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var rules HealthRuleSet
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to parse health rules: %w", err)
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// LoadHealthRules reads a YAML or JSON rule set from path.
+func LoadHealthRules(path string) (*HealthRuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read health rules: %w", err)
+	}
+	return ParseHealthRules(data)
+}
+
+// Validate checks every rule and returns all problems found as
+// ValidationErrors.
+func (s *HealthRuleSet) Validate() error {
+	var errs ValidationErrors
+	if len(s.Rules) == 0 {
+		errs.add("rules", "", errors.New("at least one rule is required"))
+	}
+	for i, r := range s.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		if r.Name == "" {
+			errs.add(field+".name", "", errors.New("is required"))
+		}
+		switch {
+		case r.Metric == "" && r.Query == "":
+			errs.add(field, r.Name, errors.New("one of metric or query is required"))
+		case r.Metric != "" && r.Query != "":
+			errs.add(field, r.Name, errors.New("metric and query are mutually exclusive"))
+		case r.Metric != "":
+			if _, ok := healthFields[r.Metric]; !ok {
+				errs.add(field+".metric", r.Metric, fmt.Errorf("must be one of %s", strings.Join(healthFieldNames(), ", ")))
+			}
+		default:
+			_, err := ParseMetricQuery(r.Query)
+			errs.add(field+".query", r.Query, err)
+		}
+		if r.Requires != "" {
+			if _, ok := healthFields[r.Requires]; !ok {
+				errs.add(field+".requires", r.Requires, fmt.Errorf("must be one of %s", strings.Join(healthFieldNames(), ", ")))
+			}
+		}
+		switch r.Comparator {
+		case CompareGreater, CompareGreaterOrEqual, CompareLess, CompareLessOrEqual:
+		default:
+			errs.add(field+".comparator", string(r.Comparator), errors.New("must be one of >, >=, <, <="))
+		}
+		if r.Warning == nil && r.Critical == nil {
+			errs.add(field, r.Name, errors.New("at least one of warning or critical is required"))
+		}
+		if r.Warning != nil && r.Critical != nil && r.compare(r.Warning.Value, r.Critical.Value) {
+			errs.add(field+".critical", fmt.Sprint(r.Critical.Value), errors.New("must be more severe than the warning threshold"))
+		}
+	}
+	return errs.err()
+}
+
+// compile validates s and returns a copy whose rules hold their parsed
+// queries, so that Evaluate does not parse them again.
+func (s *HealthRuleSet) compile() (*HealthRuleSet, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	out := &HealthRuleSet{Name: s.Name, Rules: append([]HealthRule(nil), s.Rules...)}
+	for i := range out.Rules {
+		if out.Rules[i].Query != "" {
+			// The query was parsed successfully by Validate.
+			out.Rules[i].query, _ = ParseMetricQuery(out.Rules[i].Query)
+		}
+	}
+	return out, nil
+}
+
+// healthFieldNames returns the valid HealthRule.Metric names, sorted.
+func healthFieldNames() []string {
+	names := make([]string, 0, len(healthFields))
+	for name := range healthFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ============================================================================
+// Evaluation
+// ============================================================================
+
+// Evaluate applies every rule to health, appending an issue and recommendation
+// for each breached threshold, and returns the overall status: critical if any
+// critical threshold is breached, otherwise warning if any warning threshold
+// is, otherwise healthy. raw is the scrape the health metrics came from and is
+// only needed by query rules; they are skipped when it is nil.
+//
+// A rule whose query cannot be evaluated is skipped and the others are still
+// applied; the returned error lists every rule that failed.
+func (s *HealthRuleSet) Evaluate(health *PrometheusHealthMetrics, raw *PrometheusMetricsResponse) (string, error) {
+	status := HealthStatusHealthy
+	var errs []error
+
+	// elevate raises status to the requested level only if it is a higher
+	// severity than the current one. This ensures critical is never downgraded
+	// to warning even when multiple rules are evaluated.
+	elevate := func(to string) {
+		if to == HealthStatusCritical || (to == HealthStatusWarning && status == HealthStatusHealthy) {
+			status = to
+		}
+	}
+
+	for _, r := range s.Rules {
+		if r.Requires != "" {
+			if get, ok := healthFields[r.Requires]; !ok || get(health) == 0 {
+				continue
+			}
+		}
+		values, err := r.values(health, raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("health rule %q: %w", r.Name, err))
+			continue
+		}
+		for _, v := range values {
+			var threshold *HealthThreshold
+			var level string
+			switch {
+			case r.Critical != nil && r.compare(v.Value, r.Critical.Value):
+				threshold, level = r.Critical, HealthStatusCritical
+			case r.Warning != nil && r.compare(v.Value, r.Warning.Value):
+				threshold, level = r.Warning, HealthStatusWarning
+			default:
+				continue
+			}
+			issue := threshold.Issue
+			if issue == "" {
+				issue = fmt.Sprintf("%s %s: {value}", r.Name, level)
+			}
+			health.Issues = append(health.Issues, expandHealthText(issue, v))
+			if threshold.Recommendation != "" {
+				health.Recommendations = append(health.Recommendations, expandHealthText(threshold.Recommendation, v))
+			}
+			elevate(level)
+		}
+	}
+	return status, errors.Join(errs...)
+}
+
+// values returns the values a rule checks.
+func (r HealthRule) values(health *PrometheusHealthMetrics, raw *PrometheusMetricsResponse) ([]MetricSample, error) {
+	return healthValues(r.Metric, r.Query, r.query, health, raw)
+}
+
+// healthValues returns the named health field as a single sample, or every
+// series returned by query against raw when metric is empty. parsed is the
+// query parsed by WithHealthRules or NewAnomalyDetector; it is parsed again
+// only when missing or stale, as when Evaluate is called directly.
+func healthValues(metric, query string, parsed *MetricQuery, health *PrometheusHealthMetrics, raw *PrometheusMetricsResponse) ([]MetricSample, error) {
+	if metric != "" {
+		get, ok := healthFields[metric]
+		if !ok {
+			return nil, fmt.Errorf("unknown health metric %q", metric)
+		}
+		return []MetricSample{{Name: metric, Value: get(health)}}, nil
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	if parsed == nil || parsed.String() != query {
+		q, err := ParseMetricQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		parsed = q
+	}
+	return parsed.Eval(raw)
+}
+
+// compare reports whether value breaches threshold under the rule's comparator.
+func (r HealthRule) compare(value, threshold float64) bool {
+	switch r.Comparator {
+	case CompareGreater:
+		return value > threshold
+	case CompareGreaterOrEqual:
+		return value >= threshold
+	case CompareLess:
+		return value < threshold
+	case CompareLessOrEqual:
+		return value <= threshold
+	}
+	return false
+}
+
+// expandHealthText substitutes the {value} and {labels} placeholders.
+func expandHealthText(text string, sample MetricSample) string {
+	labels := seriesKey("", sample.Labels)
+	return strings.NewReplacer(
+		"{value}", fmt.Sprintf("%.1f", sample.Value),
+		"{labels}", labels,
+	).Replace(text)
+}
@@ -0,0 +1,283 @@
+package aura
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const healthRulesYAML = `
+name: batch-workload
+rules:
+  - name: cpu
+    metric: cpu_usage_percent
+    comparator: ">"
+    warning:
+      value: 90
+      issue: "High CPU usage: {value}%"
+    critical:
+      value: 99
+      issue: "Critical CPU usage: {value}%"
+      recommendation: Scale up
+  - name: nodes
+    query: 'neo4j_database_count_node{database!="system"}'
+    comparator: ">="
+    warning:
+      value: 1000
+      issue: "Large database {labels}: {value} nodes"
+`
+
+const healthRulesJSON = `{
+  "name": "json",
+  "rules": [
+    {"name": "latency", "metric": "avg_latency_ms", "comparator": ">", "critical": {"value": 500}}
+  ]
+}`
+
+func TestParseHealthRules(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		rules, err := ParseHealthRules([]byte(healthRulesYAML))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if rules.Name != "batch-workload" || len(rules.Rules) != 2 {
+			t.Fatalf("Unexpected rule set: %+v", rules)
+		}
+		if rules.Rules[0].Critical.Recommendation != "Scale up" || rules.Rules[1].Comparator != CompareGreaterOrEqual {
+			t.Errorf("Unexpected rules: %+v", rules.Rules)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		rules, err := ParseHealthRules([]byte(healthRulesJSON))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if rules.Rules[0].Metric != "avg_latency_ms" || rules.Rules[0].Critical.Value != 500 {
+			t.Errorf("Unexpected rules: %+v", rules.Rules)
+		}
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		if _, err := ParseHealthRules([]byte("rules:\n  - name: cpu\n    metrc: cpu_usage_percent\n")); err == nil {
+			t.Error("Expected error for unknown field")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParseHealthRules([]byte(`rules:
+  - metric: disk_usage
+    comparator: "=="
+  - name: both
+    metric: cpu_usage_percent
+    query: neo4j_aura_cpu_usage
+    comparator: ">"
+    warning: {value: 95}
+    critical: {value: 80}
+  - name: badquery
+    query: "sum("
+    requires: nope
+    comparator: "<"
+    warning: {value: 1}
+`))
+		var verrs ValidationErrors
+		if !errors.As(err, &verrs) {
+			t.Fatalf("Expected ValidationErrors, got %v", err)
+		}
+		fields := map[string]bool{}
+		for _, v := range verrs {
+			fields[v.Field] = true
+		}
+		for _, want := range []string{"rules[0].name", "rules[0].metric", "rules[0].comparator", "rules[0]", "rules[1]", "rules[1].critical", "rules[2].query", "rules[2].requires"} {
+			if !fields[want] {
+				t.Errorf("Expected a problem with %s, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if _, err := ParseHealthRules([]byte("name: empty\n")); err == nil {
+			t.Error("Expected error for a rule set without rules")
+		}
+	})
+}
+
+func TestLoadHealthRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(healthRulesYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadHealthRules(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rules.Rules) != 2 {
+		t.Errorf("Expected 2 rules, got %d", len(rules.Rules))
+	}
+
+	if _, err := LoadHealthRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestHealthRuleSet_Evaluate(t *testing.T) {
+	rules, err := ParseHealthRules([]byte(healthRulesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{
+		"neo4j_database_count_node": {
+			{Labels: map[string]string{"database": "neo4j"}, Value: 2500},
+			{Labels: map[string]string{"database": "system"}, Value: 5000},
+			{Labels: map[string]string{"database": "small"}, Value: 10},
+		},
+	}}
+
+	health := &PrometheusHealthMetrics{Resources: ResourceMetrics{CPUUsagePercent: 85}}
+	if status, err := rules.Evaluate(health, raw); err != nil || status != HealthStatusWarning {
+		t.Errorf("Expected warning, got %s (err %v)", status, err)
+	}
+	want := []string{`Large database {database="neo4j"}: 2500.0 nodes`}
+	if !reflect.DeepEqual(health.Issues, want) {
+		t.Errorf("Expected issues %v, got %v", want, health.Issues)
+	}
+
+	health = &PrometheusHealthMetrics{Resources: ResourceMetrics{CPUUsagePercent: 99.5}}
+	if status, err := rules.Evaluate(health, nil); err != nil || status != HealthStatusCritical {
+		t.Errorf("Expected critical, got %s (err %v)", status, err)
+	}
+	if !reflect.DeepEqual(health.Issues, []string{"Critical CPU usage: 99.5%"}) || !reflect.DeepEqual(health.Recommendations, []string{"Scale up"}) {
+		t.Errorf("Unexpected issues %v / recommendations %v", health.Issues, health.Recommendations)
+	}
+
+	latency, _ := ParseHealthRules([]byte(healthRulesJSON))
+	health = &PrometheusHealthMetrics{Query: QueryMetrics{AvgLatencyMS: 750}}
+	latency.Evaluate(health, nil)
+	if !reflect.DeepEqual(health.Issues, []string{"latency critical: 750.0"}) {
+		t.Errorf("Expected default issue text, got %v", health.Issues)
+	}
+}
+
+func TestHealthRuleSet_EvaluateErrors(t *testing.T) {
+	rules, err := ParseHealthRules([]byte(healthRulesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o healthOptions
+	if err := WithHealthRules(rules)(&o); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range o.rules.Rules {
+		if r.Query != "" && (r.query == nil || r.query.String() != r.Query) {
+			t.Errorf("Expected rule %q to hold its parsed query", r.Name)
+		}
+		if rules.Rules[i].query != nil {
+			t.Errorf("Expected the caller's rule %q to be left unchanged", r.Name)
+		}
+	}
+
+	// Rules built in code and never validated are still applied, and the
+	// ones that cannot be evaluated are reported rather than dropped.
+	unvalidated := &HealthRuleSet{Rules: []HealthRule{
+		{Name: "broken", Query: `neo4j_aura_cpu_usage{`, Comparator: CompareGreater, Warning: &HealthThreshold{Value: 1}},
+		{Name: "nodes", Query: "neo4j_database_count_node", Comparator: CompareGreater, Critical: &HealthThreshold{Value: 1}},
+	}}
+	raw := &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{
+		"neo4j_database_count_node": {{Labels: map[string]string{"database": "neo4j"}, Value: 2}},
+	}}
+	status, err := unvalidated.Evaluate(&PrometheusHealthMetrics{}, raw)
+	if status != HealthStatusCritical {
+		t.Errorf("Expected the valid rule to apply, got %s", status)
+	}
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) {
+		t.Errorf("Expected an error naming the broken rule, got %v", err)
+	}
+}
+
+func TestPrometheusService_GetInstanceHealth_SharedHealthRules(t *testing.T) {
+	const url = "https://c9f0d13a.metrics.neo4j.io/prometheus"
+	scrape := "neo4j_aura_cpu_usage 0.5\nneo4j_aura_cpu_limit 1\nneo4j_database_count_node{database=\"neo4j\"} 2500\n"
+	mock := newMockAPIServiceRouter().on("GET", url, []byte(scrape))
+	svc := &prometheusService{api: mock, timeout: 30 * time.Second, logger: testLogger()}
+	rules, err := ParseHealthRules([]byte(healthRulesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One rule set, and one option, shared by concurrent calls as FleetHealth
+	// and the auraprom exporter do; run with -race.
+	shared := WithHealthRules(rules)
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		for _, opt := range []HealthOption{shared, WithHealthRules(rules)} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				health, err := svc.GetInstanceHealth(context.Background(), "c9f0d13a", url, opt)
+				if err == nil && health.OverallStatus != HealthStatusWarning {
+					err = fmt.Errorf("expected warning, got %s", health.OverallStatus)
+				}
+				errs <- err
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPrometheusService_GetInstanceHealth_WithHealthRules(t *testing.T) {
+	const url = "https://c9f0d13a.metrics.neo4j.io/prometheus"
+	scrape := "neo4j_aura_cpu_usage 0.5\nneo4j_aura_cpu_limit 1\n"
+	mock := newMockAPIServiceRouter().on("GET", url, []byte(scrape))
+	svc := &prometheusService{api: mock, timeout: 30 * time.Second, logger: testLogger()}
+	ctx := context.Background()
+
+	health, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if health.OverallStatus != HealthStatusHealthy {
+		t.Errorf("Expected healthy with default rules, got %s", health.OverallStatus)
+	}
+
+	strict := DefaultHealthRules()
+	strict.Rules[0].Warning.Value = 40
+	health, err = svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithHealthRules(strict))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if health.OverallStatus != HealthStatusWarning || len(health.Issues) != 1 {
+		t.Errorf("Expected one warning with strict rules, got %s %v", health.OverallStatus, health.Issues)
+	}
+
+	if _, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithHealthRules(nil)); err == nil {
+		t.Error("Expected error for nil rules")
+	}
+	if _, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url, WithHealthRules(&HealthRuleSet{})); err == nil {
+		t.Error("Expected error for invalid rules")
+	}
+}
+
+func TestDefaultHealthRules_Valid(t *testing.T) {
+	if err := DefaultHealthRules().Validate(); err != nil {
+		t.Errorf("Default rules must be valid: %v", err)
+	}
+	a, b := DefaultHealthRules(), DefaultHealthRules()
+	a.Rules[0].Warning.Value = 1
+	if b.Rules[0].Warning.Value == 1 {
+		t.Error("DefaultHealthRules must return independent copies")
+	}
+}
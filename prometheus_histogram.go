@@ -0,0 +1,129 @@
+package aura
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// HistogramBucket is one cumulative bucket of a Prometheus histogram: the
+// number of observations less than or equal to UpperBound.
+type HistogramBucket struct {
+	UpperBound      float64 `json:"upper_bound"`
+	CumulativeCount float64 `json:"cumulative_count"`
+	// Exemplar is the bucket's exemplar, if exposed.
+	Exemplar *Exemplar `json:"exemplar,omitempty"`
+}
+
+// SummaryQuantile is one pre-computed quantile of a Prometheus summary.
+type SummaryQuantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// ============================================================================
+// Quantiles
+// ============================================================================
+
+// Quantile returns the q-quantile (0 ≤ q ≤ 1) of a histogram or summary
+// metric. Histograms are interpolated from their buckets with
+// HistogramQuantile; summaries only expose the quantiles they were configured
+// with, so q must match one of them exactly.
+func (m PrometheusMetric) Quantile(q float64) (float64, error) {
+	switch m.Type {
+	case MetricTypeHistogram:
+		return HistogramQuantile(q, m.Buckets)
+	case MetricTypeSummary:
+		for _, sq := range m.Quantiles {
+			if sq.Quantile == q {
+				return sq.Value, nil
+			}
+		}
+		return 0, fmt.Errorf("summary %s has no %v quantile", m.Name, q)
+	default:
+		return 0, fmt.Errorf("metric %s is a %s, not a histogram or summary", m.Name, m.Type)
+	}
+}
+
+// HistogramQuantile estimates the q-quantile (0 ≤ q ≤ 1) of a histogram from
+// its cumulative buckets, using the same linear interpolation as PromQL's
+// histogram_quantile. Buckets need not be sorted but must include the +Inf
+// bucket. If the quantile falls in the +Inf bucket the highest finite upper
+// bound is returned.
+func HistogramQuantile(q float64, buckets []HistogramBucket) (float64, error) {
+	if q < 0 || q > 1 || math.IsNaN(q) {
+		return 0, fmt.Errorf("quantile must be between 0 and 1, got %v", q)
+	}
+	if len(buckets) == 0 {
+		return 0, fmt.Errorf("histogram has no buckets")
+	}
+
+	sorted := make([]HistogramBucket, len(buckets))
+	copy(sorted, buckets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].UpperBound < sorted[j].UpperBound })
+
+	last := sorted[len(sorted)-1]
+	if !math.IsInf(last.UpperBound, 1) {
+		return 0, fmt.Errorf("histogram has no +Inf bucket")
+	}
+	total := last.CumulativeCount
+	if total == 0 {
+		return 0, fmt.Errorf("histogram has no observations")
+	}
+	if len(sorted) < 2 {
+		return 0, fmt.Errorf("histogram has no finite buckets")
+	}
+
+	rank := q * total
+	b := sort.Search(len(sorted)-1, func(i int) bool { return sorted[i].CumulativeCount >= rank })
+	if b == len(sorted)-1 {
+		return sorted[len(sorted)-2].UpperBound, nil
+	}
+	if b == 0 && sorted[0].UpperBound <= 0 {
+		return sorted[0].UpperBound, nil
+	}
+
+	var lower, below float64
+	if b > 0 {
+		lower = sorted[b-1].UpperBound
+		below = sorted[b-1].CumulativeCount
+	}
+	upper := sorted[b].UpperBound
+	inBucket := sorted[b].CumulativeCount - below
+	if inBucket == 0 {
+		return upper, nil
+	}
+	return lower + (upper-lower)*((rank-below)/inBucket), nil
+}
+
+// MergeHistogramBuckets adds together the buckets of several histogram
+// series, e.g. to compute one latency quantile across all databases of an
+// instance. Buckets are matched by upper bound; the result is sorted.
+func MergeHistogramBuckets(series ...[]HistogramBucket) []HistogramBucket {
+	counts := make(map[float64]float64)
+	for _, buckets := range series {
+		for _, b := range buckets {
+			counts[b.UpperBound] += b.CumulativeCount
+		}
+	}
+	merged := make([]HistogramBucket, 0, len(counts))
+	for upper, count := range counts {
+		merged = append(merged, HistogramBucket{UpperBound: upper, CumulativeCount: count})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].UpperBound < merged[j].UpperBound })
+	return merged
+}
+
+// normaliseBuckets sorts buckets by upper bound and appends the +Inf bucket
+// (holding the total observation count) if the exposition omitted it.
+func normaliseBuckets(buckets []HistogramBucket, count float64) []HistogramBucket {
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].UpperBound < buckets[j].UpperBound })
+	if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].UpperBound, 1) {
+		buckets = append(buckets, HistogramBucket{UpperBound: math.Inf(1), CumulativeCount: count})
+	}
+	return buckets
+}
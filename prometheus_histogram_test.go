@@ -0,0 +1,134 @@
+package aura
+
+import (
+	"math"
+	"testing"
+)
+
+const histogramExposition = `# HELP neo4j_db_query_execution_latency_millis Query latency
+# TYPE neo4j_db_query_execution_latency_millis histogram
+neo4j_db_query_execution_latency_millis_bucket{database="neo4j",le="10"} 50
+neo4j_db_query_execution_latency_millis_bucket{database="neo4j",le="100"} 90
+neo4j_db_query_execution_latency_millis_bucket{database="neo4j",le="1000"} 100
+neo4j_db_query_execution_latency_millis_bucket{database="neo4j",le="+Inf"} 100
+neo4j_db_query_execution_latency_millis_sum{database="neo4j"} 4200
+neo4j_db_query_execution_latency_millis_count{database="neo4j"} 100
+# HELP neo4j_gc_pause_seconds GC pauses
+# TYPE neo4j_gc_pause_seconds summary
+neo4j_gc_pause_seconds{quantile="0.5"} 0.01
+neo4j_gc_pause_seconds{quantile="0.99"} 0.25
+neo4j_gc_pause_seconds_sum 12.5
+neo4j_gc_pause_seconds_count 400
+`
+
+func TestPrometheusService_ParseHistogramAndSummary(t *testing.T) {
+	svc := newTestPrometheusService()
+	result, err := svc.parsePrometheusMetrics([]byte(histogramExposition), "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	hist := result.Metrics["neo4j_db_query_execution_latency_millis"]
+	if len(hist) != 1 {
+		t.Fatalf("Expected 1 histogram series, got %d", len(hist))
+	}
+	h := hist[0]
+	if h.Type != MetricTypeHistogram {
+		t.Errorf("Expected type histogram, got %s", h.Type)
+	}
+	if h.Count != 100 || h.Sum != 4200 || h.Value != 4200 {
+		t.Errorf("Unexpected count/sum/value: %v/%v/%v", h.Count, h.Sum, h.Value)
+	}
+	if len(h.Buckets) != 4 || !math.IsInf(h.Buckets[3].UpperBound, 1) {
+		t.Errorf("Expected 4 buckets ending in +Inf, got %+v", h.Buckets)
+	}
+
+	summary := result.Metrics["neo4j_gc_pause_seconds"]
+	if len(summary) != 1 {
+		t.Fatalf("Expected 1 summary series, got %d", len(summary))
+	}
+	s := summary[0]
+	if s.Type != MetricTypeSummary || s.Count != 400 || s.Sum != 12.5 {
+		t.Errorf("Unexpected summary: %+v", s)
+	}
+	if p99, err := s.Quantile(0.99); err != nil || p99 != 0.25 {
+		t.Errorf("Expected p99 0.25, got %v (err %v)", p99, err)
+	}
+	if _, err := s.Quantile(0.95); err == nil {
+		t.Error("Expected error for a quantile the summary does not expose")
+	}
+
+	p95, err := h.Quantile(0.95)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// rank 95 falls in (100, 1000] which holds observations 91-100.
+	if p95 != 550 {
+		t.Errorf("Expected p95 550, got %v", p95)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	inf := math.Inf(1)
+	buckets := []HistogramBucket{
+		{UpperBound: 0.1, CumulativeCount: 20},
+		{UpperBound: 0.5, CumulativeCount: 60},
+		{UpperBound: 1, CumulativeCount: 90},
+		{UpperBound: inf, CumulativeCount: 100},
+	}
+
+	tests := []struct {
+		name    string
+		q       float64
+		buckets []HistogramBucket
+		want    float64
+		wantErr bool
+	}{
+		{"median interpolated", 0.5, buckets, 0.4, false},
+		{"first bucket from zero", 0.1, buckets, 0.05, false},
+		{"falls in +Inf bucket", 0.99, buckets, 1, false},
+		{"zero quantile", 0, buckets, 0, false},
+		{"unsorted input", 0.5, []HistogramBucket{buckets[3], buckets[1], buckets[0], buckets[2]}, 0.4, false},
+		{"quantile above 1", 1.5, buckets, 0, true},
+		{"negative quantile", -0.1, buckets, 0, true},
+		{"no buckets", 0.5, nil, 0, true},
+		{"missing +Inf", 0.5, buckets[:3], 0, true},
+		{"no observations", 0.5, []HistogramBucket{{UpperBound: 1}, {UpperBound: inf}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HistogramQuantile(tt.q, tt.buckets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HistogramQuantile error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("HistogramQuantile(%v) = %v, want %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeHistogramBuckets(t *testing.T) {
+	inf := math.Inf(1)
+	a := []HistogramBucket{{UpperBound: 1, CumulativeCount: 5}, {UpperBound: inf, CumulativeCount: 10}}
+	b := []HistogramBucket{{UpperBound: inf, CumulativeCount: 30}, {UpperBound: 1, CumulativeCount: 25}}
+
+	merged := MergeHistogramBuckets(a, b)
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(merged))
+	}
+	if merged[0].UpperBound != 1 || merged[0].CumulativeCount != 30 {
+		t.Errorf("Unexpected first bucket: %+v", merged[0])
+	}
+	if !math.IsInf(merged[1].UpperBound, 1) || merged[1].CumulativeCount != 40 {
+		t.Errorf("Unexpected +Inf bucket: %+v", merged[1])
+	}
+}
+
+func TestPrometheusMetric_Quantile_NotDistribution(t *testing.T) {
+	m := PrometheusMetric{Name: "neo4j_aura_cpu_usage", Type: MetricTypeGauge, Value: 1}
+	if _, err := m.Quantile(0.5); err == nil {
+		t.Error("Expected error for a gauge")
+	}
+}
@@ -0,0 +1,638 @@
+package aura
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// MatchOp is the comparison a LabelMatcher applies to a label value.
+type MatchOp string
+
+// Label match operators, as in PromQL.
+const (
+	MatchEqual     MatchOp = "="
+	MatchNotEqual  MatchOp = "!="
+	MatchRegexp    MatchOp = "=~"
+	MatchNotRegexp MatchOp = "!~"
+)
+
+// LabelMatcher is one `label op "value"` term of a selector. A label that is
+// not present on a series has the value "".
+type LabelMatcher struct {
+	Name  string
+	Op    MatchOp
+	Value string
+	re    *regexp.Regexp
+}
+
+// AggregateOp is an aggregation function applied across series.
+type AggregateOp string
+
+// Supported aggregation functions.
+const (
+	AggregateSum   AggregateOp = "sum"
+	AggregateAvg   AggregateOp = "avg"
+	AggregateMin   AggregateOp = "min"
+	AggregateMax   AggregateOp = "max"
+	AggregateCount AggregateOp = "count"
+	AggregateTopK  AggregateOp = "topk"
+)
+
+// MetricQuery is a parsed metric query. Build one with ParseMetricQuery and
+// evaluate it any number of times with Eval.
+//
+// The query language is a small subset of PromQL:
+//
+//	neo4j_aura_cpu_usage
+//	neo4j_aura_cpu_usage{availability_zone=~"eu-west-1[ab]", instance_mode!="secondary"}
+//	{__name__=~"neo4j_database_count_.*"}
+//	sum by (database) (neo4j_db_query_execution_success_total)
+//	avg without (availability_zone) (neo4j_aura_cpu_usage)
+//	topk(3, neo4j_database_count_node)
+//
+// Aggregations (sum, avg, min, max, count, topk) may be nested. Regular
+// expressions are fully anchored, as in PromQL.
+type MetricQuery struct {
+	// Selector fields; set when Op is empty.
+	Name     string
+	Matchers []LabelMatcher
+
+	// Aggregation fields; set when Op is not empty.
+	Op      AggregateOp
+	Param   int      // k for topk
+	By      []string // labels to group by
+	Without []string // labels to drop when grouping
+	Inner   *MetricQuery
+
+	raw string
+}
+
+// MetricSample is one series in the result of a MetricQuery. Name is empty for
+// aggregated results, which carry only their grouping labels.
+type MetricSample struct {
+	Name   string            `json:"name,omitempty"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// ============================================================================
+// Service
+// ============================================================================
+
+// Query evaluates a PromQL-style query against metrics. See MetricQuery for
+// the supported syntax.
+func (p *prometheusService) Query(ctx context.Context, metrics *PrometheusMetricsResponse, query string) ([]MetricSample, error) {
+	if err := ctx.Err(); err != nil {
+		p.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	q, err := ParseMetricQuery(query)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "failed to parse metric query", slog.String("query", query), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	result, err := q.Eval(metrics)
+	if err != nil {
+		return nil, err
+	}
+	p.logger.DebugContext(ctx, "metric query evaluated", slog.String("query", query), slog.Int("series", len(result)))
+	return result, nil
+}
+
+// ============================================================================
+// Evaluation
+// ============================================================================
+
+// String returns the query text the MetricQuery was parsed from.
+func (q *MetricQuery) String() string {
+	return q.raw
+}
+
+// Eval runs the query against metrics. Selectors return every matching series
+// sorted by name and labels; aggregations return one sample per group sorted
+// by labels, except topk which returns series by descending value. A query
+// that matches nothing returns an empty, non-nil slice.
+func (q *MetricQuery) Eval(metrics *PrometheusMetricsResponse) ([]MetricSample, error) {
+	if q == nil {
+		return nil, fmt.Errorf("metric query must not be nil")
+	}
+	if metrics == nil {
+		return nil, fmt.Errorf("metrics response must not be nil")
+	}
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	return q.eval(metrics), nil
+}
+
+// validate checks a query tree that may have been built by hand rather than
+// by ParseMetricQuery, so that eval can rely on its shape.
+func (q *MetricQuery) validate() error {
+	if q.Op == "" {
+		for _, m := range q.Matchers {
+			switch m.Op {
+			case MatchEqual, MatchNotEqual, MatchRegexp, MatchNotRegexp:
+			default:
+				return fmt.Errorf("label matcher %s: unknown operator %q", m.Name, m.Op)
+			}
+			if _, err := m.regexp(); err != nil {
+				return fmt.Errorf("label matcher %s%s%q: %w", m.Name, m.Op, m.Value, err)
+			}
+		}
+		return nil
+	}
+	switch q.Op {
+	case AggregateSum, AggregateAvg, AggregateMin, AggregateMax, AggregateCount, AggregateTopK:
+	default:
+		return fmt.Errorf("unknown aggregation %q", q.Op)
+	}
+	if q.Op == AggregateTopK && q.Param < 1 {
+		return fmt.Errorf("topk needs k of at least 1, got %d", q.Param)
+	}
+	if q.Inner == nil {
+		return fmt.Errorf("%s aggregation has no inner query", q.Op)
+	}
+	return q.Inner.validate()
+}
+
+func (q *MetricQuery) eval(metrics *PrometheusMetricsResponse) []MetricSample {
+	if q.Op == "" {
+		return q.selectSeries(metrics)
+	}
+	return q.aggregate(q.Inner.eval(metrics))
+}
+
+// selectSeries returns the series matched by a selector.
+func (q *MetricQuery) selectSeries(metrics *PrometheusMetricsResponse) []MetricSample {
+	out := []MetricSample{}
+	for name, series := range metrics.Metrics {
+		if q.Name != "" && name != q.Name {
+			continue
+		}
+		for _, m := range series {
+			if !q.matches(name, m.Labels) {
+				continue
+			}
+			out = append(out, MetricSample{Name: name, Labels: m.Labels, Value: m.Value})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return seriesKey(out[i].Name, out[i].Labels) < seriesKey(out[j].Name, out[j].Labels)
+	})
+	return out
+}
+
+// matches reports whether a series satisfies every label matcher.
+func (q *MetricQuery) matches(name string, labels map[string]string) bool {
+	for _, m := range q.Matchers {
+		value := labels[m.Name]
+		if m.Name == "__name__" {
+			value = name
+		}
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether value satisfies the matcher.
+func (m LabelMatcher) Matches(value string) bool {
+	switch m.Op {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp, MatchNotRegexp:
+		re, err := m.regexp()
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value) == (m.Op == MatchRegexp)
+	}
+	return false
+}
+
+// regexp returns the anchored expression of a regexp matcher, compiling it
+// when the matcher was built by hand rather than parsed. It returns nil for
+// other operators.
+func (m LabelMatcher) regexp() (*regexp.Regexp, error) {
+	if m.re != nil || (m.Op != MatchRegexp && m.Op != MatchNotRegexp) {
+		return m.re, nil
+	}
+	return compileMatcherRegexp(m.Value)
+}
+
+// compileMatcherRegexp anchors a label value pattern, as PromQL does.
+func compileMatcherRegexp(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// aggregate applies an aggregation to its evaluated input.
+func (q *MetricQuery) aggregate(in []MetricSample) []MetricSample {
+	type group struct {
+		labels map[string]string
+		values []float64
+		series []MetricSample
+	}
+	groups := make(map[string]*group)
+	for _, s := range in {
+		labels := q.groupLabels(s.Labels)
+		key := seriesKey("", labels)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: labels}
+			groups[key] = g
+		}
+		g.values = append(g.values, s.Value)
+		g.series = append(g.series, s)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := []MetricSample{}
+	for _, k := range keys {
+		g := groups[k]
+		if q.Op == AggregateTopK {
+			top := append([]MetricSample(nil), g.series...)
+			sort.SliceStable(top, func(i, j int) bool { return top[i].Value > top[j].Value })
+			if len(top) > q.Param {
+				top = top[:q.Param]
+			}
+			out = append(out, top...)
+			continue
+		}
+		out = append(out, MetricSample{Labels: g.labels, Value: reduce(q.Op, g.values)})
+	}
+	return out
+}
+
+// groupLabels returns the subset of labels that identifies a series' group.
+func (q *MetricQuery) groupLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string)
+	switch {
+	case q.By != nil:
+		for _, name := range q.By {
+			if v, ok := labels[name]; ok {
+				out[name] = v
+			}
+		}
+	case q.Without != nil:
+		for name, v := range labels {
+			out[name] = v
+		}
+		for _, name := range q.Without {
+			delete(out, name)
+		}
+	}
+	return out
+}
+
+// reduce folds the values of one group with op.
+func reduce(op AggregateOp, values []float64) float64 {
+	switch op {
+	case AggregateCount:
+		return float64(len(values))
+	case AggregateMin:
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return min
+	case AggregateMax:
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return max
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	if op == AggregateAvg {
+		return sum / float64(len(values))
+	}
+	return sum
+}
+
+// ============================================================================
+// Parser
+// ============================================================================
+
+// ParseMetricQuery parses a PromQL-style query. See MetricQuery for the
+// supported syntax.
+func ParseMetricQuery(query string) (*MetricQuery, error) {
+	p := &queryParser{input: query}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	q, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	q.raw = query
+	return q, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// queryParser is a recursive-descent parser over the tokens of one query.
+type queryParser struct {
+	input  string
+	tokens []queryToken
+	next   int
+}
+
+func (p *queryParser) errorf(tok queryToken, format string, args ...any) error {
+	return fmt.Errorf("invalid metric query %q at position %d: %s", p.input, tok.pos, fmt.Sprintf(format, args...))
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func (p *queryParser) tokenize() error {
+	s := p.input
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(s) && isIdentChar(s[i]) {
+				i++
+			}
+			p.tokens = append(p.tokens, queryToken{tokIdent, s[start:i], start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				i++
+			}
+			p.tokens = append(p.tokens, queryToken{tokNumber, s[start:i], start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(s) && s[i] != c {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(s) {
+				return fmt.Errorf("invalid metric query %q at position %d: unterminated string", p.input, start)
+			}
+			i++
+			lit := s[start:i]
+			if c == '\'' {
+				lit = `"` + strings.ReplaceAll(strings.ReplaceAll(lit[1:len(lit)-1], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			value, err := strconv.Unquote(lit)
+			if err != nil {
+				return fmt.Errorf("invalid metric query %q at position %d: bad string literal", p.input, start)
+			}
+			p.tokens = append(p.tokens, queryToken{tokString, value, start})
+		case (c == '=' || c == '!') && i+1 < len(s) && (s[i+1] == '~' || (c == '!' && s[i+1] == '=')):
+			p.tokens = append(p.tokens, queryToken{tokPunct, s[i : i+2], i})
+			i += 2
+		case strings.IndexByte("{}(),=", c) >= 0:
+			p.tokens = append(p.tokens, queryToken{tokPunct, s[i : i+1], i})
+			i++
+		default:
+			return fmt.Errorf("invalid metric query %q at position %d: unexpected character %q", p.input, i, c)
+		}
+	}
+	p.tokens = append(p.tokens, queryToken{tokEOF, "end of query", len(s)})
+	return nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) advance() queryToken {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *queryParser) expect(text string) error {
+	tok := p.advance()
+	if tok.kind != tokPunct || tok.text != text {
+		return p.errorf(tok, "expected %q, got %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *queryParser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+// parseExpr parses an aggregation or a selector.
+func (p *queryParser) parseExpr() (*MetricQuery, error) {
+	tok := p.peek()
+	if tok.kind == tokIdent {
+		switch op := AggregateOp(tok.text); op {
+		case AggregateSum, AggregateAvg, AggregateMin, AggregateMax, AggregateCount, AggregateTopK:
+			// A metric may share its name with a function; it is only an
+			// aggregation when followed by "(" or a grouping clause.
+			if after := p.tokens[p.next+1]; after.kind == tokPunct && after.text == "(" ||
+				after.kind == tokIdent && (after.text == "by" || after.text == "without") {
+				return p.parseAggregation(op)
+			}
+		}
+	}
+	return p.parseSelector()
+}
+
+// parseAggregation parses `op [by|without (labels)] ([k,] expr) [by|without (labels)]`.
+func (p *queryParser) parseAggregation(op AggregateOp) (*MetricQuery, error) {
+	p.advance()
+	q := &MetricQuery{Op: op}
+
+	grouped := false
+	if err := p.parseGrouping(q, &grouped); err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if op == AggregateTopK {
+		tok := p.advance()
+		if tok.kind != tokNumber {
+			return nil, p.errorf(tok, "topk expects an integer k, got %q", tok.text)
+		}
+		k, err := strconv.Atoi(tok.text)
+		if err != nil || k < 1 {
+			return nil, p.errorf(tok, "topk k must be a positive integer")
+		}
+		q.Param = k
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	inner, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	q.Inner = inner
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if err := p.parseGrouping(q, &grouped); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// parseGrouping parses an optional `by (labels)` or `without (labels)` clause.
+// grouped tracks whether a clause has already been seen for this aggregation.
+func (p *queryParser) parseGrouping(q *MetricQuery, grouped *bool) error {
+	tok := p.peek()
+	if tok.kind != tokIdent || (tok.text != "by" && tok.text != "without") {
+		return nil
+	}
+	if *grouped {
+		return p.errorf(tok, "duplicate grouping clause")
+	}
+	*grouped = true
+	p.advance()
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	labels := []string{}
+	for !p.isPunct(")") {
+		name := p.advance()
+		if name.kind != tokIdent {
+			return p.errorf(name, "expected label name, got %q", name.text)
+		}
+		labels = append(labels, name.text)
+		if !p.isPunct(",") {
+			break
+		}
+		p.advance()
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+
+	if tok.text == "by" {
+		q.By = labels
+	} else {
+		q.Without = labels
+	}
+	return nil
+}
+
+// parseSelector parses `name`, `name{matchers}` or `{matchers}`.
+func (p *queryParser) parseSelector() (*MetricQuery, error) {
+	q := &MetricQuery{}
+	start := p.peek()
+	if start.kind == tokIdent {
+		q.Name = p.advance().text
+	}
+	if p.isPunct("{") {
+		p.advance()
+		for !p.isPunct("}") {
+			m, err := p.parseMatcher()
+			if err != nil {
+				return nil, err
+			}
+			q.Matchers = append(q.Matchers, m)
+			if !p.isPunct(",") {
+				break
+			}
+			p.advance()
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Name == "" && len(q.Matchers) == 0 {
+		return nil, p.errorf(start, "expected metric selector, got %q", start.text)
+	}
+	if q.Name == "" {
+		// Without a name, at least one matcher must rule series out, or the
+		// selector would return every metric.
+		selective := false
+		for _, m := range q.Matchers {
+			if !m.Matches("") {
+				selective = true
+			}
+		}
+		if !selective {
+			return nil, p.errorf(start, "selector without a metric name must have a matcher that does not match the empty string")
+		}
+	}
+	return q, nil
+}
+
+// parseMatcher parses `label op "value"`.
+func (p *queryParser) parseMatcher() (LabelMatcher, error) {
+	name := p.advance()
+	if name.kind != tokIdent {
+		return LabelMatcher{}, p.errorf(name, "expected label name, got %q", name.text)
+	}
+	opTok := p.advance()
+	op := MatchOp(opTok.text)
+	if opTok.kind != tokPunct || (op != MatchEqual && op != MatchNotEqual && op != MatchRegexp && op != MatchNotRegexp) {
+		return LabelMatcher{}, p.errorf(opTok, "expected one of =, !=, =~, !~, got %q", opTok.text)
+	}
+	value := p.advance()
+	if value.kind != tokString {
+		return LabelMatcher{}, p.errorf(value, "expected quoted label value, got %q", value.text)
+	}
+
+	m := LabelMatcher{Name: name.text, Op: op, Value: value.text}
+	if op == MatchRegexp || op == MatchNotRegexp {
+		re, err := compileMatcherRegexp(value.text)
+		if err != nil {
+			return LabelMatcher{}, p.errorf(value, "invalid regular expression: %v", err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
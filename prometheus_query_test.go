@@ -0,0 +1,204 @@
+package aura
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func queryTestMetrics() *PrometheusMetricsResponse {
+	return &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{
+		"neo4j_aura_cpu_usage": {
+			{Name: "neo4j_aura_cpu_usage", Labels: map[string]string{"availability_zone": "eu-west-1a", "instance_mode": "primary"}, Value: 0.5},
+			{Name: "neo4j_aura_cpu_usage", Labels: map[string]string{"availability_zone": "eu-west-1b", "instance_mode": "primary"}, Value: 0.7},
+			{Name: "neo4j_aura_cpu_usage", Labels: map[string]string{"availability_zone": "eu-west-1c", "instance_mode": "secondary"}, Value: 0.3},
+		},
+		"neo4j_database_count_node": {
+			{Name: "neo4j_database_count_node", Labels: map[string]string{"database": "neo4j"}, Value: 1000},
+			{Name: "neo4j_database_count_node", Labels: map[string]string{"database": "system"}, Value: 10},
+			{Name: "neo4j_database_count_node", Labels: map[string]string{"database": "movies"}, Value: 500},
+		},
+		"neo4j_database_count_relationship": {
+			{Name: "neo4j_database_count_relationship", Labels: map[string]string{"database": "neo4j"}, Value: 3000},
+		},
+	}}
+}
+
+func TestMetricQuery_Eval(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []float64
+	}{
+		{"bare name", "neo4j_aura_cpu_usage", []float64{0.5, 0.7, 0.3}},
+		{"equality", `neo4j_aura_cpu_usage{availability_zone="eu-west-1b"}`, []float64{0.7}},
+		{"inequality", `neo4j_aura_cpu_usage{instance_mode!="secondary"}`, []float64{0.5, 0.7}},
+		{"regexp is anchored", `neo4j_aura_cpu_usage{availability_zone=~"eu-west-1[ab]"}`, []float64{0.5, 0.7}},
+		{"partial regexp does not match", `neo4j_aura_cpu_usage{availability_zone=~"west"}`, []float64{}},
+		{"negative regexp", `neo4j_aura_cpu_usage{availability_zone!~".*a"}`, []float64{0.7, 0.3}},
+		{"single quotes", `neo4j_aura_cpu_usage{availability_zone='eu-west-1c'}`, []float64{0.3}},
+		{"missing label is empty", `neo4j_aura_cpu_usage{database=""}`, []float64{0.5, 0.7, 0.3}},
+		{"name matcher", `{__name__=~"neo4j_database_count_.*", database="neo4j"}`, []float64{1000, 3000}},
+		{"unknown metric", "neo4j_missing", []float64{}},
+		{"sum", "sum(neo4j_aura_cpu_usage)", []float64{1.5}},
+		{"avg", "avg(neo4j_aura_cpu_usage)", []float64{0.5}},
+		{"min", "min(neo4j_aura_cpu_usage)", []float64{0.3}},
+		{"max", "max(neo4j_aura_cpu_usage)", []float64{0.7}},
+		{"count", `count(neo4j_database_count_node{database!="system"})`, []float64{2}},
+		{"sum by", "sum by (instance_mode) (neo4j_aura_cpu_usage)", []float64{1.2, 0.3}},
+		{"trailing by", "max(neo4j_aura_cpu_usage) by (instance_mode)", []float64{0.7, 0.3}},
+		{"without", "sum without (availability_zone) (neo4j_aura_cpu_usage)", []float64{1.2, 0.3}},
+		{"topk", "topk(2, neo4j_database_count_node)", []float64{1000, 500}},
+		{"topk larger than input", "topk(10, neo4j_database_count_relationship)", []float64{3000}},
+		{"nested", "sum(topk(2, neo4j_database_count_node))", []float64{1500}},
+		{"aggregation of nothing", "sum(neo4j_missing)", []float64{}},
+	}
+
+	metrics := queryTestMetrics()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseMetricQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseMetricQuery(%q) error: %v", tt.query, err)
+			}
+			result, err := q.Eval(metrics)
+			if err != nil {
+				t.Fatalf("Eval error: %v", err)
+			}
+			got := make([]float64, 0, len(result))
+			for _, s := range result {
+				got = append(got, s.Value)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if diff := got[i] - tt.want[i]; diff > 1e-9 || diff < -1e-9 {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricQuery_AggregationLabels(t *testing.T) {
+	q, err := ParseMetricQuery("sum by (instance_mode) (neo4j_aura_cpu_usage)")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, err := q.Eval(queryTestMetrics())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result[0].Name != "" {
+		t.Errorf("Expected aggregated sample to have no name, got %q", result[0].Name)
+	}
+	if want := map[string]string{"instance_mode": "primary"}; !reflect.DeepEqual(result[0].Labels, want) {
+		t.Errorf("Expected labels %v, got %v", want, result[0].Labels)
+	}
+
+	q, _ = ParseMetricQuery("topk(1, neo4j_database_count_node)")
+	result, _ = q.Eval(queryTestMetrics())
+	if result[0].Name != "neo4j_database_count_node" || result[0].Labels["database"] != "neo4j" {
+		t.Errorf("Expected topk to keep the series identity, got %+v", result[0])
+	}
+}
+
+func TestParseMetricQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"empty", ""},
+		{"unterminated matchers", `neo4j_aura_cpu_usage{zone="a"`},
+		{"unquoted value", `neo4j_aura_cpu_usage{zone=a}`},
+		{"bad operator", `neo4j_aura_cpu_usage{zone=="a"}`},
+		{"unterminated string", `neo4j_aura_cpu_usage{zone="a}`},
+		{"bad regexp", `neo4j_aura_cpu_usage{zone=~"("}`},
+		{"unknown character", `neo4j_aura_cpu_usage > 1`},
+		{"trailing tokens", `neo4j_aura_cpu_usage neo4j_aura_cpu_usage`},
+		{"topk without k", "topk(neo4j_aura_cpu_usage)"},
+		{"topk zero", "topk(0, neo4j_aura_cpu_usage)"},
+		{"duplicate grouping", "sum by (a) (neo4j_aura_cpu_usage) by (b)"},
+		{"unclosed aggregation", "sum(neo4j_aura_cpu_usage"},
+		{"empty-matching selector", `{zone=~".*"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMetricQuery(tt.query); err == nil {
+				t.Errorf("ParseMetricQuery(%q) expected error", tt.query)
+			}
+		})
+	}
+}
+
+func TestMetricQuery_EvalHandBuilt(t *testing.T) {
+	q := &MetricQuery{Op: AggregateSum, Inner: &MetricQuery{
+		Name:     "neo4j_aura_cpu_usage",
+		Matchers: []LabelMatcher{{Name: "availability_zone", Op: MatchRegexp, Value: "eu-west-1[ab]"}},
+	}}
+	result, err := q.Eval(queryTestMetrics())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result) != 1 || result[0].Value != 1.2 {
+		t.Errorf("Expected the regexp matcher to be compiled on demand, got %+v", result)
+	}
+
+	if !(LabelMatcher{Name: "zone", Op: MatchNotRegexp, Value: "a|b"}).Matches("c") {
+		t.Error("Expected a hand-built !~ matcher to match")
+	}
+
+	for name, q := range map[string]*MetricQuery{
+		"nil inner":        {Op: AggregateMax},
+		"bad regexp":       {Op: AggregateSum, Inner: &MetricQuery{Matchers: []LabelMatcher{{Name: "zone", Op: MatchRegexp, Value: "("}}}},
+		"negative topk":    {Op: AggregateTopK, Param: -1, Inner: &MetricQuery{Name: "neo4j_aura_cpu_usage"}},
+		"zero topk":        {Op: AggregateTopK, Inner: &MetricQuery{Name: "neo4j_aura_cpu_usage"}},
+		"unknown op":       {Op: "median", Inner: &MetricQuery{Name: "neo4j_aura_cpu_usage"}},
+		"unknown match op": {Matchers: []LabelMatcher{{Name: "zone", Op: "==", Value: "a"}}},
+	} {
+		if _, err := q.Eval(queryTestMetrics()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseMetricQuery_MetricNamedLikeFunction(t *testing.T) {
+	metrics := &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{
+		"count": {{Name: "count", Labels: map[string]string{}, Value: 7}},
+	}}
+	q, err := ParseMetricQuery("count")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result, _ := q.Eval(metrics)
+	if len(result) != 1 || result[0].Value != 7 {
+		t.Errorf("Expected the metric named count, got %+v", result)
+	}
+}
+
+func TestPrometheusService_Query(t *testing.T) {
+	svc := newTestPrometheusService()
+	ctx := context.Background()
+
+	result, err := svc.Query(ctx, queryTestMetrics(), "avg by (instance_mode) (neo4j_aura_cpu_usage)")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(result))
+	}
+
+	if _, err := svc.Query(ctx, nil, "neo4j_aura_cpu_usage"); err == nil {
+		t.Error("Expected error for nil metrics")
+	}
+	if _, err := svc.Query(ctx, queryTestMetrics(), "sum("); err == nil {
+		t.Error("Expected parse error")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := svc.Query(cancelled, queryTestMetrics(), "neo4j_aura_cpu_usage"); err == nil {
+		t.Error("Expected error for cancelled context")
+	}
+}
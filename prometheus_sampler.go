@@ -0,0 +1,205 @@
+package aura
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// defaultSampleStaleness is how long a series may be missing from scrapes
+// before MetricsSampler forgets its previous sample.
+const defaultSampleStaleness = 10 * time.Minute
+
+// MetricRate is the per-second rate of increase of one counter series between
+// two successive observations.
+type MetricRate struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels"`
+	PerSecond float64           `json:"per_second"`
+	// Reset is true when the counter went backwards (e.g. after a restart)
+	// and the rate was computed from zero.
+	Reset bool `json:"reset"`
+}
+
+// MetricRates holds the rates computed by one MetricsSampler.Observe call,
+// keyed by metric name.
+type MetricRates struct {
+	Rates map[string][]MetricRate `json:"rates"`
+}
+
+// MetricsSampler turns cumulative Prometheus counters into per-second rates.
+// It keeps the previous sample of every series it observes; each Observe call
+// compares the new scrape against those samples. A sampler tracks one scrape
+// target — use a separate sampler per metrics endpoint. It is safe for
+// concurrent use.
+//
+// Counter resets (the value going down, usually after a restart) are handled
+// as Prometheus does: the new value is taken as the increase since the reset.
+// A series missing from a scrape keeps its previous sample, so the next rate
+// is computed over the longer interval; series missing for longer than the
+// staleness window are forgotten.
+type MetricsSampler struct {
+	mu         sync.Mutex
+	staleAfter time.Duration
+	series     map[string]*seriesSample
+}
+
+// seriesSample is the state kept for one series between observations.
+type seriesSample struct {
+	value    float64    // counter value at the last sample
+	at       time.Time  // sample time of value
+	rate     MetricRate // most recently computed rate
+	hasRate  bool       // whether rate has been computed yet
+	lastSeen time.Time  // observation time the series was last present
+}
+
+// ============================================================================
+// Sampler
+// ============================================================================
+
+// NewMetricsSampler returns an empty MetricsSampler. Series that are absent
+// from scrapes for longer than staleAfter are forgotten; a value of zero or
+// less uses the default of ten minutes.
+func NewMetricsSampler(staleAfter time.Duration) *MetricsSampler {
+	if staleAfter <= 0 {
+		staleAfter = defaultSampleStaleness
+	}
+	return &MetricsSampler{
+		staleAfter: staleAfter,
+		series:     make(map[string]*seriesSample),
+	}
+}
+
+// Observe records every series in metrics and returns the rates that can be
+// computed against the previous observation. The sample time of each series
+// is its exposition timestamp when present, otherwise at. A series seen for
+// the first time has no rate yet. When a series' timestamp has not moved
+// since the last observation (e.g. the endpoint served a cached scrape) its
+// previous rate is reported again.
+//
+// Rates are only meaningful for counters; gauges are sampled too but their
+// "rates" should be ignored.
+func (s *MetricsSampler) Observe(metrics *PrometheusMetricsResponse, at time.Time) *MetricRates {
+	rates := &MetricRates{Rates: make(map[string][]MetricRate)}
+	if metrics == nil {
+		return rates
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, series := range metrics.Metrics {
+		for _, m := range series {
+			sampledAt := at
+			if m.Timestamp > 0 {
+				sampledAt = time.UnixMilli(m.Timestamp)
+			}
+
+			key := seriesKey(name, m.Labels)
+			prev, ok := s.series[key]
+			if !ok {
+				s.series[key] = &seriesSample{value: m.Value, at: sampledAt, lastSeen: at}
+				continue
+			}
+			prev.lastSeen = at
+
+			elapsed := sampledAt.Sub(prev.at).Seconds()
+			if elapsed <= 0 {
+				// No new data since the last scrape; repeat the last known rate.
+				if prev.hasRate {
+					rates.Rates[name] = append(rates.Rates[name], prev.rate)
+				}
+				continue
+			}
+
+			increase := m.Value - prev.value
+			reset := false
+			if increase < 0 {
+				increase = m.Value
+				reset = true
+			}
+
+			prev.rate = MetricRate{Name: name, Labels: m.Labels, PerSecond: increase / elapsed, Reset: reset}
+			prev.hasRate = true
+			prev.value = m.Value
+			prev.at = sampledAt
+			rates.Rates[name] = append(rates.Rates[name], prev.rate)
+		}
+	}
+
+	for key, sample := range s.series {
+		if at.Sub(sample.lastSeen) > s.staleAfter {
+			delete(s.series, key)
+		}
+	}
+
+	return rates
+}
+
+// Reset forgets every stored sample.
+func (s *MetricsSampler) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series = make(map[string]*seriesSample)
+}
+
+// Rate returns the summed per-second rate of every series of name whose labels
+// match labelFilters exactly. Summing gives, for example, the total query rate
+// across all databases of an instance. An error is returned when no matching
+// series has a rate yet.
+func (r *MetricRates) Rate(name string, labelFilters map[string]string) (float64, error) {
+	if r == nil {
+		return 0, fmt.Errorf("metric rates must not be nil")
+	}
+	var sum float64
+	found := false
+	for _, rate := range r.Rates[name] {
+		if !labelsMatch(rate.Labels, labelFilters) {
+			continue
+		}
+		sum += rate.PerSecond
+		found = true
+	}
+	if !found {
+		return 0, fmt.Errorf("no rate available for %s", name)
+	}
+	return sum, nil
+}
+
+// seriesKey returns a stable identity for a series: its name followed by its
+// labels in sorted order.
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", k, labels[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelsMatch reports whether labels contains every key/value in filters.
+func labelsMatch(labels, filters map[string]string) bool {
+	for k, v := range filters {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package aura
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// counterScrape builds a metrics response holding one counter series per
+// database value, all stamped with ts (milliseconds; 0 for none).
+func counterScrape(name string, ts int64, values map[string]float64) *PrometheusMetricsResponse {
+	resp := &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{}}
+	for db, v := range values {
+		resp.Metrics[name] = append(resp.Metrics[name], PrometheusMetric{
+			Name: name, Labels: map[string]string{"database": db}, Value: v, Timestamp: ts,
+		})
+	}
+	return resp
+}
+
+func TestMetricsSampler_Rate(t *testing.T) {
+	const name = "neo4j_db_query_execution_success_total"
+	start := time.Unix(1_700_000_000, 0)
+
+	t.Run("FirstObservationHasNoRate", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		rates := s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 100}), start)
+		if _, err := rates.Rate(name, nil); err == nil {
+			t.Error("expected no rate on first observation")
+		}
+	})
+
+	t.Run("RateSummedAcrossSeries", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 100, "system": 10}), start)
+		rates := s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 700, "system": 70}), start.Add(60*time.Second))
+
+		got, err := rates.Rate(name, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != 11 {
+			t.Errorf("expected 11/s, got %v", got)
+		}
+		got, err = rates.Rate(name, map[string]string{"database": "neo4j"})
+		if err != nil || got != 10 {
+			t.Errorf("expected 10/s for neo4j, got %v (err %v)", got, err)
+		}
+	})
+
+	t.Run("UsesExpositionTimestamps", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		ts := start.UnixMilli()
+		s.Observe(counterScrape(name, ts, map[string]float64{"neo4j": 0}), start)
+		// Observed only one second apart, but the samples are 30s apart.
+		rates := s.Observe(counterScrape(name, ts+30_000, map[string]float64{"neo4j": 300}), start.Add(time.Second))
+		if got, _ := rates.Rate(name, nil); got != 10 {
+			t.Errorf("expected 10/s, got %v", got)
+		}
+	})
+
+	t.Run("CounterReset", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 1000}), start)
+		rates := s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 50}), start.Add(10*time.Second))
+		got, err := rates.Rate(name, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != 5 {
+			t.Errorf("expected 5/s after reset, got %v", got)
+		}
+		if !rates.Rates[name][0].Reset {
+			t.Error("expected Reset to be true")
+		}
+	})
+
+	t.Run("UnchangedTimestampRepeatsLastRate", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		ts := start.UnixMilli()
+		s.Observe(counterScrape(name, ts, map[string]float64{"neo4j": 0}), start)
+		s.Observe(counterScrape(name, ts+10_000, map[string]float64{"neo4j": 20}), start.Add(10*time.Second))
+		rates := s.Observe(counterScrape(name, ts+10_000, map[string]float64{"neo4j": 20}), start.Add(15*time.Second))
+		if got, _ := rates.Rate(name, nil); got != 2 {
+			t.Errorf("expected repeated 2/s, got %v", got)
+		}
+	})
+
+	t.Run("MissingSampleSpansGap", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 0}), start)
+		s.Observe(&PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{}}, start.Add(10*time.Second))
+		rates := s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 40}), start.Add(20*time.Second))
+		if got, _ := rates.Rate(name, nil); got != 2 {
+			t.Errorf("expected 2/s over the 20s gap, got %v", got)
+		}
+	})
+
+	t.Run("StaleSeriesForgotten", func(t *testing.T) {
+		s := NewMetricsSampler(time.Minute)
+		s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 0}), start)
+		s.Observe(&PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{}}, start.Add(2*time.Minute))
+		rates := s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 40}), start.Add(3*time.Minute))
+		if _, err := rates.Rate(name, nil); err == nil {
+			t.Error("expected stale series to start over without a rate")
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		s := NewMetricsSampler(0)
+		s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 0}), start)
+		s.Reset()
+		rates := s.Observe(counterScrape(name, 0, map[string]float64{"neo4j": 40}), start.Add(time.Second))
+		if _, err := rates.Rate(name, nil); err == nil {
+			t.Error("expected no rate after Reset")
+		}
+	})
+}
+
+func TestPrometheusService_GetInstanceHealth_QueryRate(t *testing.T) {
+	const url = "https://c9f0d13a.metrics.neo4j.io/prometheus"
+	scrape := func(count float64, ts int64) string {
+		return fmt.Sprintf("# TYPE neo4j_db_query_execution_success_total counter\nneo4j_db_query_execution_success_total{database=\"neo4j\"} %v %d\n", count, ts)
+	}
+
+	mock := newMockAPIServiceRouter().on("GET", url, []byte(scrape(1000, 1_700_000_000_000)))
+	svc := &prometheusService{api: mock, timeout: 30 * time.Second, logger: testLogger()}
+	ctx := context.Background()
+
+	first, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if first.Query.QueriesPerSecond != 0 {
+		t.Errorf("expected no rate on first call, got %v", first.Query.QueriesPerSecond)
+	}
+
+	mock.on("GET", url, []byte(scrape(1600, 1_700_000_060_000)))
+	second, err := svc.GetInstanceHealth(ctx, "c9f0d13a", url)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if second.Query.QueriesPerSecond != 10 {
+		t.Errorf("expected 10 queries/s, got %v", second.Query.QueriesPerSecond)
+	}
+
+	// A different instance has its own sampler and starts without a rate.
+	other, err := svc.GetInstanceHealth(ctx, "d0e1f2a3", url)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if other.Query.QueriesPerSecond != 0 {
+		t.Errorf("expected no rate for a new instance, got %v", other.Query.QueriesPerSecond)
+	}
+}
+
+func TestPrometheusService_SamplerEviction(t *testing.T) {
+	svc := &prometheusService{logger: testLogger()}
+	start := time.Now()
+
+	kept := svc.samplerFor("a1b2c3d4", start)
+	svc.samplerFor("e5f6a7b8", start)
+	if got := svc.samplerFor("a1b2c3d4", start.Add(time.Minute)); got != kept {
+		t.Error("expected the same sampler for a recently used instance")
+	}
+
+	// Only the instance used since is kept once the other goes stale.
+	svc.samplerFor("a1b2c3d4", start.Add(defaultSampleStaleness))
+	svc.samplerFor("c9f0d13a", start.Add(defaultSampleStaleness+time.Second))
+	if len(svc.samplers) != 2 || svc.samplers["e5f6a7b8"] != nil {
+		t.Errorf("expected the unused sampler to be evicted, got %v", svc.samplers)
+	}
+	if got := svc.samplerFor("a1b2c3d4", start.Add(defaultSampleStaleness+time.Second)); got != kept {
+		t.Error("expected the sampler in use to survive eviction")
+	}
+}
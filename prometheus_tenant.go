@@ -0,0 +1,133 @@
+package aura
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/LackOfMorals/aura-client/internal/utils"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// InstanceIDLabel is the label that identifies the instance of each series
+// in a tenant metrics scrape.
+const InstanceIDLabel = "instance_id"
+
+// TenantHealthReport is the health of every instance found in one scrape of a
+// tenant's metrics endpoint.
+type TenantHealthReport struct {
+	TenantID    string    `json:"tenant_id"`
+	Endpoint    string    `json:"endpoint"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Instances holds one entry per instance_id value, sorted by instance ID.
+	Instances []*PrometheusHealthMetrics `json:"instances"`
+	// Unattributed counts series without an instance_id label, or whose
+	// instance_id is not a valid instance ID.
+	Unattributed int `json:"unattributed"`
+}
+
+// ============================================================================
+// Tenant health
+// ============================================================================
+
+// GetTenantHealth fetches the tenant's metrics integration endpoint once,
+// splits the scrape by the instance_id label and computes health metrics for
+// each instance from its share. This costs one metrics request however many
+// instances the tenant has, unlike calling GetInstanceHealth per instance.
+// Series without an instance_id label, or with one that is not a valid
+// instance ID, are counted in Unattributed and otherwise ignored, so one bad
+// series does not cost the report for the rest.
+func (c *AuraAPIClient) GetTenantHealth(ctx context.Context, tenantID string, opts ...HealthOption) (*TenantHealthReport, error) {
+	if err := ctx.Err(); err != nil {
+		c.logger.ErrorContext(ctx, "context already cancelled before function", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err := utils.ValidateTenantID(tenantID); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := c.Tenants.GetMetrics(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant metrics endpoint: %w", err)
+	}
+	if endpoint.Data.Endpoint == "" {
+		return nil, fmt.Errorf("tenant %s has no metrics endpoint", tenantID)
+	}
+
+	raw, err := c.Prometheus.FetchRawMetrics(ctx, endpoint.Data.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tenant metrics: %w", err)
+	}
+
+	byInstance, unattributed := SplitMetricsByLabel(raw, InstanceIDLabel)
+	report := &TenantHealthReport{
+		TenantID:     tenantID,
+		Endpoint:     endpoint.Data.Endpoint,
+		GeneratedAt:  time.Now(),
+		Instances:    make([]*PrometheusHealthMetrics, 0, len(byInstance)),
+		Unattributed: unattributed,
+	}
+
+	ids := make([]string, 0, len(byInstance))
+	for id := range byInstance {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := utils.ValidateInstanceID(id); err != nil {
+			series := 0
+			for _, s := range byInstance[id].Metrics {
+				series += len(s)
+			}
+			c.logger.WarnContext(ctx, "ignoring series with an invalid instance ID",
+				slog.String("instanceID", id), slog.Int("series", series), slog.String("error", err.Error()))
+			report.Unattributed += series
+			continue
+		}
+		health, err := c.Prometheus.InstanceHealthFromMetrics(ctx, id, byInstance[id], opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assess instance %s: %w", id, err)
+		}
+		report.Instances = append(report.Instances, health)
+	}
+
+	c.logger.InfoContext(ctx, "tenant health metrics retrieved",
+		slog.String("tenantID", tenantID),
+		slog.Int("instances", len(report.Instances)),
+		slog.Int("unattributed", report.Unattributed))
+
+	return report, nil
+}
+
+// SplitMetricsByLabel partitions metrics by the value of label, returning one
+// response per distinct value. Series without the label are dropped and
+// counted in the second return value.
+func SplitMetricsByLabel(metrics *PrometheusMetricsResponse, label string) (map[string]*PrometheusMetricsResponse, int) {
+	out := make(map[string]*PrometheusMetricsResponse)
+	missing := 0
+	if metrics == nil {
+		return out, 0
+	}
+	for name, series := range metrics.Metrics {
+		for _, m := range series {
+			value, ok := m.Labels[label]
+			if !ok || value == "" {
+				missing++
+				continue
+			}
+			part, ok := out[value]
+			if !ok {
+				part = &PrometheusMetricsResponse{Metrics: make(map[string][]PrometheusMetric)}
+				out[value] = part
+			}
+			part.Metrics[name] = append(part.Metrics[name], m)
+		}
+	}
+	return out, missing
+}
@@ -0,0 +1,146 @@
+package aura
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/LackOfMorals/aura-client/internal/api"
+)
+
+const (
+	tenantHealthID  = "12345678-1234-1234-1234-123456789abc"
+	tenantHealthURL = "https://customer-metrics-api.neo4j.io/api/v1/12345678/metrics"
+)
+
+const tenantScrape = `neo4j_aura_cpu_usage{instance_id="aaaaaaaa",availability_zone="a"} 0.97
+neo4j_aura_cpu_limit{instance_id="aaaaaaaa"} 1
+neo4j_aura_cpu_usage{instance_id="bbbbbbbb",availability_zone="a"} 0.5
+neo4j_aura_cpu_usage{instance_id="bbbbbbbb",availability_zone="b"} 0.7
+neo4j_aura_cpu_limit{instance_id="bbbbbbbb"} 2
+neo4j_aura_projects_total 3
+`
+
+// TestGetTenantHealth verifies one scrape is split into per-instance health
+func TestGetTenantHealth(t *testing.T) {
+	mock := newMockAPIServiceRouter().
+		on("GET", "tenants/"+tenantHealthID+"/metrics-integration", GetTenantMetricsURLResponse{Data: GetTenantMetricsURLData{Endpoint: tenantHealthURL}}).
+		on("GET", tenantHealthURL, []byte(tenantScrape))
+	client := newTestClientWithAPI(mock)
+
+	report, err := client.GetTenantHealth(context.Background(), tenantHealthID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n := len(mock.callsTo("GET", tenantHealthURL)); n != 1 {
+		t.Errorf("expected a single scrape, got %d", n)
+	}
+	if report.Endpoint != tenantHealthURL || report.Unattributed != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Instances) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(report.Instances))
+	}
+
+	a, b := report.Instances[0], report.Instances[1]
+	if a.InstanceID != "aaaaaaaa" || a.OverallStatus != HealthStatusCritical {
+		t.Errorf("unexpected first instance: %+v", a)
+	}
+	// bbbbbbbb averages 0.6 cores across zones out of a limit of 2.
+	if b.InstanceID != "bbbbbbbb" || b.Resources.CPUUsagePercent != 30 || b.OverallStatus != HealthStatusHealthy {
+		t.Errorf("unexpected second instance: %+v", b)
+	}
+}
+
+func TestGetTenantHealth_InvalidInstanceID(t *testing.T) {
+	scrape := tenantScrape + "neo4j_aura_cpu_usage{instance_id=\"not-an-id\"} 0.5\nneo4j_aura_cpu_limit{instance_id=\"not-an-id\"} 1\n"
+	mock := newMockAPIServiceRouter().
+		on("GET", "tenants/"+tenantHealthID+"/metrics-integration", GetTenantMetricsURLResponse{Data: GetTenantMetricsURLData{Endpoint: tenantHealthURL}}).
+		on("GET", tenantHealthURL, []byte(scrape))
+
+	report, err := newTestClientWithAPI(mock).GetTenantHealth(context.Background(), tenantHealthID)
+	if err != nil {
+		t.Fatalf("expected the valid instances to be reported, got %v", err)
+	}
+	if len(report.Instances) != 2 || report.Instances[0].InstanceID != "aaaaaaaa" || report.Instances[1].InstanceID != "bbbbbbbb" {
+		t.Errorf("expected the two valid instances, got %+v", report.Instances)
+	}
+	if report.Unattributed != 3 {
+		t.Errorf("expected the malformed series to be unattributed, got %d", report.Unattributed)
+	}
+}
+
+// TestGetTenantHealth_Errors verifies endpoint and scrape failures are returned
+func TestGetTenantHealth_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	client := newTestClientWithAPI(newMockAPIServiceRouter())
+	if _, err := client.GetTenantHealth(ctx, "not-a-tenant"); err == nil {
+		t.Error("expected error for invalid tenant ID")
+	}
+
+	mock := newMockAPIServiceRouter().
+		onError("GET", "tenants/"+tenantHealthID+"/metrics-integration", &api.Error{StatusCode: http.StatusForbidden, Message: "forbidden"})
+	if _, err := newTestClientWithAPI(mock).GetTenantHealth(ctx, tenantHealthID); err == nil {
+		t.Error("expected error when the endpoint cannot be fetched")
+	}
+
+	mock = newMockAPIServiceRouter().
+		on("GET", "tenants/"+tenantHealthID+"/metrics-integration", GetTenantMetricsURLResponse{Data: GetTenantMetricsURLData{Endpoint: tenantHealthURL}}).
+		onError("GET", tenantHealthURL, &api.Error{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"})
+	if _, err := newTestClientWithAPI(mock).GetTenantHealth(ctx, tenantHealthID); err == nil {
+		t.Error("expected error when the scrape fails")
+	}
+
+	mock = newMockAPIServiceRouter().
+		on("GET", "tenants/"+tenantHealthID+"/metrics-integration", GetTenantMetricsURLResponse{})
+	if _, err := newTestClientWithAPI(mock).GetTenantHealth(ctx, tenantHealthID); err == nil {
+		t.Error("expected error when the tenant has no endpoint")
+	}
+}
+
+func TestSplitMetricsByLabel(t *testing.T) {
+	svc := newTestPrometheusService()
+	raw, err := svc.parsePrometheusMetrics([]byte(tenantScrape), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, missing := SplitMetricsByLabel(raw, InstanceIDLabel)
+	if missing != 1 || len(parts) != 2 {
+		t.Fatalf("expected 2 parts and 1 unlabelled series, got %d and %d", len(parts), missing)
+	}
+	if n := len(parts["bbbbbbbb"].Metrics["neo4j_aura_cpu_usage"]); n != 2 {
+		t.Errorf("expected 2 cpu series for bbbbbbbb, got %d", n)
+	}
+	if _, ok := parts["aaaaaaaa"].Metrics["neo4j_aura_projects_total"]; ok {
+		t.Error("unlabelled series must not be attributed to an instance")
+	}
+
+	if parts, missing := SplitMetricsByLabel(nil, InstanceIDLabel); len(parts) != 0 || missing != 0 {
+		t.Error("expected empty result for nil metrics")
+	}
+}
+
+func TestPrometheusService_InstanceHealthFromMetrics(t *testing.T) {
+	svc := newTestPrometheusService()
+	ctx := context.Background()
+	raw := &PrometheusMetricsResponse{Metrics: map[string][]PrometheusMetric{
+		"neo4j_dbms_vm_heap_used_ratio": {{Value: 0.9}},
+	}}
+
+	health, err := svc.InstanceHealthFromMetrics(ctx, "c9f0d13a", raw)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if health.InstanceID != "c9f0d13a" || health.OverallStatus != HealthStatusWarning {
+		t.Errorf("unexpected health: %+v", health)
+	}
+
+	if _, err := svc.InstanceHealthFromMetrics(ctx, "c9f0d13a", nil); err == nil {
+		t.Error("expected error for nil metrics")
+	}
+	if _, err := svc.InstanceHealthFromMetrics(ctx, "bad", raw); err == nil {
+		t.Error("expected error for invalid instance ID")
+	}
+}
@@ -90,7 +90,7 @@ test_metric{label="b"} 20.0
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := svc.parsePrometheusMetrics([]byte(tt.input))
+			result, err := svc.parsePrometheusMetrics([]byte(tt.input), "")
 
 			if tt.expectError {
 				if err == nil {
@@ -198,9 +198,7 @@ func TestPrometheusService_GetMetricValue(t *testing.T) {
 	})
 }
 
-func TestAssessHealth(t *testing.T) {
-	svc := newTestPrometheusService()
-
+func TestDefaultHealthRules(t *testing.T) {
 	tests := []struct {
 		name           string
 		metrics        *PrometheusHealthMetrics
@@ -326,7 +324,10 @@ func TestAssessHealth(t *testing.T) {
 			tt.metrics.Issues = []string{}
 			tt.metrics.Recommendations = []string{}
 
-			status := svc.assessHealth(tt.metrics)
+			status, err := DefaultHealthRules().Evaluate(tt.metrics, nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
 
 			if status != tt.expectedStatus {
 				t.Errorf("Expected status %s, got %s", tt.expectedStatus, status)
@@ -0,0 +1,407 @@
+package aura
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	utils "github.com/LackOfMorals/aura-client/internal/utils"
+)
+
+// ============================================================================
+// Types
+// ============================================================================
+
+// WatchEventType identifies what a watch event reports.
+type WatchEventType string
+
+// Event types sent by Instances.Watch and GraphAnalytics.Watch.
+const (
+	// WatchEventObserved is sent for the first poll of each resource, with
+	// its status in After.
+	WatchEventObserved WatchEventType = "observed"
+	// WatchEventStatus reports a status transition.
+	WatchEventStatus WatchEventType = "status"
+	// WatchEventMemory reports a memory change.
+	WatchEventMemory WatchEventType = "memory"
+	// WatchEventConnectionURL reports a new instance connection URL.
+	WatchEventConnectionURL WatchEventType = "connection_url"
+	// WatchEventHost reports a new GDS session host.
+	WatchEventHost WatchEventType = "host"
+	// WatchEventDeleted reports that the resource no longer exists. The
+	// resource is not polled again.
+	WatchEventDeleted WatchEventType = "deleted"
+	// WatchEventError reports a failed poll; Err holds the error. Polling
+	// continues at the steady interval.
+	WatchEventError WatchEventType = "error"
+)
+
+// Default polling intervals, changed with WithWatchIntervals.
+const (
+	defaultWatchInterval             = 30 * time.Second
+	defaultWatchTransitionalInterval = 5 * time.Second
+)
+
+// InstanceEvent is a change observed by Instances.Watch.
+type InstanceEvent struct {
+	Type       WatchEventType `json:"type"`
+	InstanceID string         `json:"instance_id"`
+	// Before and After hold the old and new value of the field named by
+	// Type. Before is empty for observed events.
+	Before string    `json:"before,omitempty"`
+	After  string    `json:"after,omitempty"`
+	Time   time.Time `json:"time"`
+	// Instance is the state polled at Time; nil for deleted and error events.
+	Instance *InstanceData `json:"instance,omitempty"`
+	Err      error         `json:"-"`
+}
+
+// GDSSessionEvent is a change observed by GraphAnalytics.Watch.
+type GDSSessionEvent struct {
+	Type      WatchEventType `json:"type"`
+	SessionID string         `json:"session_id"`
+	// Before and After hold the old and new value of the field named by
+	// Type. Before is empty for observed events.
+	Before string    `json:"before,omitempty"`
+	After  string    `json:"after,omitempty"`
+	Time   time.Time `json:"time"`
+	// Session is the state polled at Time; nil for deleted and error events.
+	Session *GetGDSSessionData `json:"session,omitempty"`
+	Err     error              `json:"-"`
+}
+
+// watchConfig holds the polling intervals used by Watch.
+type watchConfig struct {
+	interval     time.Duration // between polls of a resource in a steady state
+	transitional time.Duration // between polls of a resource in a transitional state
+}
+
+// watchSource adapts a resource type to the shared polling loop.
+type watchSource[T any] struct {
+	get          func(ctx context.Context, id string) (*T, error)
+	list         func(ctx context.Context) ([]string, error)
+	status       func(*T) string
+	transitional func(*T) bool
+	diff         func(before, after *T) []watchChange
+}
+
+// watchChange is one field that differs between two polls.
+type watchChange struct {
+	typ           WatchEventType
+	before, after string
+}
+
+// watchEvent is the resource-independent form of InstanceEvent and GDSSessionEvent.
+type watchEvent[T any] struct {
+	typ           WatchEventType
+	id            string
+	before, after string
+	time          time.Time
+	state         *T
+	err           error
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+// WithWatchIntervals sets how often Watch polls each resource: every steady
+// interval, or every transitional interval while the resource is in a
+// transitional state such as StatusCreating or StatusUpdating. Defaults to
+// 30 seconds and 5 seconds.
+func WithWatchIntervals(steady, transitional time.Duration) Option {
+	return func(o *options) error {
+		if steady <= 0 || transitional <= 0 {
+			return errors.New("watch intervals must be greater than zero")
+		}
+		if transitional > steady {
+			return errors.New("transitional watch interval must not exceed the steady interval")
+		}
+		o.config.watch = watchConfig{interval: steady, transitional: transitional}
+		return nil
+	}
+}
+
+// withDefaults fills unset intervals.
+func (w watchConfig) withDefaults() watchConfig {
+	if w.interval <= 0 {
+		w.interval = defaultWatchInterval
+	}
+	if w.transitional <= 0 {
+		w.transitional = min(defaultWatchTransitionalInterval, w.interval)
+	}
+	return w
+}
+
+// ============================================================================
+// Instances
+// ============================================================================
+
+// IsTransitional reports whether the status is a temporary one that the
+// instance will leave on its own, such as StatusCreating or StatusUpdating.
+func (s InstanceStatus) IsTransitional() bool {
+	switch s {
+	case StatusCreating, StatusDestroying, StatusPausing, StatusSuspending, StatusResuming,
+		StatusLoading, StatusRestoring, StatusUpdating, StatusOverwriting:
+		return true
+	}
+	return false
+}
+
+// Watch polls the given instances, or every instance when none are given,
+// and sends an event for each status, memory or connection URL change until
+// ctx is done. Each instance is polled at the steady interval, and more often
+// while its status is transitional; see WithWatchIntervals. The channel is
+// closed when ctx is done, or when every given instance has been deleted.
+func (i *instanceService) Watch(ctx context.Context, instanceIDs ...string) (<-chan InstanceEvent, error) {
+	for _, id := range instanceIDs {
+		if err := utils.ValidateInstanceID(id); err != nil {
+			i.logger.ErrorContext(ctx, "invalid instance ID", slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	src := watchSource[InstanceData]{
+		get: func(ctx context.Context, id string) (*InstanceData, error) {
+			resp, err := i.Get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return &resp.Data, nil
+		},
+		list: func(ctx context.Context) ([]string, error) {
+			resp, err := i.List(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]string, len(resp.Data))
+			for n, inst := range resp.Data {
+				ids[n] = inst.ID
+			}
+			return ids, nil
+		},
+		status:       func(d *InstanceData) string { return string(d.Status) },
+		transitional: func(d *InstanceData) bool { return d.Status.IsTransitional() },
+		diff: func(before, after *InstanceData) []watchChange {
+			return changes(
+				watchChange{WatchEventStatus, string(before.Status), string(after.Status)},
+				watchChange{WatchEventMemory, before.Memory, after.Memory},
+				watchChange{WatchEventConnectionURL, before.ConnectionURL, after.ConnectionURL},
+			)
+		},
+	}
+
+	events := make(chan InstanceEvent, 16)
+	go func() {
+		defer close(events)
+		watch(ctx, i.watch.withDefaults(), instanceIDs, src, i.logger, func(e watchEvent[InstanceData]) bool {
+			select {
+			case events <- InstanceEvent{Type: e.typ, InstanceID: e.id, Before: e.before, After: e.after, Time: e.time, Instance: e.state, Err: e.err}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return events, nil
+}
+
+// ============================================================================
+// GDS sessions
+// ============================================================================
+
+// Watch polls the given GDS sessions, or every session when none are given,
+// and sends an event for each status, memory or host change until ctx is
+// done. Sessions whose status ends in "ing", such as Creating, are polled at
+// the transitional interval; see WithWatchIntervals. The channel is closed
+// when ctx is done, or when every given session has been deleted.
+func (g *gDSSessionService) Watch(ctx context.Context, sessionIDs ...string) (<-chan GDSSessionEvent, error) {
+	for _, id := range sessionIDs {
+		if err := utils.ValidateGDSSessionID(id); err != nil {
+			g.logger.ErrorContext(ctx, "invalid GDS session ID", slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	src := watchSource[GetGDSSessionData]{
+		get: func(ctx context.Context, id string) (*GetGDSSessionData, error) {
+			resp, err := g.Get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return &resp.Data, nil
+		},
+		list: func(ctx context.Context) ([]string, error) {
+			resp, err := g.List(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]string, len(resp.Data))
+			for n, session := range resp.Data {
+				ids[n] = session.ID
+			}
+			return ids, nil
+		},
+		status:       func(d *GetGDSSessionData) string { return d.Status },
+		transitional: func(d *GetGDSSessionData) bool { return strings.HasSuffix(strings.ToLower(d.Status), "ing") },
+		diff: func(before, after *GetGDSSessionData) []watchChange {
+			return changes(
+				watchChange{WatchEventStatus, before.Status, after.Status},
+				watchChange{WatchEventMemory, before.Memory, after.Memory},
+				watchChange{WatchEventHost, before.Host, after.Host},
+			)
+		},
+	}
+
+	events := make(chan GDSSessionEvent, 16)
+	go func() {
+		defer close(events)
+		watch(ctx, g.watch.withDefaults(), sessionIDs, src, g.logger, func(e watchEvent[GetGDSSessionData]) bool {
+			select {
+			case events <- GDSSessionEvent{Type: e.typ, SessionID: e.id, Before: e.before, After: e.after, Time: e.time, Session: e.state, Err: e.err}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return events, nil
+}
+
+// ============================================================================
+// Polling loop
+// ============================================================================
+
+// changes returns the candidates whose values differ.
+func changes(candidates ...watchChange) []watchChange {
+	var out []watchChange
+	for _, c := range candidates {
+		if c.before != c.after {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// watch polls ids, or every listed resource when ids is empty, and calls
+// emit for each event until ctx is done, emit returns false, or every given
+// resource is gone. Each resource keeps its own schedule, so a transitional
+// one is polled more often without speeding up the rest.
+func watch[T any](ctx context.Context, cfg watchConfig, ids []string, src watchSource[T], logger *slog.Logger, emit func(watchEvent[T]) bool) {
+	type target struct {
+		last *T
+		next time.Time
+	}
+	all := len(ids) == 0
+	targets := make(map[string]*target)
+	for _, id := range ids {
+		targets[id] = &target{}
+	}
+	var nextList time.Time
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		if all && !now.Before(nextList) {
+			nextList = now.Add(cfg.interval)
+			found, err := src.list(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				if !emit(watchEvent[T]{typ: WatchEventError, time: now, err: err}) {
+					return
+				}
+			default:
+				for _, id := range found {
+					if _, ok := targets[id]; !ok {
+						targets[id] = &target{}
+					}
+				}
+				for id, t := range targets {
+					if !slices.Contains(found, id) && t.last != nil {
+						delete(targets, id)
+						if !emit(watchEvent[T]{typ: WatchEventDeleted, id: id, before: src.status(t.last), time: now}) {
+							return
+						}
+					}
+				}
+			}
+		}
+
+		ordered := make([]string, 0, len(targets))
+		for id := range targets {
+			ordered = append(ordered, id)
+		}
+		slices.Sort(ordered)
+		for _, id := range ordered {
+			t := targets[id]
+			if now.Before(t.next) {
+				continue
+			}
+			state, err := src.get(ctx, id)
+			polled := time.Now()
+			if ctx.Err() != nil {
+				return
+			}
+			var apiErr *Error
+			if errors.As(err, &apiErr) && apiErr.IsNotFound() {
+				delete(targets, id)
+				event := watchEvent[T]{typ: WatchEventDeleted, id: id, time: polled}
+				if t.last != nil {
+					event.before = src.status(t.last)
+				}
+				if !emit(event) {
+					return
+				}
+				continue
+			}
+			if err != nil {
+				logger.WarnContext(ctx, "watch poll failed", slog.String("id", id), slog.String("error", err.Error()))
+				t.next = polled.Add(cfg.interval)
+				if !emit(watchEvent[T]{typ: WatchEventError, id: id, time: polled, err: err}) {
+					return
+				}
+				continue
+			}
+
+			var found []watchChange
+			if t.last == nil {
+				found = []watchChange{{typ: WatchEventObserved, after: src.status(state)}}
+			} else {
+				found = src.diff(t.last, state)
+			}
+			t.last = state
+			t.next = polled.Add(cfg.interval)
+			if src.transitional(state) {
+				t.next = polled.Add(cfg.transitional)
+			}
+			for _, c := range found {
+				if !emit(watchEvent[T]{typ: c.typ, id: id, before: c.before, after: c.after, time: polled, state: state}) {
+					return
+				}
+			}
+		}
+
+		if !all && len(targets) == 0 {
+			return
+		}
+		wake := nextList
+		for _, t := range targets {
+			if wake.IsZero() || t.next.Before(wake) {
+				wake = t.next
+			}
+		}
+		timer.Reset(max(time.Until(wake), 0))
+	}
+}
@@ -0,0 +1,181 @@
+package aura_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	aura "github.com/LackOfMorals/aura-client"
+	"github.com/LackOfMorals/aura-client/auratest"
+)
+
+func newWatchClient(t *testing.T, srv *auratest.Server) *aura.AuraAPIClient {
+	t.Helper()
+	client, err := srv.Client(aura.WithWatchIntervals(20*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return client
+}
+
+// nextEvent returns the next event from ch, failing the test after a second.
+func nextEvent[E any](t *testing.T, ch <-chan E) E {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		if !ok {
+			t.Fatal("Expected an event, but the channel was closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	panic("unreachable")
+}
+
+func TestInstancesWatch(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	created, err := client.Instances.Create(ctx, &aura.CreateInstanceConfigData{
+		Name: "watched", TenantID: auratest.DefaultTenantID, CloudProvider: "gcp",
+		Region: "europe-west1", Type: "enterprise-db", Memory: "8GB",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	id := created.Data.ID
+
+	events, err := client.Instances.Watch(ctx, id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expect := func(typ aura.WatchEventType, before, after string) aura.InstanceEvent {
+		t.Helper()
+		e := nextEvent(t, events)
+		if e.Type != typ || e.InstanceID != id || e.Before != before || e.After != after || e.Time.IsZero() {
+			t.Fatalf("Expected %s %q -> %q, got %+v", typ, before, after, e)
+		}
+		return e
+	}
+
+	if e := expect(aura.WatchEventObserved, "", "creating"); e.Instance == nil || e.Instance.Name != "watched" {
+		t.Errorf("Expected the polled instance, got %+v", e.Instance)
+	}
+	expect(aura.WatchEventStatus, "creating", "running")
+
+	if _, err := client.Instances.Update(ctx, id, &aura.UpdateInstanceData{Memory: "16GB"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expect(aura.WatchEventMemory, "8GB", "16GB")
+
+	if _, err := client.Instances.Pause(ctx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expect(aura.WatchEventStatus, "running", "paused")
+
+	if _, err := client.Instances.Delete(ctx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if e := expect(aura.WatchEventDeleted, "paused", ""); e.Instance != nil {
+		t.Errorf("Expected no instance for a deleted event, got %+v", e.Instance)
+	}
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to close once every watched instance is deleted")
+	}
+}
+
+func TestInstancesWatch_AdaptiveInterval(t *testing.T) {
+	srv := auratest.NewServer(auratest.WithTransitionDelay(100 * time.Millisecond))
+	defer srv.Close()
+	steady := srv.AddInstance(aura.InstanceData{Name: "steady", TenantID: auratest.DefaultTenantID, Memory: "8GB"})
+	busy := srv.AddInstance(aura.InstanceData{Name: "busy", TenantID: auratest.DefaultTenantID, Memory: "8GB", Status: aura.StatusRunning})
+
+	client, err := srv.Client(aura.WithWatchIntervals(time.Hour, 5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := client.Instances.Pause(context.Background(), busy.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Instances.Watch(ctx, steady.ID, busy.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for {
+		e := nextEvent(t, events)
+		if e.Type == aura.WatchEventStatus && e.InstanceID == busy.ID && e.After == "paused" {
+			break
+		}
+	}
+
+	polls := map[string]int{}
+	for _, r := range srv.Requests() {
+		polls[r.Path]++
+	}
+	if n := polls["/v1/instances/"+steady.ID]; n != 1 {
+		t.Errorf("Expected the steady instance to be polled once, got %d", n)
+	}
+	if n := polls["/v1/instances/"+busy.ID]; n < 2 {
+		t.Errorf("Expected the pausing instance to be polled repeatedly, got %d", n)
+	}
+}
+
+func TestGDSSessionsWatch_All(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+	session := srv.AddGDSSession(aura.GetGDSSessionData{Name: "analysis", Memory: "8GB", TenantID: auratest.DefaultTenantID})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.GraphAnalytics.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	e := nextEvent(t, events)
+	if e.Type != aura.WatchEventObserved || e.SessionID != session.ID || e.After != auratest.GDSSessionReady || e.Session == nil {
+		t.Fatalf("Expected the session to be observed, got %+v", e)
+	}
+
+	if _, err := client.GraphAnalytics.Delete(ctx, session.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	e = nextEvent(t, events)
+	if e.Type != aura.WatchEventDeleted || e.SessionID != session.ID || e.Before != auratest.GDSSessionReady {
+		t.Fatalf("Expected the session to be deleted, got %+v", e)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatch_Validation(t *testing.T) {
+	srv := auratest.NewServer()
+	defer srv.Close()
+	client := newWatchClient(t, srv)
+
+	if _, err := client.Instances.Watch(context.Background(), "not-an-id"); err == nil {
+		t.Error("Expected an error for an invalid instance ID")
+	}
+	if _, err := client.GraphAnalytics.Watch(context.Background(), ""); err == nil {
+		t.Error("Expected an error for an empty session ID")
+	}
+	for _, opt := range []aura.Option{
+		aura.WithWatchIntervals(0, time.Second),
+		aura.WithWatchIntervals(time.Second, 2*time.Second),
+	} {
+		if _, err := srv.Client(opt); err == nil {
+			t.Error("Expected an error for invalid watch intervals")
+		}
+	}
+}